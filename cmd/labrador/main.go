@@ -4,21 +4,20 @@ import (
 	"flag"
 	"fmt"
 	"log"
-	"path/filepath"
+	"os"
 
-	"tsumegolang/internal/labrador"
+	"tsumegolang/pkg/labrador"
 )
 
 var (
-	flagFile        = flag.String("file", "", "file containing a list of URLs to download")
-	flagRetryCount  = flag.Int("retry-count", 3, "number of times to retry a failed download")
-	flagBackoff     = flag.Int("backoff", 1000, "backoff time in milliseconds between retries")
-	flagWorkerCount = flag.Int("worker-count", 1, "number of concurrent workers to use for downloading")
-	flagOutputDir   = flag.String("output-dir", "downloads", "base directory for downloaded files")
+	flagFile = flag.String("file", "", "file containing a list of URLs to download")
 )
 
 func main() {
-	flag.Parse()
+	config, err := labrador.LoadConfig(flag.CommandLine, os.Args[1:], os.LookupEnv)
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
 
 	if *flagFile == "" {
 		log.Fatal("Error: -file flag is required")
@@ -33,12 +32,10 @@ func main() {
 		log.Fatal("Error: no valid sections found in YAML file")
 	}
 
-	downloader := labrador.NewMultiDownloader(labrador.MultiDownloaderSettings{
-		RetryCount:  *flagRetryCount,
-		BackoffMs:   *flagBackoff,
-		WorkerCount: *flagWorkerCount,
-		OutputDir:   *flagOutputDir,
-	})
+	downloader, err := labrador.NewDownloader(config)
+	if err != nil {
+		log.Fatalf("Error creating downloader: %v", err)
+	}
 
 	downloader.Start()
 	defer downloader.Shutdown()
@@ -46,10 +43,9 @@ func main() {
 	fmt.Println("Starting downloads...")
 	records := downloader.DownloadSections(sections)
 
-	indexPath := filepath.Join(*flagOutputDir, "index.md")
-	err = labrador.GenerateMarkdownIndex(records, indexPath)
+	reports, err := downloader.WriteReports(records)
 	if err != nil {
-		log.Fatalf("Error generating markdown index: %v", err)
+		log.Fatalf("Error generating reports: %v", err)
 	}
 
 	successCount := 0
@@ -60,5 +56,9 @@ func main() {
 	}
 
 	fmt.Printf("Downloads completed: %d/%d successful\n", successCount, len(records))
-	fmt.Printf("Index generated at: %s\n", indexPath)
+	stats := downloader.Stats()
+	fmt.Printf("Workers: %d, jobs failed: %d/%d, average latency: %v\n", stats.Workers, stats.Failed, stats.Completed, stats.AverageLatency)
+	for _, report := range reports {
+		fmt.Printf("Report generated at: %s\n", report)
+	}
 }
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"tsumegolang/internal/maze"
+)
+
+// batch implements "maze batch": it generates many mazes at once and lays them
+// out as a printable book of numbered puzzles followed by an answer key.
+func batch(args []string) error {
+	fs := flag.NewFlagSet("batch", flag.ExitOnError)
+	count := fs.Int("count", 10, "Number of mazes")
+	sizesFlag := fs.String("sizes", "10x10", "Maze sizes as WxH separated by commas, each used for an equal share of the mazes in order")
+	out := fs.String("out", "book.html", "Output filename: .html for one document, or .svg for one file per page")
+	title := fs.String("title", "Mazes", "Title printed at the top of each page")
+	perPage := fs.Int("per-page", 1, "Puzzles per page")
+	answersPerPage := fs.Int("answers-per-page", 4, "Solutions per page of the answer key")
+	workers := fs.Int("workers", runtime.NumCPU(), "Number of mazes generated at once")
+	seed := fs.Uint64("seed", rand.Uint64(), "Seed for the whole book; each maze gets its own seed from it")
+	algorithmName := fs.String("algorithm", "kruskal", "Generation algorithm: "+strings.Join(maze.AlgorithmNames(), ", "))
+	shapeName := fs.String("shape", "square", "Maze shape: "+strings.Join(maze.ShapeNames(), ", "))
+	fs.Parse(args)
+
+	sizes, err := parseSizes(*sizesFlag)
+	if err != nil {
+		return err
+	}
+	algorithm, err := maze.ParseAlgorithm(*algorithmName)
+	if err != nil {
+		return err
+	}
+	shape, err := maze.ParseShape(*shapeName)
+	if err != nil {
+		return err
+	}
+
+	book := maze.DefaultBookOptions()
+	book.Title = *title
+	book.PuzzlesPerPage = *perPage
+	book.AnswersPerPage = *answersPerPage
+	if err := book.Validate(); err != nil {
+		return err
+	}
+
+	puzzles, err := maze.GenerateBatch(*count, sizes, *seed, *workers, maze.WithAlgorithm(algorithm), maze.WithShape(shape))
+	if err != nil {
+		return err
+	}
+	if err := book.WriteBook(*out, puzzles); err != nil {
+		return err
+	}
+
+	fmt.Printf("Generated %d mazes with seed %d, written to %s\n", len(puzzles), *seed, *out)
+	return nil
+}
+
+// parseSizes reads sizes written as WxH and separated by commas.
+func parseSizes(s string) ([]image.Point, error) {
+	sizes := []image.Point{}
+	for _, part := range strings.Split(s, ",") {
+		width, height, ok := strings.Cut(strings.TrimSpace(part), "x")
+		w, werr := strconv.Atoi(width)
+		h, herr := strconv.Atoi(height)
+		if !ok || werr != nil || herr != nil || w < 1 || h < 1 {
+			return nil, fmt.Errorf("invalid size %q: want WxH, such as 20x20", part)
+		}
+		sizes = append(sizes, image.Pt(w, h))
+	}
+	return sizes, nil
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"flag"
 	"fmt"
+	"image/color"
+	"os"
+	"strings"
 
 	"tsumegolang/internal/maze"
 )
@@ -10,26 +13,273 @@ import (
 var (
 	widthFlag     = flag.Int("width", 20, "Width of the maze")
 	heightFlag    = flag.Int("height", 20, "Height of the maze")
-	filenameFlag  = flag.String("filename", "maze.png", "Output filename for the maze image")
+	filenameFlag  = flag.String("filename", "maze.png", "Output filename for the maze")
+	formatFlag    = flag.String("format", "", "Output format: "+strings.Join(maze.FormatNames(), ", ")+" (default from the filename extension)")
 	recursionFlag = flag.Int("recursion", 0, "Recursion level")
+	levelsFlag    = flag.String("levels", "", "Levels of a hierarchical maze, outermost first, as WxH:algorithm:connections separated by commas; replaces -width, -height and -recursion")
+	floorsFlag    = flag.Int("floors", 1, "Number of floors joined by stairs, drawn side by side; the entrance is on the first and the exit on the last")
+	shapeFlag     = flag.String("shape", "square", "Maze shape: "+strings.Join(maze.ShapeNames(), ", ")+"; polar mazes have -height rings")
+	maskFlag      = flag.String("mask", "", "PNG or text file shaping the maze: dark pixels or X characters mark cells left out; replaces -width and -height")
+	algorithmFlag = flag.String("algorithm", "kruskal", "Generation algorithm: "+strings.Join(maze.AlgorithmNames(), ", "))
+	braidFlag     = flag.Float64("braid", 0, "Fraction of dead ends, from 0 to 1, to open into loops")
+	passagesFlag  = flag.Int("passages", 0, "Number of extra walls to open at random")
+	biasFlag      = flag.String("bias", "", "Bias for kruskal or prim as kind=strength, strength from 0 to 1; kinds: "+strings.Join(maze.BiasNames(), ", "))
+	weaveFlag     = flag.Float64("weave", 0, "Density of crossings, from 0 to 1, where kruskal or prim passages tunnel under perpendicular corridors")
+	seedFlag      = flag.Uint64("seed", 0, "Seed for reproducible mazes (random if unset)")
+	fromFlag      = flag.String("from", "", "Regenerate the maze stored in this PNG's metadata, ignoring the other generation flags")
+	entranceFlag  = flag.String("entrance", "", "Entrance cell on the border as x,y (default top-left)")
+	exitFlag      = flag.String("exit", "", "Exit cell on the border as x,y (default bottom-right)")
+	farthestFlag  = flag.Bool("farthest", false, "Place the entrance and exit at the border cells farthest apart")
+	solverFlag    = flag.String("solver", "bfs", "Solver: "+strings.Join(maze.SolverNames(), ", "))
+	solutionFlag  = flag.String("solution", "", "Output filename for a copy of the maze with the solution drawn")
+	statsFlag     = flag.String("stats", "", "Print statistics about the maze as txt or json")
+	compactFlag   = flag.Bool("compact", false, "Store the maze in two bits a cell and draw it in bands, for very large mazes written as PNG or SVG; only eller, binary-tree and sidewinder stream rows, and eller is used unless -algorithm names one of them")
+
+	animateFlag       = flag.String("animate", "", "Output filename for an animated GIF of the maze being carved")
+	animateStepsFlag  = flag.Int("animate-steps", 1, "Passages carved or cells explored per frame of the animation")
+	animateDelayFlag  = flag.Int("animate-delay", 4, "Time each frame of the animation is shown, in hundredths of a second")
+	animateFramesFlag = flag.Int("animate-frames", 300, "Maximum number of frames in the animation, showing more steps per frame if needed (0 for no limit)")
+	animateSolveFlag  = flag.Bool("animate-solve", false, "Follow the carving with the solver exploring the maze")
+
+	cellSizeFlag      = flag.Int("cell-size", maze.SquareSize, "Width of a cell in pixels")
+	wallThicknessFlag = flag.Int("wall-thickness", 1, "Thickness of the walls in pixels")
+	marginFlag        = flag.Int("margin", 0, "Space around the maze in pixels")
+	wallColorFlag     = flag.String("wall-color", "black", "Wall color, as #rrggbb or a name")
+	passageColorFlag  = flag.String("passage-color", "white", "Color of the cells in the maze")
+	backgroundFlag    = flag.String("background", "white", "Color of the margin and of cells left out by a mask")
+	solutionColorFlag = flag.String("solution-color", "red", "Color of the solution path")
+	roundFlag         = flag.Bool("round", false, "Round the ends of walls and of the solution path")
+	heatmapFlag       = flag.Bool("heatmap", false, "Shade cells by their distance from the entrance")
+	blockColorFlag    = flag.String("block-color", "", "Color of the walls between the sub-mazes of a recursive maze (default the wall color)")
 )
 
-func doMaze(width, height int, filename string, recursionLevel int) {
-	mg := maze.NewMazeGenerator(
-		width,
-		height,
-		maze.WithFilename(filename),
-		maze.WithRecursionLevel(recursionLevel),
-	)
-	err := mg.Generate()
+func isFlagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func renderOptions() (maze.RenderOptions, error) {
+	render := maze.DefaultRenderOptions()
+	render.CellSize = *cellSizeFlag
+	render.WallThickness = *wallThicknessFlag
+	render.Margin = *marginFlag
+	render.RoundCorners = *roundFlag
+	render.HeatMap = *heatmapFlag
+
+	colors := []struct {
+		value string
+		color *color.Color
+	}{
+		{*wallColorFlag, &render.WallColor},
+		{*passageColorFlag, &render.PassageColor},
+		{*backgroundFlag, &render.Background},
+		{*solutionColorFlag, &render.SolutionColor},
+	}
+	for _, c := range colors {
+		parsed, err := maze.ParseColor(c.value)
+		if err != nil {
+			return render, err
+		}
+		*c.color = parsed
+	}
+	if *blockColorFlag != "" {
+		parsed, err := maze.ParseColor(*blockColorFlag)
+		if err != nil {
+			return render, err
+		}
+		render.BlockColor = parsed
+	}
+
+	return render, render.Validate()
+}
+
+// outputOptions are the options that only affect what is written, so they also
+// apply when regenerating a maze from a file.
+func outputOptions() ([]maze.GeneratorOptions, error) {
+	solver, err := maze.ParseSolver(*solverFlag)
 	if err != nil {
-		fmt.Printf("Error generating maze: %v\n", err)
-		return
+		return nil, err
+	}
+	render, err := renderOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []maze.GeneratorOptions{
+		maze.WithFilename(*filenameFlag),
+		maze.WithSolver(solver),
+		maze.WithSolutionFilename(*solutionFlag),
+		maze.WithRenderOptions(render),
+	}
+	if *compactFlag {
+		opts = append(opts, maze.WithCompact())
+	}
+	if *formatFlag != "" {
+		format, err := maze.ParseFormat(*formatFlag)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, maze.WithFormat(format))
+	}
+	if *animateFlag != "" {
+		animation := maze.DefaultAnimationOptions()
+		animation.StepsPerFrame = *animateStepsFlag
+		animation.Delay = *animateDelayFlag
+		animation.MaxFrames = *animateFramesFlag
+		animation.Solve = *animateSolveFlag
+		if err := animation.Validate(); err != nil {
+			return nil, err
+		}
+		opts = append(opts, maze.WithAnimation(*animateFlag, animation))
+	}
+
+	return opts, nil
+}
+
+func newGenerator() (*maze.MazeGenerator, error) {
+	opts, err := outputOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	if *fromFlag != "" {
+		metadata, err := maze.ReadMetadata(*fromFlag)
+		if err != nil {
+			return nil, err
+		}
+		return maze.NewMazeGeneratorFromMetadata(metadata, opts...)
+	}
+
+	algorithm, err := maze.ParseAlgorithm(*algorithmFlag)
+	if err != nil {
+		return nil, err
+	}
+	// Compact mazes are carved row by row, which the default kruskal cannot do.
+	if *compactFlag {
+		switch {
+		case !isFlagSet("algorithm"):
+			algorithm = maze.Eller
+		case !algorithm.CarvesRows():
+			return nil, fmt.Errorf("-compact needs an algorithm that carves row by row: eller, binary-tree or sidewinder, not %v", algorithm)
+		}
 	}
+
+	shape, err := maze.ParseShape(*shapeFlag)
+	if err != nil {
+		return nil, err
+	}
+
+	opts = append(opts,
+		maze.WithRecursionLevel(*recursionFlag),
+		maze.WithAlgorithm(algorithm),
+		maze.WithShape(shape),
+		maze.WithBraid(*braidFlag),
+		maze.WithExtraPassages(*passagesFlag),
+		maze.WithFloors(*floorsFlag),
+		maze.WithWeave(*weaveFlag),
+	)
+	if *levelsFlag != "" {
+		levels, err := maze.ParseLevels(*levelsFlag)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, maze.WithLevels(levels...))
+	}
+	if *maskFlag != "" {
+		mask, err := maze.ReadMask(*maskFlag)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, maze.WithMask(mask))
+	}
+	if *biasFlag != "" {
+		bias, err := maze.ParseBias(*biasFlag)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, maze.WithBias(bias))
+	}
+	if isFlagSet("seed") {
+		opts = append(opts, maze.WithSeed(*seedFlag))
+	}
+
+	switch {
+	case *farthestFlag:
+		opts = append(opts, maze.WithFarthestOpenings())
+	default:
+		if *entranceFlag != "" {
+			entrance, err := maze.ParsePoint(*entranceFlag)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, maze.WithEntrance(entrance))
+		}
+		if *exitFlag != "" {
+			exit, err := maze.ParsePoint(*exitFlag)
+			if err != nil {
+				return nil, err
+			}
+			opts = append(opts, maze.WithExit(exit))
+		}
+	}
+
+	return maze.NewMazeGenerator(*widthFlag, *heightFlag, opts...), nil
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "solve" {
+		if err := solve(os.Args[2:]); err != nil {
+			fmt.Printf("Error solving maze: %v\n", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "play" {
+		if err := play(os.Args[2:]); err != nil {
+			fmt.Printf("Error playing maze: %v\n", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		if err := serve(os.Args[2:]); err != nil {
+			fmt.Printf("Error serving mazes: %v\n", err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "batch" {
+		if err := batch(os.Args[2:]); err != nil {
+			fmt.Printf("Error generating mazes: %v\n", err)
+		}
+		return
+	}
+
 	flag.Parse()
 
-	doMaze(*widthFlag, *heightFlag, *filenameFlag, *recursionFlag)
+	mg, err := newGenerator()
+	if err != nil {
+		fmt.Printf("Error generating maze: %v\n", err)
+		return
+	}
+
+	if err := mg.Generate(); err != nil {
+		fmt.Printf("Error generating maze: %v\n", err)
+		return
+	}
+
+	fmt.Printf("Generated maze with seed %d\n", mg.Seed())
+	fmt.Printf("Solution from %v to %v is %d cells long\n", mg.Entrance(), mg.Exit(), len(mg.Solution()))
+
+	if *statsFlag != "" {
+		format, err := maze.ParseFormat(*statsFlag)
+		if err == nil {
+			err = maze.EncodeStats(os.Stdout, mg.Stats(), format)
+		}
+		if err != nil {
+			fmt.Printf("Error writing statistics: %v\n", err)
+		}
+	}
 }
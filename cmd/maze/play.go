@@ -0,0 +1,257 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"tsumegolang/internal/maze"
+)
+
+// command is what a key asks the game to do. The moves match maze.Direction.
+type command int
+
+const (
+	moveNorth command = iota
+	moveEast
+	moveSouth
+	moveWest
+	showHint
+	giveUp
+	quit
+)
+
+var keyCommands = map[byte]command{
+	'w': moveNorth, 'W': moveNorth,
+	'd': moveEast, 'D': moveEast,
+	's': moveSouth, 'S': moveSouth,
+	'a': moveWest, 'A': moveWest,
+	'h': showHint, 'H': showHint,
+	'g': giveUp, 'G': giveUp,
+	'q': quit, 'Q': quit,
+	3:    quit, // Ctrl-C, which raw mode passes through as a key
+	0x1b: quit, // Escape on its own
+}
+
+// escapeTimeout is how long an Escape waits for the rest of an arrow key's
+// escape sequence before it counts as a key of its own.
+const escapeTimeout = 50 * time.Millisecond
+
+// arrowCommands are the final bytes of the arrow keys' escape sequences.
+var arrowCommands = map[byte]command{'A': moveNorth, 'C': moveEast, 'B': moveSouth, 'D': moveWest}
+
+// play implements "maze play": it generates a maze and lets the user walk it in
+// the terminal from the entrance to the exit.
+func play(args []string) error {
+	fs := flag.NewFlagSet("play", flag.ExitOnError)
+	width := fs.Int("width", 15, "Width of the maze")
+	height := fs.Int("height", 10, "Height of the maze")
+	seed := fs.Uint64("seed", rand.Uint64(), "Seed for reproducible mazes")
+	algorithmName := fs.String("algorithm", "kruskal", "Generation algorithm: "+strings.Join(maze.AlgorithmNames(), ", "))
+	fs.Parse(args)
+
+	algorithm, err := maze.ParseAlgorithm(*algorithmName)
+	if err != nil {
+		return err
+	}
+	mg := maze.NewMazeGenerator(*width, *height, maze.WithAlgorithm(algorithm), maze.WithSeed(*seed))
+	if err := mg.Generate(); err != nil {
+		return err
+	}
+	game, err := mg.Game()
+	if err != nil {
+		return err
+	}
+
+	summary, err := playInTerminal(game)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s (seed %d)\n", summary, mg.Seed())
+	return nil
+}
+
+// playInTerminal plays game on the alternate screen with the terminal in raw
+// mode, putting the terminal back however the game ends.
+func playInTerminal(game *maze.Game) (summary string, err error) {
+	restore, err := makeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		if rerr := restore(); err == nil {
+			err = rerr
+		}
+	}()
+	fmt.Print("\x1b[?1049h\x1b[?25l") // switch to the alternate screen and hide the cursor
+	return playGame(game)
+}
+
+// playGame runs game until the player quits, redrawing it after every key and
+// every second for the timer, and returns how it ended.
+func playGame(game *maze.Game) (string, error) {
+	commands := make(chan command)
+	go readCommands(os.Stdin, commands)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var elapsed time.Duration
+	message := ""
+	for {
+		if !game.Over() {
+			elapsed = time.Since(start)
+		}
+		switch {
+		case game.Won():
+			message = "You escaped! Press q to quit."
+		case game.GaveUp():
+			message = "The solution is marked. Press q to quit."
+		}
+		if err := drawGame(os.Stdout, game, elapsed, message); err != nil {
+			return "", err
+		}
+
+		select {
+		case cmd, ok := <-commands:
+			if !ok || cmd == quit {
+				return summarize(game, elapsed), nil
+			}
+			message = ""
+			switch cmd {
+			case showHint:
+				if _, err := game.Hint(); err != nil {
+					message = err.Error()
+				} else {
+					message = "The next step is marked with +."
+				}
+			case giveUp:
+				if err := game.GiveUp(); err != nil {
+					message = err.Error()
+				}
+			default:
+				if !game.Move(maze.Direction(cmd)) && !game.Over() {
+					message = "There is a wall in the way."
+				}
+			}
+		case <-ticker.C:
+		case <-signals:
+			return summarize(game, elapsed), nil
+		}
+	}
+}
+
+// readCommands sends the commands of the keys read from r until it fails. An
+// escape sequence split between reads is put back together, and an Escape with
+// nothing after it for escapeTimeout counts as a key.
+func readCommands(r io.Reader, commands chan<- command) {
+	defer close(commands)
+	chunks := make(chan []byte)
+	go func() {
+		defer close(chunks)
+		for {
+			buf := make([]byte, 64)
+			n, err := r.Read(buf)
+			if n > 0 {
+				chunks <- buf[:n]
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	var pending []byte
+	var timeout <-chan time.Time
+	send := func(final bool) {
+		var cmds []command
+		cmds, pending = parseKeys(pending, final)
+		for _, cmd := range cmds {
+			commands <- cmd
+		}
+	}
+	for {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				send(true)
+				return
+			}
+			pending = append(pending, chunk...)
+			send(false)
+		case <-timeout:
+			send(true)
+		}
+
+		timeout = nil
+		if len(pending) > 0 {
+			timeout = time.After(escapeTimeout)
+		}
+	}
+}
+
+// parseKeys returns the commands of the keys in b, ignoring other keys. An
+// escape sequence cut off at the end of b is returned as rest, to be completed
+// by the next read, unless final is set.
+func parseKeys(b []byte, final bool) (commands []command, rest []byte) {
+	commands = []command{}
+	for i := 0; i < len(b); i++ {
+		if b[i] == 0x1b {
+			introducer := i+1 < len(b) && (b[i+1] == '[' || b[i+1] == 'O')
+			switch {
+			case (i+1 == len(b) || introducer && i+2 == len(b)) && !final:
+				return commands, b[i:]
+			case introducer && i+2 < len(b):
+				if cmd, ok := arrowCommands[b[i+2]]; ok {
+					commands = append(commands, cmd)
+				}
+				i += 2
+				continue
+			}
+		}
+		if cmd, ok := keyCommands[b[i]]; ok {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands, nil
+}
+
+func drawGame(w io.Writer, game *maze.Game, elapsed time.Duration, message string) error {
+	var sb strings.Builder
+	if err := game.Render(&sb); err != nil {
+		return err
+	}
+	fmt.Fprintf(&sb, "Moves: %d   Time: %s\n", game.Moves(), formatDuration(elapsed))
+	sb.WriteString("Arrows or WASD move · h hint · g give up · q quit\n")
+	sb.WriteString(message + "\n")
+
+	// Raw mode leaves line feeds without carriage returns.
+	_, err := io.WriteString(w, "\x1b[H\x1b[2J"+strings.ReplaceAll(sb.String(), "\n", "\r\n"))
+	return err
+}
+
+func summarize(game *maze.Game, elapsed time.Duration) string {
+	switch {
+	case game.Won():
+		return fmt.Sprintf("Escaped in %d moves and %s", game.Moves(), formatDuration(elapsed))
+	case game.GaveUp():
+		return fmt.Sprintf("Gave up after %d moves and %s", game.Moves(), formatDuration(elapsed))
+	}
+	return fmt.Sprintf("Quit after %d moves and %s", game.Moves(), formatDuration(elapsed))
+}
+
+// formatDuration writes d as minutes and seconds, such as 1:05.
+func formatDuration(d time.Duration) string {
+	seconds := int(d.Seconds())
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        string
+		final        bool
+		wantCommands []command
+		wantRest     string
+	}{
+		{name: "WASD", input: "wDsA", wantCommands: []command{moveNorth, moveEast, moveSouth, moveWest}},
+		{name: "CSI arrows", input: "\x1b[A\x1b[C", wantCommands: []command{moveNorth, moveEast}},
+		{name: "SS3 arrows", input: "\x1bOB\x1bOD", wantCommands: []command{moveSouth, moveWest}},
+		{
+			name:         "mixed keys and arrows",
+			input:        "w\x1b[Cxh\x1bOBq",
+			wantCommands: []command{moveNorth, moveEast, showHint, moveSouth, quit},
+		},
+		{name: "split after ESC", input: "d\x1b", wantCommands: []command{moveEast}, wantRest: "\x1b"},
+		{name: "split after ESC [", input: "d\x1b[", wantCommands: []command{moveEast}, wantRest: "\x1b["},
+		{name: "split after ESC O", input: "\x1bO", wantCommands: []command{}, wantRest: "\x1bO"},
+		{name: "lone ESC when final", input: "\x1b", final: true, wantCommands: []command{quit}},
+		{name: "unknown sequence", input: "\x1b[Zs", wantCommands: []command{moveSouth}},
+		{name: "Ctrl-C", input: "\x03", wantCommands: []command{quit}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commands, rest := parseKeys([]byte(tc.input), tc.final)
+			if !slices.Equal(commands, tc.wantCommands) {
+				t.Errorf("(parseKeys) want commands %v, got %v", tc.wantCommands, commands)
+			}
+			if string(rest) != tc.wantRest {
+				t.Errorf("(parseKeys) want rest %q, got %q", tc.wantRest, rest)
+			}
+		})
+	}
+
+	// The rest of one read is completed by the next.
+	first, rest := parseKeys([]byte("a\x1b"), false)
+	second, rest := parseKeys(append(rest, "[Bd"...), false)
+	if got := append(first, second...); !slices.Equal(got, []command{moveWest, moveSouth, moveEast}) || len(rest) != 0 {
+		t.Errorf("(parseKeys) want a split arrow reassembled, got %v with rest %q", got, rest)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"runtime"
+	"time"
+
+	"tsumegolang/internal/maze"
+)
+
+// serve implements "maze serve": it answers requests such as
+// /maze.png?w=20&h=20&seed=42&algo=prim&solution=true with freshly generated mazes.
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	addr := fs.String("addr", ":8080", "Address to listen on")
+	maxCells := fs.Int("max-cells", maze.DefaultMaxCells, "Largest maze served, in cells")
+	timeout := fs.Duration("timeout", maze.DefaultRequestTimeout, "Longest time spent generating a maze for a request")
+	cacheSize := fs.Int("cache", maze.DefaultCacheSize, "Number of responses to mazes with a seed kept in memory")
+	workers := fs.Int("workers", runtime.GOMAXPROCS(0), "Number of mazes generated at once; other requests wait for a turn")
+	fs.Parse(args)
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           maze.NewServer(maze.WithMaxCells(*maxCells), maze.WithRequestTimeout(*timeout), maze.WithCacheSize(*cacheSize), maze.WithMaxGenerating(*workers)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	fmt.Printf("Serving mazes on %s\n", *addr)
+	return server.ListenAndServe()
+}
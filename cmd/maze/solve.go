@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"tsumegolang/internal/maze"
+)
+
+// solve implements "maze solve": it reads a maze image, finds the path between
+// its openings and writes a copy with the path drawn over it.
+func solve(args []string) error {
+	fs := flag.NewFlagSet("solve", flag.ExitOnError)
+	in := fs.String("in", "", "Maze image to solve")
+	out := fs.String("out", "", "Output filename for the solved image (default the input name ending in -solved.png)")
+	solverName := fs.String("solver", "bfs", "Solver: "+strings.Join(maze.SolverNames(), ", "))
+	cellSize := fs.Int("cell-size", 0, "Width of a cell in pixels (detected if 0)")
+	wallThickness := fs.Int("wall-thickness", 0, "Thickness of the walls in pixels (detected if 0)")
+	solutionColor := fs.String("solution-color", "red", "Color of the solution path")
+	stats := fs.String("stats", "", "Print statistics about the maze as txt or json")
+	fs.Parse(args)
+
+	if *in == "" {
+		return fmt.Errorf("-in is required")
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(*in, filepath.Ext(*in)) + "-solved.png"
+	}
+	solver, err := maze.ParseSolver(*solverName)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(*in)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", *in, err)
+	}
+
+	opts := []maze.ImageOption{}
+	if *cellSize > 0 {
+		opts = append(opts, maze.WithCellSize(*cellSize))
+	}
+	if *wallThickness > 0 {
+		opts = append(opts, maze.WithWallThickness(*wallThickness))
+	}
+	// Metadata of mazes written by this program is kept, so -from still works,
+	// and gives the mask of a masked maze.
+	metadata, _ := maze.ReadMetadata(*in)
+	if text := metadata[maze.MetadataMask]; text != "" {
+		mask, err := maze.ParseMaskText(strings.NewReader(text))
+		if err != nil {
+			return fmt.Errorf("failed to read the mask of %s: %w", *in, err)
+		}
+		opts = append(opts, maze.WithImageMask(mask))
+	}
+	r, render, err := maze.ParseImage(img, opts...)
+	if err != nil {
+		return err
+	}
+	if render.SolutionColor, err = maze.ParseColor(*solutionColor); err != nil {
+		return err
+	}
+
+	// Without two openings, the maze is solved between opposite corners.
+	start, goal := 0, r.Width*r.Height-1
+	if len(r.Openings) >= 2 {
+		start, goal = r.Openings[0], r.Openings[len(r.Openings)-1]
+	}
+	solution, err := solver.Solve(r, start, goal)
+	if err != nil {
+		return err
+	}
+
+	solved := render.DrawSolution(img, r, solution)
+	if err := maze.WriteImageToFile(&solved, *out, metadata); err != nil {
+		return err
+	}
+
+	fmt.Printf("Read %dx%d maze with %d pixel cells\n", r.Width, r.Height, render.CellSize)
+	fmt.Printf("Solution is %d cells long, written to %s\n", len(solution), *out)
+
+	if *stats != "" {
+		format, err := maze.ParseFormat(*stats)
+		if err != nil {
+			return err
+		}
+		return maze.EncodeStats(os.Stdout, maze.Analyze(r, solution), format)
+	}
+	return nil
+}
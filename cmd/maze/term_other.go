@@ -0,0 +1,9 @@
+//go:build !(linux || darwin || dragonfly || freebsd || netbsd || openbsd)
+
+package main
+
+import "errors"
+
+func makeRaw(int) (func() error, error) {
+	return nil, errors.New("playing needs a Unix terminal")
+}
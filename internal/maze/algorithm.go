@@ -0,0 +1,449 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"tsumegolang/pkg/algo/graph/common"
+	"tsumegolang/pkg/algo/graph/kruskal"
+	"tsumegolang/pkg/algo/graph/prim"
+	"tsumegolang/pkg/ds/basic"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+// Algorithm selects how the passages of a maze are carved.
+type Algorithm int
+
+const (
+	Kruskal      Algorithm = iota // minimum spanning tree of random weights; many short dead ends
+	Backtracker                   // randomized depth-first search; long, winding corridors
+	Prim                          // minimum spanning tree grown from one cell; short, radiating branches
+	Wilson                        // loop-erased random walks; uniform spanning tree
+	AldousBroder                  // random walk; uniform spanning tree, slow to finish
+	Eller                         // row by row with set merging; rectangles only
+	BinaryTree                    // each cell opens north or east; rectangles only
+	Sidewinder                    // runs along each row closed by a north opening; rectangles only
+)
+
+var (
+	ErrUnknownAlgorithm     = errors.New("unknown maze algorithm")
+	ErrUnsupportedAlgorithm = errors.New("algorithm is not supported for this maze")
+)
+
+var algorithmNames = []string{
+	Kruskal:      "kruskal",
+	Backtracker:  "backtracker",
+	Prim:         "prim",
+	Wilson:       "wilson",
+	AldousBroder: "aldous-broder",
+	Eller:        "eller",
+	BinaryTree:   "binary-tree",
+	Sidewinder:   "sidewinder",
+}
+
+func (a Algorithm) String() string {
+	if a < 0 || int(a) >= len(algorithmNames) {
+		return fmt.Sprintf("Algorithm(%d)", int(a))
+	}
+	return algorithmNames[a]
+}
+
+// ParseAlgorithm returns the Algorithm with the given name, as listed by AlgorithmNames.
+func ParseAlgorithm(name string) (Algorithm, error) {
+	i := slices.Index(algorithmNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownAlgorithm, name)
+	}
+	return Algorithm(i), nil
+}
+
+// AlgorithmNames lists the names accepted by ParseAlgorithm.
+func AlgorithmNames() []string {
+	return slices.Clone(algorithmNames)
+}
+
+// Carve returns a new undirected graph holding a spanning tree of the candidate
+// edges in r.Graph, chosen according to the algorithm. Every random choice is
+// drawn from rng, so the same candidates and seed give the same maze.
+func (a Algorithm) Carve(r *Rectangle, rng *rand.Rand) (*sparsegraph.Graph, error) {
+	return a.carve(r, rng, nil)
+}
+
+// carving is the graph passages are carved into. It reports each passage to
+// record, if set, in the order they are carved, and stops carving with the error
+// record returns.
+type carving struct {
+	*sparsegraph.Graph
+	record func(from, to int) error
+}
+
+func newCarving(n int, record func(from, to int) error) (carving, error) {
+	g, err := sparsegraph.NewGraph(n, false)
+	return carving{Graph: g, record: record}, err
+}
+
+func (c carving) Connect(i, j int, w float64) error {
+	if err := c.Graph.Connect(i, j, w); err != nil {
+		return err
+	}
+	if c.record != nil {
+		return c.record(i, j)
+	}
+	return nil
+}
+
+func (a Algorithm) carve(r *Rectangle, rng *rand.Rand, record func(from, to int) error) (*sparsegraph.Graph, error) {
+	if r.Mask != nil {
+		return a.carveMasked(r, rng, record)
+	}
+
+	carveRows, ok := a.rowCarver()
+	if !ok {
+		return a.carveGraph(r.Graph, rng, record)
+	}
+
+	paths, err := newCarving(r.Graph.GetSize(), record)
+	if err != nil {
+		return nil, err
+	}
+	connect := func(from, to int) error {
+		return paths.Connect(from, to, DefaultWeight)
+	}
+	if err := carveRows(r.Width, r.Height, rng, connect); err != nil {
+		return nil, err
+	}
+
+	return paths.Graph, nil
+}
+
+// rowCarver carves a width x height rectangle row by row from the top, passing
+// each passage to connect as it is carved.
+type rowCarver func(width, height int, rng *rand.Rand, connect func(from, to int) error) error
+
+// rowCarver returns the carver of the algorithms that work row by row.
+func (a Algorithm) rowCarver() (rowCarver, bool) {
+	switch a {
+	case Eller:
+		return carveEller, true
+	case BinaryTree:
+		return carveBinaryTree, true
+	case Sidewinder:
+		return carveSidewinder, true
+	}
+	return nil, false
+}
+
+// CarvesRows reports whether a carves a rectangle row by row, so it can Stream
+// and generate compact mazes: Eller, BinaryTree and Sidewinder do.
+func (a Algorithm) CarvesRows() bool {
+	_, ok := a.rowCarver()
+	return ok
+}
+
+// CarveGraph is Carve for mazes of any shape, given only their candidate edges.
+// The algorithms that work row by row return ErrUnsupportedAlgorithm.
+func (a Algorithm) CarveGraph(candidates *sparsegraph.Graph, rng *rand.Rand) (*sparsegraph.Graph, error) {
+	return a.carveGraph(candidates, rng, nil)
+}
+
+func (a Algorithm) carveGraph(candidates *sparsegraph.Graph, rng *rand.Rand, record func(from, to int) error) (*sparsegraph.Graph, error) {
+	paths, err := newCarving(candidates.GetSize(), record)
+	if err != nil {
+		return nil, err
+	}
+
+	switch a {
+	case Kruskal:
+		err = kruskal.MST(candidates, paths)
+	case Prim:
+		err = prim.MST(candidates, paths, rng.IntN(candidates.GetSize()))
+	case Backtracker:
+		err = carveBacktracker(adjacency(candidates), paths, rng)
+	case Wilson:
+		err = carveWilson(adjacency(candidates), paths, rng)
+	case AldousBroder:
+		err = carveAldousBroder(adjacency(candidates), paths, rng)
+	case Eller, BinaryTree, Sidewinder:
+		err = fmt.Errorf("%w: %v needs a rectangle", ErrUnsupportedAlgorithm, a)
+	default:
+		err = fmt.Errorf("%w: %v", ErrUnknownAlgorithm, a)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return paths.Graph, nil
+}
+
+// carveMasked carves only the cells included by the mask, which is assumed to
+// leave a single island. The row by row algorithms need every cell.
+func (a Algorithm) carveMasked(r *Rectangle, rng *rand.Rand, record func(from, to int) error) (*sparsegraph.Graph, error) {
+	switch a {
+	case Eller, BinaryTree, Sidewinder:
+		return nil, fmt.Errorf("%w: %v needs an unmasked rectangle", ErrUnsupportedAlgorithm, a)
+	}
+
+	// The algorithms choose their starting cells among all nodes, so carve a graph
+	// of the included cells alone and map it back.
+	cells := []int{}
+	compact := make(map[int]int)
+	for n := range r.Graph.GetSize() {
+		if r.Included(n) {
+			compact[n] = len(cells)
+			cells = append(cells, n)
+		}
+	}
+	candidates, err := sparsegraph.NewGraph(len(cells), false)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range r.Graph.GetAllEdges() {
+		if err := candidates.Connect(compact[e.From.(int)], compact[e.To.(int)], e.Weight); err != nil {
+			return nil, err
+		}
+	}
+
+	var recordCompact func(from, to int) error
+	if record != nil {
+		recordCompact = func(from, to int) error { return record(cells[from], cells[to]) }
+	}
+	carved, err := a.carveGraph(candidates, rng, recordCompact)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := sparsegraph.NewGraph(r.Graph.GetSize(), false)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range carved.GetAllEdges() {
+		if err := paths.Connect(cells[e.From.(int)], cells[e.To.(int)], e.Weight); err != nil {
+			return nil, err
+		}
+	}
+
+	return paths, nil
+}
+
+// adjacency lists the neighbors of every node in g in ascending order.
+func adjacency(g *sparsegraph.Graph) [][]int {
+	adj := make([][]int, g.GetSize())
+	for _, e := range g.GetAllEdges() {
+		from, to := e.From.(int), e.To.(int)
+		adj[from] = append(adj[from], to)
+		adj[to] = append(adj[to], from)
+	}
+	for _, neighbors := range adj {
+		slices.Sort(neighbors)
+	}
+	return adj
+}
+
+func carveBacktracker(adj [][]int, paths carving, rng *rand.Rand) error {
+	visited := make([]bool, len(adj))
+	start := rng.IntN(len(adj))
+	visited[start] = true
+
+	stack := basic.NewStack[int]()
+	stack.Push(start)
+	for !stack.IsEmpty() {
+		current, _ := stack.Peek()
+
+		unvisited := []int{}
+		for _, next := range adj[current] {
+			if !visited[next] {
+				unvisited = append(unvisited, next)
+			}
+		}
+		if len(unvisited) == 0 {
+			stack.Pop()
+			continue
+		}
+
+		next := unvisited[rng.IntN(len(unvisited))]
+		if err := paths.Connect(current, next, DefaultWeight); err != nil {
+			return err
+		}
+		visited[next] = true
+		stack.Push(next)
+	}
+
+	return nil
+}
+
+// connected reports whether every node of adj can be reached from the first.
+// The random walks of Wilson and Aldous-Broder would never end otherwise.
+func connected(adj [][]int) bool {
+	return !slices.Contains(distances(adj, 0), -1)
+}
+
+func carveWilson(adj [][]int, paths carving, rng *rand.Rand) error {
+	if !connected(adj) {
+		return common.ErrDisconnectedGraph
+	}
+	inTree := make([]bool, len(adj))
+	inTree[rng.IntN(len(adj))] = true
+
+	// next[i] records the step taken when the walk last left i, which erases loops implicitly.
+	next := make([]int, len(adj))
+	for _, start := range rng.Perm(len(adj)) {
+		if inTree[start] {
+			continue
+		}
+
+		for current := start; !inTree[current]; current = next[current] {
+			next[current] = adj[current][rng.IntN(len(adj[current]))]
+		}
+		for current := start; !inTree[current]; current = next[current] {
+			if err := paths.Connect(current, next[current], DefaultWeight); err != nil {
+				return err
+			}
+			inTree[current] = true
+		}
+	}
+
+	return nil
+}
+
+func carveAldousBroder(adj [][]int, paths carving, rng *rand.Rand) error {
+	if !connected(adj) {
+		return common.ErrDisconnectedGraph
+	}
+	visited := make([]bool, len(adj))
+	current := rng.IntN(len(adj))
+	visited[current] = true
+
+	for remaining := len(adj) - 1; remaining > 0; {
+		next := adj[current][rng.IntN(len(adj[current]))]
+		if !visited[next] {
+			if err := paths.Connect(current, next, DefaultWeight); err != nil {
+				return err
+			}
+			visited[next] = true
+			remaining--
+		}
+		current = next
+	}
+
+	return nil
+}
+
+func carveEller(width, height int, rng *rand.Rand, connect func(from, to int) error) error {
+	sets := make([]int, width) // set id of each cell in the current row
+	nextSet := 0
+	for x := range sets {
+		sets[x] = nextSet
+		nextSet++
+	}
+
+	for y := range height {
+		lastRow := y == height-1
+		members := map[int][]int{} // cells of the current row in each set
+		for x, set := range sets {
+			members[set] = append(members[set], x)
+		}
+
+		// Join horizontal neighbors in different sets; the last row must join all of them.
+		for x := 0; x < width-1; x++ {
+			if sets[x] == sets[x+1] || (!lastRow && rng.IntN(2) == 0) {
+				continue
+			}
+			if err := connect(y*width+x, y*width+x+1); err != nil {
+				return err
+			}
+			joined, old := sets[x], sets[x+1]
+			for _, i := range members[old] {
+				sets[i] = joined
+			}
+			members[joined] = append(members[joined], members[old]...)
+			delete(members, old)
+		}
+		if lastRow {
+			break
+		}
+
+		// Every set extends down at least once; other cells start new sets.
+		below := make([]int, width)
+		for x := range below {
+			below[x] = -1
+		}
+		for _, set := range slices.Sorted(maps.Keys(members)) {
+			xs := members[set]
+			slices.Sort(xs)
+			rng.Shuffle(len(xs), func(i, j int) { xs[i], xs[j] = xs[j], xs[i] })
+			for i, x := range xs {
+				if i > 0 && rng.IntN(2) == 0 {
+					continue
+				}
+				if err := connect(y*width+x, (y+1)*width+x); err != nil {
+					return err
+				}
+				below[x] = set
+			}
+		}
+		for x := range below {
+			if below[x] < 0 {
+				below[x] = nextSet
+				nextSet++
+			}
+		}
+		sets = below
+	}
+
+	return nil
+}
+
+func carveBinaryTree(width, height int, rng *rand.Rand, connect func(from, to int) error) error {
+	choices := make([]int, 0, 2)
+	for y := range height {
+		for x := range width {
+			cell := y*width + x
+			choices = choices[:0]
+			if y > 0 {
+				choices = append(choices, cell-width) // north
+			}
+			if x < width-1 {
+				choices = append(choices, cell+1) // east
+			}
+			if len(choices) == 0 {
+				continue
+			}
+			if err := connect(cell, choices[rng.IntN(len(choices))]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func carveSidewinder(width, height int, rng *rand.Rand, connect func(from, to int) error) error {
+	for y := range height {
+		runStart := 0
+		for x := range width {
+			atEastEdge := x == width-1
+			closeRun := atEastEdge || (y > 0 && rng.IntN(2) == 0)
+
+			if !closeRun {
+				if err := connect(y*width+x, y*width+x+1); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if y > 0 {
+				member := runStart + rng.IntN(x-runStart+1)
+				if err := connect(y*width+member, (y-1)*width+member); err != nil {
+					return err
+				}
+			}
+			runStart = x + 1
+		}
+	}
+
+	return nil
+}
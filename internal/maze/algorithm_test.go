@@ -0,0 +1,107 @@
+package maze_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"tsumegolang/pkg/algo/graph/common"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestParseAlgorithm(t *testing.T) {
+	for _, name := range AlgorithmNames() {
+		a, err := ParseAlgorithm(name)
+		if err != nil {
+			t.Fatalf("(ParseAlgorithm) unexpected error for %q: %v", name, err)
+		}
+		if a.String() != name {
+			t.Errorf("(ParseAlgorithm) want %q, got %q", name, a.String())
+		}
+	}
+
+	if _, err := ParseAlgorithm("maze-o-matic"); !errors.Is(err, ErrUnknownAlgorithm) {
+		t.Errorf("(ParseAlgorithm) want %v, got %v", ErrUnknownAlgorithm, err)
+	}
+}
+
+func TestCarve(t *testing.T) {
+	testCases := []struct {
+		name   string
+		width  int
+		height int
+	}{
+		{name: "single cell", width: 1, height: 1},
+		{name: "wide line", width: 9, height: 1},
+		{name: "tall line", width: 1, height: 9},
+		{name: "rectangle", width: 7, height: 5},
+		{name: "square", width: 12, height: 12},
+	}
+
+	for _, name := range AlgorithmNames() {
+		algorithm, _ := ParseAlgorithm(name)
+		for _, tc := range testCases {
+			t.Run(name+" "+tc.name, func(t *testing.T) {
+				r, err := NewRectangle(tc.width, tc.height, ConnectRandom)
+				if err != nil {
+					t.Fatalf("(Carve) unexpected error: %v", err)
+				}
+
+				paths, err := algorithm.Carve(r, rand.New(rand.NewPCG(1, 2)))
+				if err != nil {
+					t.Fatalf("(Carve) unexpected error: %v", err)
+				}
+
+				assertSpanningTree(t, r, paths.GetAllEdges())
+			})
+		}
+	}
+}
+
+func TestCarveDisconnected(t *testing.T) {
+	for _, algorithm := range []Algorithm{Wilson, AldousBroder} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			r, err := NewRectangle(3, 3, NoConnect)
+			if err != nil {
+				t.Fatalf("(Carve) unexpected error: %v", err)
+			}
+			if _, err := algorithm.Carve(r, rand.New(rand.NewPCG(1, 2))); !errors.Is(err, common.ErrDisconnectedGraph) {
+				t.Errorf("(Carve) want %v, got %v", common.ErrDisconnectedGraph, err)
+			}
+		})
+	}
+}
+
+func TestCarveSeeded(t *testing.T) {
+	for _, name := range AlgorithmNames() {
+		algorithm, _ := ParseAlgorithm(name)
+		t.Run(name, func(t *testing.T) {
+			carve := func() []string {
+				rng := rand.New(rand.NewPCG(42, 42))
+				r, err := NewRectangle(10, 8, ConnectRandom, WithRand(rng))
+				if err != nil {
+					t.Fatalf("(Carve) unexpected error: %v", err)
+				}
+				paths, err := algorithm.Carve(r, rng)
+				if err != nil {
+					t.Fatalf("(Carve) unexpected error: %v", err)
+				}
+
+				edges := []string{}
+				for _, e := range paths.GetAllEdges() {
+					edges = append(edges, fmt.Sprintf("%v-%v", e.From, e.To))
+				}
+				slices.Sort(edges)
+				return edges
+			}
+
+			first, second := carve(), carve()
+			if !slices.Equal(first, second) {
+				t.Errorf("(Carve) same seed gave different mazes:\n%v\n%v", first, second)
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
+	"io"
+	"slices"
+
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+const (
+	animationHold = 200 // time the finished maze is shown before the GIF loops, in hundredths of a second
+	heatSteps     = 32  // shades of the heat map kept in the GIF palette
+)
+
+var (
+	ErrInvalidAnimation = errors.New("invalid animation options")
+)
+
+// AnimationOptions controls the animated GIF of a maze being carved and solved.
+type AnimationOptions struct {
+	StepsPerFrame int         // passages carved or cells explored between frames
+	Delay         int         // time each frame is shown, in hundredths of a second
+	MaxFrames     int         // more steps are shown per frame to stay within it; 0 for no limit
+	Solve         bool        // after carving, show the solver exploring the maze and then the solution
+	ExploredColor color.Color // fill of the cells the solver has visited
+}
+
+// DefaultAnimationOptions returns options showing one step per frame at 25
+// frames a second, with at most 300 frames.
+func DefaultAnimationOptions() AnimationOptions {
+	return AnimationOptions{
+		StepsPerFrame: 1,
+		Delay:         4,
+		MaxFrames:     300,
+		ExploredColor: color.RGBA{R: 170, G: 200, B: 255, A: 255},
+	}
+}
+
+// Validate checks that the options give at least one step per frame and room
+// for the first and last frames of each stage.
+func (a AnimationOptions) Validate() error {
+	if a.StepsPerFrame < 1 {
+		return fmt.Errorf("%w: %d steps per frame is less than 1", ErrInvalidAnimation, a.StepsPerFrame)
+	}
+	if a.Delay < 0 {
+		return fmt.Errorf("%w: delay %d is negative", ErrInvalidAnimation, a.Delay)
+	}
+	if a.MaxFrames != 0 && a.MaxFrames < 4 {
+		return fmt.Errorf("%w: at most %d frames is less than 4", ErrInvalidAnimation, a.MaxFrames)
+	}
+	if a.ExploredColor == nil {
+		return fmt.Errorf("%w: missing color", ErrInvalidAnimation)
+	}
+	return nil
+}
+
+// Recording is the history of a maze replayed by an animation.
+type Recording struct {
+	Carved   [][2]int // passages in the order they were opened
+	Explored []int    // cells in the order the solver visited them
+	Solution []int
+}
+
+// EncodeAnimation writes a GIF of r being carved in the order of rec.Carved, each
+// frame drawn as by DrawRectangle. Passages of r missing from the recording, such
+// as those opened by braiding, are carved last.
+func (o RenderOptions) EncodeAnimation(w io.Writer, r *Rectangle, rec Recording, a AnimationOptions) error {
+	frame := *r
+	return o.encodeAnimation(w, r.Graph, rec, a, func(o RenderOptions, paths *sparsegraph.Graph, path []int) (image.Image, error) {
+		frame.Graph = paths
+		return o.DrawRectangle(&frame, path)
+	})
+}
+
+// EncodeGridAnimation is EncodeAnimation for hex, triangle and polar mazes.
+func (o RenderOptions) EncodeGridAnimation(w io.Writer, g *Grid, rec Recording, a AnimationOptions) error {
+	frame := *g
+	return o.encodeAnimation(w, g.Graph, rec, a, func(o RenderOptions, paths *sparsegraph.Graph, path []int) (image.Image, error) {
+		frame.Graph = paths
+		return o.DrawGrid(&frame, path)
+	})
+}
+
+type frameDrawer func(o RenderOptions, paths *sparsegraph.Graph, path []int) (image.Image, error)
+
+func (o RenderOptions) encodeAnimation(w io.Writer, final *sparsegraph.Graph, rec Recording, a AnimationOptions, drawFrame frameDrawer) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	passages := carvingOrder(final, rec.Carved)
+	explored := []int{}
+	if a.Solve {
+		explored = rec.Explored
+	}
+	per := a.stepsPerFrame(len(passages), len(explored))
+
+	paths, err := sparsegraph.NewGraph(final.GetSize(), false)
+	if err != nil {
+		return err
+	}
+
+	colors := o.palette(a)
+	anim := &gif.GIF{}
+	addFrame := func(o RenderOptions, path []int, delay int) error {
+		img, err := drawFrame(o, paths, path)
+		if err != nil {
+			return err
+		}
+		bounds := img.Bounds()
+		frame := image.NewPaletted(bounds, colors)
+		draw.Draw(frame, bounds, img, bounds.Min, draw.Src)
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+		return nil
+	}
+
+	for done := 0; ; {
+		if err := addFrame(o, nil, a.Delay); err != nil {
+			return err
+		}
+		if done == len(passages) {
+			break
+		}
+		next := min(done+per, len(passages))
+		for _, p := range passages[done:next] {
+			if err := paths.Connect(p[0], p[1], DefaultWeight); err != nil {
+				return err
+			}
+		}
+		done = next
+	}
+
+	if !a.Solve {
+		anim.Delay[len(anim.Delay)-1] = max(a.Delay, animationHold)
+		return gif.EncodeAll(w, anim)
+	}
+
+	o.overlay = make(map[int]color.Color, len(explored))
+	for done := 0; done < len(explored); {
+		next := min(done+per, len(explored))
+		for _, cell := range explored[done:next] {
+			o.overlay[cell] = a.ExploredColor
+		}
+		done = next
+		if err := addFrame(o, nil, a.Delay); err != nil {
+			return err
+		}
+	}
+	if err := addFrame(o, rec.Solution, max(a.Delay, animationHold)); err != nil {
+		return err
+	}
+
+	return gif.EncodeAll(w, anim)
+}
+
+// carvingOrder returns the passages of final, those in carved first and in the
+// same order, followed by the rest.
+func carvingOrder(final *sparsegraph.Graph, carved [][2]int) [][2]int {
+	key := func(a, b int) [2]int {
+		return [2]int{min(a, b), max(a, b)}
+	}
+
+	order := [][2]int{}
+	seen := make(map[[2]int]bool)
+	for _, p := range carved {
+		if _, ok := final.GetEdge(p[0], p[1]); ok && !seen[key(p[0], p[1])] {
+			seen[key(p[0], p[1])] = true
+			order = append(order, p)
+		}
+	}
+	for _, e := range final.GetAllEdges() {
+		from, to := e.From.(int), e.To.(int)
+		if !seen[key(from, to)] {
+			seen[key(from, to)] = true
+			order = append(order, [2]int{from, to})
+		}
+	}
+	return order
+}
+
+// stepsPerFrame returns the fewest steps per frame, no fewer than asked for, that
+// keep the animation within MaxFrames.
+func (a AnimationOptions) stepsPerFrame(carved, explored int) int {
+	frames := func(per int) int {
+		n := 1 + (carved+per-1)/per
+		if a.Solve {
+			n += (explored+per-1)/per + 1
+		}
+		return n
+	}
+
+	per := a.StepsPerFrame
+	if a.MaxFrames > 0 {
+		per = max(per, (carved+explored)/a.MaxFrames)
+		for frames(per) > a.MaxFrames {
+			per++
+		}
+	}
+	return per
+}
+
+// palette holds the colors the frames are drawn with, followed by web-safe
+// colors to approximate any others.
+func (o RenderOptions) palette(a AnimationOptions) color.Palette {
+	colors := []color.Color{o.Background, o.PassageColor, o.WallColor, o.SolutionColor, a.ExploredColor}
+	if o.BlockColor != nil {
+		colors = append(colors, o.BlockColor)
+	}
+	if o.HeatMap {
+		for k := range heatSteps {
+			colors = append(colors, lerpColor(o.HeatNear, o.HeatFar, float64(k)/(heatSteps-1)))
+		}
+	}
+
+	p := color.Palette{}
+	for _, c := range append(colors, palette.WebSafe...) {
+		if len(p) == 256 {
+			break
+		}
+		if !slices.ContainsFunc(p, func(q color.Color) bool { return sameColor(q, c) }) {
+			p = append(p, c)
+		}
+	}
+	return p
+}
@@ -0,0 +1,157 @@
+package maze_test
+
+import (
+	"bytes"
+	"errors"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestEncodeAnimation(t *testing.T) {
+	r := newTestMaze(t)
+	carved := [][2]int{{0, 1}, {0, 3}, {3, 4}, {4, 5}, {2, 5}}
+	explored := []int{0, 1, 3, 4, 5}
+
+	testCases := []struct {
+		name       string
+		carved     [][2]int
+		opts       func(*AnimationOptions)
+		wantFrames int
+	}{
+		{
+			name:       "carving",
+			carved:     carved,
+			wantFrames: 6,
+		},
+		{
+			name:       "passages missing from the recording",
+			carved:     carved[:3],
+			wantFrames: 6,
+		},
+		{
+			name:       "solving",
+			carved:     carved,
+			opts:       func(a *AnimationOptions) { a.Solve = true },
+			wantFrames: 12,
+		},
+		{
+			name:       "two steps per frame",
+			carved:     carved,
+			opts:       func(a *AnimationOptions) { a.Solve, a.StepsPerFrame = true, 2 },
+			wantFrames: 8,
+		},
+		{
+			name:       "frame limit",
+			carved:     carved,
+			opts:       func(a *AnimationOptions) { a.Solve, a.MaxFrames = true, 5 },
+			wantFrames: 4,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultAnimationOptions()
+			if tc.opts != nil {
+				tc.opts(&opts)
+			}
+
+			var buf bytes.Buffer
+			rec := Recording{Carved: tc.carved, Explored: explored, Solution: []int{0, 3, 4, 5}}
+			if err := DefaultRenderOptions().EncodeAnimation(&buf, r, rec, opts); err != nil {
+				t.Fatalf("(EncodeAnimation) unexpected error: %v", err)
+			}
+
+			anim, err := gif.DecodeAll(&buf)
+			if err != nil {
+				t.Fatalf("(EncodeAnimation) invalid GIF: %v", err)
+			}
+			if len(anim.Image) != tc.wantFrames {
+				t.Fatalf("(EncodeAnimation) want %d frames, got %d", tc.wantFrames, len(anim.Image))
+			}
+			if last := anim.Delay[len(anim.Delay)-1]; last <= opts.Delay {
+				t.Errorf("(EncodeAnimation) want the last frame held longer than %d, got %d", opts.Delay, last)
+			}
+
+			// The wall between cells 0 and 1 is standing in the first frame and
+			// carved away by the last.
+			first, last := anim.Image[0], anim.Image[len(anim.Image)-1]
+			if got := first.At(19, 10); color.RGBAModel.Convert(got) != color.RGBAModel.Convert(color.Black) {
+				t.Errorf("(EncodeAnimation) want a wall in the first frame, got %v", got)
+			}
+			if got := last.At(19, 5); color.RGBAModel.Convert(got) == color.RGBAModel.Convert(color.Black) {
+				t.Errorf("(EncodeAnimation) want a passage in the last frame, got %v", got)
+			}
+		})
+	}
+}
+
+func TestAnimationOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts func(*AnimationOptions)
+	}{
+		{name: "no steps", opts: func(a *AnimationOptions) { a.StepsPerFrame = 0 }},
+		{name: "negative delay", opts: func(a *AnimationOptions) { a.Delay = -1 }},
+		{name: "too few frames", opts: func(a *AnimationOptions) { a.MaxFrames = 3 }},
+		{name: "missing color", opts: func(a *AnimationOptions) { a.ExploredColor = nil }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultAnimationOptions()
+			tc.opts(&opts)
+			if err := opts.Validate(); !errors.Is(err, ErrInvalidAnimation) {
+				t.Errorf("(Validate) want %v, got %v", ErrInvalidAnimation, err)
+			}
+		})
+	}
+}
+
+func TestGenerateAnimation(t *testing.T) {
+	testCases := []struct {
+		name       string
+		width      int
+		height     int
+		opts       []GeneratorOptions
+		wantFrames int // one for the uncarved grid and one per passage
+	}{
+		{name: "kruskal", width: 4, height: 3, wantFrames: 12},
+		{name: "backtracker", width: 4, height: 3, opts: []GeneratorOptions{WithAlgorithm(Backtracker)}, wantFrames: 12},
+		{name: "eller", width: 4, height: 3, opts: []GeneratorOptions{WithAlgorithm(Eller)}, wantFrames: 12},
+		{name: "hex", width: 4, height: 3, opts: []GeneratorOptions{WithShape(Hex)}, wantFrames: 12},
+		{name: "recursive", width: 2, height: 2, opts: []GeneratorOptions{WithRecursionLevel(1)}, wantFrames: 64},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "maze.gif")
+			opts := DefaultAnimationOptions()
+			opts.MaxFrames = 0
+
+			mg := NewMazeGenerator(tc.width, tc.height, append(tc.opts,
+				WithSeed(5), WithFilename(filepath.Join(dir, "maze.png")), WithAnimation(filename, opts))...)
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			file, err := os.Open(filename)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			defer file.Close()
+			anim, err := gif.DecodeAll(file)
+			if err != nil {
+				t.Fatalf("(Generate) invalid GIF: %v", err)
+			}
+			if len(anim.Image) != tc.wantFrames {
+				t.Errorf("(Generate) want %d frames, got %d", tc.wantFrames, len(anim.Image))
+			}
+		})
+	}
+}
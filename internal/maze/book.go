@@ -0,0 +1,282 @@
+package maze
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"image"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"tsumegolang/pkg/concurrency"
+)
+
+const (
+	bookMargin  = 48 // space around each page's content, in pixels
+	bookHeader  = 40 // height of the title at the top of each page
+	bookFooter  = 28 // height of the page number at the bottom
+	bookCaption = 28 // height of the label above each maze
+	bookGutter  = 16 // space between mazes on a page
+)
+
+var (
+	ErrInvalidBook = errors.New("invalid puzzle book")
+)
+
+// Puzzle is one maze of a puzzle book, with its solution for the answer key.
+type Puzzle struct {
+	Number   int         // from 1, in the order of the book
+	Size     image.Point // the requested width and height
+	Seed     uint64
+	Maze     *Rectangle // the maze when it is square
+	Grid     *Grid      // the maze when it is hex, triangle or polar
+	Solution []int
+	Stats    Stats
+}
+
+// GenerateBatch generates count puzzles on a pool of workers. Each puzzle has a
+// seed of its own, drawn in turn from seed, so a batch comes out the same however
+// many workers build it. The sizes are used in order, each for an equal share of
+// the puzzles, so a book grows harder as it goes. opts apply to every puzzle;
+// the puzzles are only written to files if opts name them.
+func GenerateBatch(count int, sizes []image.Point, seed uint64, workers int, opts ...GeneratorOptions) ([]Puzzle, error) {
+	if count < 1 || len(sizes) == 0 || workers < 1 {
+		return nil, fmt.Errorf("%w: %d puzzles of %d sizes on %d workers", ErrInvalidBook, count, len(sizes), workers)
+	}
+
+	rng := newRand(seed)
+	puzzles := make([]Puzzle, count)
+	for k := range puzzles {
+		puzzles[k] = Puzzle{Number: k + 1, Size: sizes[k*len(sizes)/count], Seed: rng.Uint64()}
+	}
+
+	pool := concurrency.NewWorkerPool(generatePuzzle(opts), workers)
+	pool.Start()
+	defer pool.Shutdown(context.Background())
+
+	results := make([]chan concurrency.JobResult[Puzzle, Puzzle], count)
+	for k, p := range puzzles {
+		var err error
+		if results[k], err = pool.Submit(p); err != nil {
+			return nil, err
+		}
+	}
+	for k, ch := range results {
+		result := <-ch
+		if result.Status != concurrency.StatusSuccess {
+			return nil, fmt.Errorf("puzzle %d: %w", k+1, result.Err)
+		}
+		puzzles[k] = result.Output
+	}
+	return puzzles, nil
+}
+
+// generatePuzzle returns a job generating the maze of a puzzle from its size and seed.
+func generatePuzzle(opts []GeneratorOptions) concurrency.Job[Puzzle, Puzzle] {
+	return func(p Puzzle) concurrency.JobResult[Puzzle, Puzzle] {
+		mg := NewMazeGenerator(p.Size.X, p.Size.Y, append(slices.Clone(opts), WithSeed(p.Seed))...)
+		if err := mg.Generate(); err != nil {
+			return concurrency.JobResult[Puzzle, Puzzle]{Input: p, Status: concurrency.StatusError, Err: err}
+		}
+
+		out := p
+		if out.Grid = mg.Grid(); out.Grid == nil {
+			out.Maze = mg.Maze()
+		}
+		out.Solution = mg.Solution()
+		out.Stats = mg.Stats()
+		return concurrency.JobResult[Puzzle, Puzzle]{Input: p, Output: out, Status: concurrency.StatusSuccess}
+	}
+}
+
+// BookOptions controls the layout of a puzzle book.
+type BookOptions struct {
+	Title          string
+	PageWidth      int // in CSS pixels, 96 to the inch
+	PageHeight     int //
+	PuzzlesPerPage int //
+	AnswersPerPage int // solutions are drawn smaller, several to a page
+	Render         RenderOptions
+}
+
+// DefaultBookOptions returns US Letter pages with one puzzle to a page and four
+// answers.
+func DefaultBookOptions() BookOptions {
+	return BookOptions{
+		Title:          "Mazes",
+		PageWidth:      816,
+		PageHeight:     1056,
+		PuzzlesPerPage: 1,
+		AnswersPerPage: 4,
+		Render:         DefaultRenderOptions(),
+	}
+}
+
+// Validate checks that every page has room for its mazes.
+func (o BookOptions) Validate() error {
+	if o.PuzzlesPerPage < 1 || o.AnswersPerPage < 1 {
+		return fmt.Errorf("%w: %d puzzles and %d answers per page", ErrInvalidBook, o.PuzzlesPerPage, o.AnswersPerPage)
+	}
+	for _, perPage := range []int{o.PuzzlesPerPage, o.AnswersPerPage} {
+		slot := o.slot(perPage)
+		if slot.Dx() < bookCaption || slot.Dy() < 2*bookCaption {
+			return fmt.Errorf("%w: %dx%d pages have no room for %d mazes", ErrInvalidBook, o.PageWidth, o.PageHeight, perPage)
+		}
+	}
+	return o.Render.Validate()
+}
+
+// Pages lays out the puzzles, then the answer key with their solutions drawn,
+// as one SVG document per page.
+func (o BookOptions) Pages(puzzles []Puzzle) ([]string, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	pages := []string{}
+	for _, answers := range []bool{false, true} {
+		perPage := o.PuzzlesPerPage
+		if answers {
+			perPage = o.AnswersPerPage
+		}
+		for start := 0; start < len(puzzles); start += perPage {
+			page, err := o.page(puzzles[start:min(start+perPage, len(puzzles))], perPage, answers, len(pages)+1)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, page)
+		}
+	}
+	return pages, nil
+}
+
+// EncodeHTML writes the book as an HTML document that prints one page per sheet.
+func (o BookOptions) EncodeHTML(w io.Writer, puzzles []Puzzle) error {
+	pages, err := o.Pages(puzzles)
+	if err != nil {
+		return err
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&sb, "<title>%s</title>\n", html.EscapeString(o.Title))
+	fmt.Fprintf(&sb, "<style>\n@page { size: %dpx %dpx; margin: 0 }\nbody { margin: 0 }\n.page { width: %dpx; height: %dpx; break-after: page; overflow: hidden }\n</style>\n",
+		o.PageWidth, o.PageHeight, o.PageWidth, o.PageHeight)
+	sb.WriteString("</head>\n<body>\n")
+	for _, page := range pages {
+		sb.WriteString("<div class=\"page\">\n")
+		sb.WriteString(page)
+		sb.WriteString("</div>\n")
+	}
+	sb.WriteString("</body>\n</html>\n")
+
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteBook writes the book to an HTML file or, for a filename ending in .svg,
+// one SVG file per page numbered from 01, as book-01.svg for book.svg.
+func (o BookOptions) WriteBook(filename string, puzzles []Puzzle) error {
+	ext := strings.ToLower(filepath.Ext(filename))
+	switch ext {
+	case ".html", ".htm":
+		var buf bytes.Buffer
+		if err := o.EncodeHTML(&buf, puzzles); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filename, buf.Bytes(), 0644); err != nil {
+			return fmt.Errorf("failed to write file: %w", err)
+		}
+		return nil
+	case ".svg":
+		pages, err := o.Pages(puzzles)
+		if err != nil {
+			return err
+		}
+		base := strings.TrimSuffix(filename, filepath.Ext(filename))
+		digits := max(2, len(fmt.Sprint(len(pages))))
+		for k, page := range pages {
+			name := fmt.Sprintf("%s-%0*d.svg", base, digits, k+1)
+			if err := os.WriteFile(name, []byte(page), 0644); err != nil {
+				return fmt.Errorf("failed to write file: %w", err)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: %q is neither .html nor .svg", ErrInvalidBook, filename)
+}
+
+// grid returns the columns and rows of a page holding perPage mazes.
+func (o BookOptions) grid(perPage int) (int, int) {
+	cols := int(math.Ceil(math.Sqrt(float64(perPage))))
+	return cols, (perPage + cols - 1) / cols
+}
+
+// slot returns the space for the first of perPage mazes on a page, caption included.
+func (o BookOptions) slot(perPage int) image.Rectangle {
+	cols, rows := o.grid(perPage)
+	width := (o.PageWidth - 2*bookMargin - (cols-1)*bookGutter) / cols
+	height := (o.PageHeight - 2*bookMargin - bookHeader - bookFooter - (rows-1)*bookGutter) / rows
+	return image.Rect(0, 0, width, height).Add(image.Pt(bookMargin, bookMargin+bookHeader))
+}
+
+// page draws one page of puzzles or answers, each in a slot of a grid for perPage.
+func (o BookOptions) page(puzzles []Puzzle, perPage int, answers bool, number int) (string, error) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n",
+		o.PageWidth, o.PageHeight, o.PageWidth, o.PageHeight)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="white"/>`+"\n", o.PageWidth, o.PageHeight)
+
+	title := o.Title
+	if answers {
+		title += ": Answers"
+	}
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="24">%s</text>`+"\n", bookMargin, bookMargin+24, html.EscapeString(title))
+	fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="14" text-anchor="middle">%d</text>`+"\n", o.PageWidth/2, o.PageHeight-bookMargin, number)
+
+	cols, _ := o.grid(perPage)
+	first := o.slot(perPage)
+	for k, p := range puzzles {
+		slot := first.Add(image.Pt(k%cols*(first.Dx()+bookGutter), k/cols*(first.Dy()+bookGutter)))
+
+		caption := fmt.Sprintf("Puzzle %d · %v", p.Number, p.Stats.Rating())
+		var path []int
+		if answers {
+			caption = fmt.Sprintf("Answer %d", p.Number)
+			path = p.Solution
+		}
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-size="16">%s</text>`+"\n", slot.Min.X, slot.Min.Y+18, html.EscapeString(caption))
+
+		var drawing bytes.Buffer
+		var width, height float64
+		var err error
+		if p.Grid != nil {
+			size := o.Render.gridPixels(p.Grid.Size())
+			width, height, _ = o.Render.svgSize(size.X, size.Y)
+			err = o.Render.EncodeGridSVG(&drawing, p.Grid, path, nil)
+		} else {
+			width, height, _ = o.Render.svgSize(float64(p.Maze.Width*o.Render.CellSize), float64(p.Maze.Height*o.Render.CellSize))
+			err = o.Render.EncodeSVG(&drawing, p.Maze, path, nil)
+		}
+		if err != nil {
+			return "", fmt.Errorf("puzzle %d: %w", p.Number, err)
+		}
+
+		// The drawing is scaled to fill the slot below its caption and centered
+		// across it.
+		scale := math.Floor(min(float64(slot.Dx())/width, float64(slot.Dy()-bookCaption)/height)*1000) / 1000
+		x := float64(slot.Min.X) + (float64(slot.Dx())-width*scale)/2
+		fmt.Fprintf(&sb, `<g transform="translate(%g %d) scale(%g)">`+"\n", svgRound(x), slot.Min.Y+bookCaption, scale)
+		sb.Write(drawing.Bytes())
+		sb.WriteString("</g>\n")
+	}
+
+	sb.WriteString("</svg>\n")
+	return sb.String(), nil
+}
@@ -0,0 +1,209 @@
+package maze_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestGenerateBatch(t *testing.T) {
+	sizes := []image.Point{{4, 4}, {6, 5}, {8, 8}}
+	puzzles, err := GenerateBatch(7, sizes, 12, 3)
+	if err != nil {
+		t.Fatalf("(GenerateBatch) unexpected error: %v", err)
+	}
+
+	wantSizes := []image.Point{{4, 4}, {4, 4}, {4, 4}, {6, 5}, {6, 5}, {8, 8}, {8, 8}}
+	for k, p := range puzzles {
+		if p.Number != k+1 {
+			t.Errorf("(GenerateBatch) want puzzle %d numbered %d, got %d", k, k+1, p.Number)
+		}
+		if p.Maze.Width != wantSizes[k].X || p.Maze.Height != wantSizes[k].Y {
+			t.Errorf("(GenerateBatch) want puzzle %d to be %v, got %dx%d", p.Number, wantSizes[k], p.Maze.Width, p.Maze.Height)
+		}
+		if len(p.Solution) == 0 || p.Stats.SolutionLength != len(p.Solution) {
+			t.Errorf("(GenerateBatch) want puzzle %d solved, got %d cells and stats %+v", p.Number, len(p.Solution), p.Stats)
+		}
+	}
+
+	// The same seed builds the same puzzles on any number of workers.
+	again, err := GenerateBatch(7, sizes, 12, 1)
+	if err != nil {
+		t.Fatalf("(GenerateBatch) unexpected error: %v", err)
+	}
+	for k := range puzzles {
+		if again[k].Seed != puzzles[k].Seed || !reflect.DeepEqual(again[k].Solution, puzzles[k].Solution) {
+			t.Errorf("(GenerateBatch) want puzzle %d the same on one worker", k+1)
+		}
+	}
+	if puzzles[0].Seed == puzzles[1].Seed {
+		t.Errorf("(GenerateBatch) want a seed for each puzzle, got %d twice", puzzles[0].Seed)
+	}
+}
+
+func TestGenerateBatchInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		count   int
+		sizes   []image.Point
+		workers int
+		opts    []GeneratorOptions
+		wantErr error
+	}{
+		{name: "no puzzles", count: 0, sizes: []image.Point{{4, 4}}, workers: 1, wantErr: ErrInvalidBook},
+		{name: "no sizes", count: 2, workers: 1, wantErr: ErrInvalidBook},
+		{name: "no workers", count: 2, sizes: []image.Point{{4, 4}}, workers: 0, wantErr: ErrInvalidBook},
+		{name: "failing maze", count: 3, sizes: []image.Point{{4, 4}}, workers: 2,
+			opts: []GeneratorOptions{WithShape(Hex), WithLevels(Level{Width: 2, Height: 2})}, wantErr: ErrUnsupportedShape},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := GenerateBatch(tc.count, tc.sizes, 1, tc.workers, tc.opts...); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(GenerateBatch) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestBookPages(t *testing.T) {
+	square, err := GenerateBatch(5, []image.Point{{5, 5}}, 3, 2)
+	if err != nil {
+		t.Fatalf("(GenerateBatch) unexpected error: %v", err)
+	}
+	hex, err := GenerateBatch(2, []image.Point{{5, 4}}, 3, 2, WithShape(Hex))
+	if err != nil {
+		t.Fatalf("(GenerateBatch) unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name           string
+		puzzles        []Puzzle
+		puzzlesPerPage int
+		answersPerPage int
+		wantPages      int
+	}{
+		{name: "one per page", puzzles: square, puzzlesPerPage: 1, answersPerPage: 4, wantPages: 7},
+		{name: "several per page", puzzles: square, puzzlesPerPage: 2, answersPerPage: 6, wantPages: 4},
+		{name: "hex", puzzles: hex, puzzlesPerPage: 1, answersPerPage: 4, wantPages: 3},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultBookOptions()
+			o.PuzzlesPerPage, o.AnswersPerPage = tc.puzzlesPerPage, tc.answersPerPage
+			pages, err := o.Pages(tc.puzzles)
+			if err != nil {
+				t.Fatalf("(Pages) unexpected error: %v", err)
+			}
+			if len(pages) != tc.wantPages {
+				t.Fatalf("(Pages) want %d pages, got %d", tc.wantPages, len(pages))
+			}
+
+			// Every puzzle is numbered and rated once, and solved once in the
+			// answer key, which comes last.
+			book := strings.Join(pages, "")
+			for _, p := range tc.puzzles {
+				caption := fmt.Sprintf("Puzzle %d · %v", p.Number, p.Stats.Rating())
+				if n := strings.Count(book, caption+"<"); n != 1 {
+					t.Errorf("(Pages) want %q once, got %d", caption, n)
+				}
+				if n := strings.Count(book, fmt.Sprintf("Answer %d<", p.Number)); n != 1 {
+					t.Errorf("(Pages) want answer %d once, got %d", p.Number, n)
+				}
+			}
+			if n := strings.Count(book, "<polyline"); n != len(tc.puzzles) {
+				t.Errorf("(Pages) want %d solutions drawn, got %d", len(tc.puzzles), n)
+			}
+			if strings.Contains(pages[0], "<polyline") || !strings.Contains(pages[len(pages)-1], "Answers") {
+				t.Errorf("(Pages) want the answers after the puzzles")
+			}
+		})
+	}
+}
+
+func TestBookOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts func(*BookOptions)
+	}{
+		{name: "no puzzles per page", opts: func(o *BookOptions) { o.PuzzlesPerPage = 0 }},
+		{name: "too many answers per page", opts: func(o *BookOptions) { o.AnswersPerPage = 400 }},
+		{name: "small page", opts: func(o *BookOptions) { o.PageWidth, o.PageHeight = 100, 100 }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultBookOptions()
+			tc.opts(&o)
+			if err := o.Validate(); !errors.Is(err, ErrInvalidBook) {
+				t.Errorf("(Validate) want %v, got %v", ErrInvalidBook, err)
+			}
+		})
+	}
+}
+
+func TestWriteBook(t *testing.T) {
+	puzzles, err := GenerateBatch(3, []image.Point{{4, 4}}, 8, 2)
+	if err != nil {
+		t.Fatalf("(GenerateBatch) unexpected error: %v", err)
+	}
+	dir := t.TempDir()
+	o := DefaultBookOptions()
+
+	if err := o.WriteBook(filepath.Join(dir, "book.html"), puzzles); err != nil {
+		t.Fatalf("(WriteBook) unexpected error: %v", err)
+	}
+	var want bytes.Buffer
+	if err := o.EncodeHTML(&want, puzzles); err != nil {
+		t.Fatalf("(EncodeHTML) unexpected error: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "book.html"))
+	if err != nil || !bytes.Equal(got, want.Bytes()) {
+		t.Errorf("(WriteBook) want the HTML of EncodeHTML, got %d bytes (%v)", len(got), err)
+	}
+	if n := strings.Count(want.String(), `<div class="page">`); n != 4 {
+		t.Errorf("(EncodeHTML) want 4 pages, got %d", n)
+	}
+
+	if err := o.WriteBook(filepath.Join(dir, "book.svg"), puzzles); err != nil {
+		t.Fatalf("(WriteBook) unexpected error: %v", err)
+	}
+	for _, name := range []string{"book-01.svg", "book-02.svg", "book-03.svg", "book-04.svg"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("(WriteBook) want %s written: %v", name, err)
+		}
+	}
+
+	if err := o.WriteBook(filepath.Join(dir, "book.png"), puzzles); !errors.Is(err, ErrInvalidBook) {
+		t.Errorf("(WriteBook) want %v, got %v", ErrInvalidBook, err)
+	}
+}
+
+func TestStatsRating(t *testing.T) {
+	testCases := []struct {
+		stats Stats
+		want  Rating
+	}{
+		{stats: Stats{SolutionLength: 20, Difficulty: 50}, want: Easy},
+		{stats: Stats{SolutionLength: 60, Difficulty: 40}, want: Medium},
+		{stats: Stats{SolutionLength: 120, Difficulty: 40}, want: Hard},
+		{stats: Stats{SolutionLength: 300, Difficulty: 45}, want: Expert},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.want.String(), func(t *testing.T) {
+			if got := tc.stats.Rating(); got != tc.want {
+				t.Errorf("(Rating) want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+
+	"tsumegolang/pkg/algo/graph/bfs"
+	"tsumegolang/pkg/algo/graph/common"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+// Box is a maze of Floors levels, each a Width x Height grid of cells, where
+// stairs join cells directly above one another on adjacent floors. Cell n is on
+// floor n/(Width*Height) and numbered within its floor as in a Rectangle. As with
+// Rectangle, Graph holds the candidate edges until a maze is carved into it.
+type Box struct {
+	Width    int
+	Height   int
+	Floors   int
+	Graph    *sparsegraph.Graph
+	Openings []int // border cells whose outer wall is open, such as the entrance and exit
+}
+
+// NewBox builds an undirected graph over the cells of a box, connecting each
+// cell to its neighbors on the same floor and the cells above and below it
+// according to init.
+func NewBox(width, height, floors int, init EdgeInit, opts ...RectangleOption) (*Box, error) {
+	config := rectangleConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.rng == nil {
+		config.rng = newRand(rand.Uint64())
+	}
+	if width < 1 || height < 1 || floors < 1 {
+		return nil, sparsegraph.ErrInvalidConfiguration
+	}
+
+	g, err := sparsegraph.NewGraph(width*height*floors, false)
+	if err != nil {
+		return nil, err
+	}
+	b := &Box{Width: width, Height: height, Floors: floors, Graph: g}
+	if init == NoConnect {
+		return b, nil
+	}
+
+	for n := range g.GetSize() {
+		floor, i, j := b.GraphToBox(n)
+		neighbors := []struct {
+			ok   bool
+			cell int
+		}{
+			{j < width-1, n + 1},             // east
+			{i < height-1, n + width},        // south
+			{floor < floors-1, n + b.area()}, // up
+		}
+		for _, next := range neighbors {
+			if !next.ok {
+				continue
+			}
+			if err := g.Connect(n, next.cell, getNewWeight(init, config.rng)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return b, nil
+}
+
+// area is the number of cells on a floor.
+func (b *Box) area() int {
+	return b.Width * b.Height
+}
+
+// BoxToGraph returns the cell in row i and column j of the given floor.
+func (b *Box) BoxToGraph(floor, i, j int) int {
+	return floor*b.area() + i*b.Width + j
+}
+
+// GraphToBox is the inverse of BoxToGraph.
+func (b *Box) GraphToBox(n int) (int, int, int) {
+	floor, rest := n/b.area(), n%b.area()
+	return floor, rest / b.Width, rest % b.Width
+}
+
+// Stairs reports whether cell n has a passage to the floor above and to the
+// floor below.
+func (b *Box) Stairs(n int) (bool, bool) {
+	_, up := b.Graph.GetEdge(n, n+b.area())
+	_, down := b.Graph.GetEdge(n, n-b.area())
+	return up, down
+}
+
+// Floor returns one floor of b as a Rectangle, with the passages and openings on
+// that floor alone.
+func (b *Box) Floor(floor int) (*Rectangle, error) {
+	r, err := NewRectangle(b.Width, b.Height, NoConnect)
+	if err != nil {
+		return nil, err
+	}
+	first := floor * b.area()
+	for _, e := range b.Graph.GetAllEdges() {
+		from, to := e.From.(int)-first, e.To.(int)-first
+		if from < 0 || to >= b.area() {
+			continue
+		}
+		if err := r.Graph.Connect(from, to, e.Weight); err != nil {
+			return nil, err
+		}
+	}
+	for _, cell := range b.Openings {
+		if cell/b.area() == floor {
+			r.Openings = append(r.Openings, cell-first)
+		}
+	}
+	return r, nil
+}
+
+// floorRuns splits path where it takes the stairs, returning the runs on each
+// floor with their cells numbered as in Floor.
+func (b *Box) floorRuns(path []int) [][][]int {
+	runs := make([][][]int, b.Floors)
+	for k := 0; k < len(path); {
+		floor := path[k] / b.area()
+		run := []int{}
+		for ; k < len(path) && path[k]/b.area() == floor; k++ {
+			run = append(run, path[k]-floor*b.area())
+		}
+		runs[floor] = append(runs[floor], run)
+	}
+	return runs
+}
+
+// stairMarkers returns the triangles marking the stairs on a floor drawn with
+// cells size across: one pointing up left of the center of a cell with stairs up,
+// and one pointing down right of the center of a cell with stairs down.
+func (b *Box) stairMarkers(floor int, size float64) [][]Point {
+	half := size / 6
+	markers := [][]Point{}
+	for cell := range b.area() {
+		up, down := b.Stairs(floor*b.area() + cell)
+		i, j := cell/b.Width, cell%b.Width
+		x, y := (float64(j)+0.5)*size, (float64(i)+0.5)*size
+		if up {
+			left := x - size/4
+			markers = append(markers, []Point{{left, y - half}, {left + half, y + half}, {left - half, y + half}})
+		}
+		if down {
+			right := x + size/4
+			markers = append(markers, []Point{{right, y + half}, {right - half, y - half}, {right + half, y - half}})
+		}
+	}
+	return markers
+}
+
+// manhattan returns the fewest steps from a cell to goal, ignoring walls.
+func (b *Box) manhattan(goal int) func(int) int {
+	gf, gi, gj := b.GraphToBox(goal)
+	return func(cell int) int {
+		f, i, j := b.GraphToBox(cell)
+		return abs(f-gf) + abs(i-gi) + abs(j-gj)
+	}
+}
+
+// SolveBox is Solve for boxes, finding paths that climb and descend stairs.
+func (s Solver) SolveBox(b *Box, start, goal int) ([]int, error) {
+	var path []int
+	var err error
+	switch s {
+	case BFS:
+		path, err = bfs.ShortestPath(b.Graph, start, goal)
+	case AStar:
+		path, err = aStar(b.Graph, start, goal, b.manhattan(goal), nil)
+	default:
+		err = fmt.Errorf("%w: %v", ErrUnknownSolver, s)
+	}
+	if errors.Is(err, common.ErrNoPath) {
+		return nil, ErrNoSolution
+	}
+
+	return path, err
+}
+
+// AnalyzeBox is Analyze for boxes, with the floors laid side by side as they are
+// drawn, so taking the stairs counts as a turn.
+func AnalyzeBox(b *Box, solution []int) Stats {
+	included := func(int) bool { return true }
+	center := func(cell int) Point {
+		floor, i, j := b.GraphToBox(cell)
+		return Point{float64(floor*(b.Width+1) + j), float64(i)}
+	}
+	return analyze(b.Graph, included, center, solution)
+}
+
+// WithFloors builds a Box of n floors of the maze's width and height, joined by
+// stairs, instead of a Rectangle when n is more than 1. The entrance is on the
+// first floor and the exit on the last, at the cells chosen by WithOpenings.
+// Shapes, masks, levels, recursion, loops, animation, farthest openings and the
+// row by row algorithms are not supported.
+func WithFloors(n int) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.floors = n
+	}
+}
+
+// Box returns the maze generated with WithFloors, or nil.
+func (mg *MazeGenerator) Box() *Box {
+	return mg.box
+}
+
+// generateBox is Generate for WithFloors.
+func (mg *MazeGenerator) generateBox() error {
+	if mg.shape != Square || mg.mask != nil || mg.levels != nil || mg.recursionLevel > 0 || mg.compact ||
+		mg.braid > 0 || mg.extraPassages > 0 || mg.animationFile != "" || mg.openings == farthestOpenings || mg.weightFunc() != nil || mg.weave > 0 {
+		return fmt.Errorf("%w: mazes with floors are square, without masks, levels, recursion, loops, animation, farthest openings, weights or weaving", ErrUnsupportedShape)
+	}
+
+	b, err := NewBox(mg.width, mg.height, mg.floors, ConnectRandom, WithRand(mg.rng))
+	if err != nil {
+		return fmt.Errorf("failed to generate maze: %w", err)
+	}
+	if b.Graph, err = mg.algorithm.CarveGraph(b.Graph, mg.rng); err != nil {
+		return fmt.Errorf("failed to generate maze: %w", err)
+	}
+	mg.box = b
+
+	// Openings are placed on a floor, whose cells are numbered as in the box.
+	floor, err := NewRectangle(b.Width, b.Height, NoConnect)
+	if err != nil {
+		return err
+	}
+	entrance, exit := 0, b.area()-1
+	if mg.openings == pointOpenings {
+		if entrance, exit, err = mg.chosenOpenings(floor.borderCell, entrance, exit); err != nil {
+			return fmt.Errorf("failed to place openings: %w", err)
+		}
+	}
+	mg.entrancePoint, mg.exitPoint = floor.cellPoint(entrance), floor.cellPoint(exit)
+	exit += (b.Floors - 1) * b.area()
+	b.Openings = []int{entrance, exit}
+	mg.entrance, mg.exit = entrance, exit
+
+	if mg.solution, err = mg.solver.SolveBox(b, entrance, exit); err != nil {
+		return fmt.Errorf("failed to solve maze: %w", err)
+	}
+
+	files := []struct {
+		name string
+		path []int
+	}{
+		{mg.filename, nil},
+		{mg.solutionFile, mg.solution},
+	}
+	for _, file := range files {
+		if file.name == "" {
+			continue
+		}
+		if err := mg.render.WriteBox(b, file.name, mg.formatFor(file.name), file.path, mg.Metadata()); err != nil {
+			return fmt.Errorf("failed to draw maze: %w", err)
+		}
+	}
+	return nil
+}
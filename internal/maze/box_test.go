@@ -0,0 +1,306 @@
+package maze_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"image"
+	"image/color"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestNewBox(t *testing.T) {
+	testCases := []struct {
+		width, height, floors int
+		wantEdges             int
+	}{
+		{width: 1, height: 1, floors: 1, wantEdges: 0},
+		{width: 1, height: 1, floors: 4, wantEdges: 3},
+		{width: 3, height: 2, floors: 1, wantEdges: 7},
+		{width: 3, height: 2, floors: 3, wantEdges: 3*7 + 2*6},
+	}
+
+	for _, tc := range testCases {
+		b, err := NewBox(tc.width, tc.height, tc.floors, ConnectConst)
+		if err != nil {
+			t.Fatalf("(NewBox) unexpected error: %v", err)
+		}
+		if got := len(b.Graph.GetAllEdges()); got != tc.wantEdges {
+			t.Errorf("(NewBox) want %d edges in a %dx%dx%d box, got %d", tc.wantEdges, tc.width, tc.height, tc.floors, got)
+		}
+	}
+
+	if _, err := NewBox(3, 3, 0, ConnectConst); err == nil {
+		t.Errorf("(NewBox) want an error without floors")
+	}
+}
+
+func TestBoxToGraph(t *testing.T) {
+	b, _ := NewBox(4, 3, 2, NoConnect)
+	if n := b.BoxToGraph(1, 2, 3); n != 23 {
+		t.Errorf("(BoxToGraph) want 23, got %d", n)
+	}
+	if floor, i, j := b.GraphToBox(17); floor != 1 || i != 1 || j != 1 {
+		t.Errorf("(GraphToBox) want floor 1, row 1, column 1, got %d, %d, %d", floor, i, j)
+	}
+}
+
+func TestGenerateBox(t *testing.T) {
+	for _, algorithm := range []Algorithm{Kruskal, Prim, Backtracker, Wilson, AldousBroder} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			mg := NewMazeGenerator(5, 4, WithFloors(3), WithAlgorithm(algorithm), WithSeed(6))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+			b := mg.Box()
+			if b == nil || mg.Maze() != nil {
+				t.Fatalf("(Generate) want a box and no rectangle")
+			}
+
+			// The passages join neighbors and form a tree reaching every cell.
+			n := b.Width * b.Height * b.Floors
+			edges := b.Graph.GetAllEdges()
+			if len(edges) != n-1 {
+				t.Fatalf("(Generate) want %d passages, got %d", n-1, len(edges))
+			}
+			for _, e := range edges {
+				f1, i1, j1 := b.GraphToBox(e.From.(int))
+				f2, i2, j2 := b.GraphToBox(e.To.(int))
+				if d := (f1-f2)*(f1-f2) + (i1-i2)*(i1-i2) + (j1-j2)*(j1-j2); d != 1 {
+					t.Fatalf("(Generate) passage %v-%v does not join neighbors", e.From, e.To)
+				}
+			}
+			for cell := range n {
+				if _, err := BFS.SolveBox(b, 0, cell); err != nil {
+					t.Fatalf("(SolveBox) want a path to cell %d, got %v", cell, err)
+				}
+			}
+
+			// The solution climbs from the first floor to the last.
+			solution := mg.Solution()
+			if solution[0] != 0 || solution[len(solution)-1] != n-1 {
+				t.Errorf("(Generate) want a solution from 0 to %d, got %v", n-1, solution)
+			}
+			if stats := mg.Stats(); stats.Cells != n || stats.SolutionLength != len(solution) {
+				t.Errorf("(Stats) want %d cells and a solution of %d, got %+v", n, len(solution), stats)
+			}
+		})
+	}
+}
+
+func TestSolveBox(t *testing.T) {
+	// Two 2x1 floors: the only way from the west cell of the first floor to
+	// the west cell of the second goes east and up the stairs.
+	b, _ := NewBox(2, 1, 2, NoConnect)
+	b.Graph.Connect(0, 1, 1)
+	b.Graph.Connect(1, 3, 1)
+	b.Graph.Connect(3, 2, 1)
+
+	for _, solver := range []Solver{BFS, AStar} {
+		path, err := solver.SolveBox(b, 0, 2)
+		if err != nil || !reflect.DeepEqual(path, []int{0, 1, 3, 2}) {
+			t.Errorf("(SolveBox) want %v with %v, got %v (%v)", []int{0, 1, 3, 2}, solver, path, err)
+		}
+	}
+
+	b.Graph.Disconnect(1, 3)
+	if _, err := BFS.SolveBox(b, 0, 2); !errors.Is(err, ErrNoSolution) {
+		t.Errorf("(SolveBox) want %v, got %v", ErrNoSolution, err)
+	}
+}
+
+func TestBoxFloor(t *testing.T) {
+	b, _ := NewBox(2, 2, 2, NoConnect)
+	b.Graph.Connect(0, 1, 1)
+	b.Graph.Connect(1, 5, 1)
+	b.Graph.Connect(5, 7, 1)
+	b.Openings = []int{0, 7}
+
+	testCases := []struct {
+		floor        int
+		wantEdges    int
+		wantOpenings []int
+		wantUp       []int
+		wantDown     []int
+	}{
+		{floor: 0, wantEdges: 1, wantOpenings: []int{0}, wantUp: []int{1}},
+		{floor: 1, wantEdges: 1, wantOpenings: []int{3}, wantDown: []int{5}},
+	}
+
+	for _, tc := range testCases {
+		r, err := b.Floor(tc.floor)
+		if err != nil {
+			t.Fatalf("(Floor) unexpected error: %v", err)
+		}
+		if got := len(r.Graph.GetAllEdges()); got != tc.wantEdges {
+			t.Errorf("(Floor) want %d passages on floor %d, got %d", tc.wantEdges, tc.floor, got)
+		}
+		if !reflect.DeepEqual(r.Openings, tc.wantOpenings) {
+			t.Errorf("(Floor) want openings %v on floor %d, got %v", tc.wantOpenings, tc.floor, r.Openings)
+		}
+		for cell := range 4 {
+			n := b.BoxToGraph(tc.floor, cell/2, cell%2)
+			up, down := b.Stairs(n)
+			if up != slices.Contains(tc.wantUp, n) || down != slices.Contains(tc.wantDown, n) {
+				t.Errorf("(Stairs) want up %t and down %t at %d, got %t and %t", slices.Contains(tc.wantUp, n), slices.Contains(tc.wantDown, n), n, up, down)
+			}
+		}
+	}
+}
+
+func TestDrawBox(t *testing.T) {
+	mg := NewMazeGenerator(4, 3, WithFloors(2), WithSeed(1))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	b := mg.Box()
+
+	o := DefaultRenderOptions()
+	o.Margin = 2
+	img, err := o.DrawBox(b, mg.Solution())
+	if err != nil {
+		t.Fatalf("(DrawBox) unexpected error: %v", err)
+	}
+	// Two floors of 4x3 cells with their margins, a cell apart.
+	floorWidth := 4*SquareSize + 4
+	if want := image.Rect(0, 0, 2*floorWidth+SquareSize, 3*SquareSize+4); img.Bounds() != want {
+		t.Fatalf("(DrawBox) want bounds %v, got %v", want, img.Bounds())
+	}
+
+	// The stairs are marked left of the cell center going up and right of it
+	// going down, on the floor above.
+	for cell := range 12 {
+		up, _ := b.Stairs(cell)
+		if !up {
+			continue
+		}
+		i, j := cell/4, cell%4
+		x, y := 2+j*SquareSize+SquareSize/4, 2+i*SquareSize+SquareSize/2+1
+		if color.RGBAModel.Convert(img.At(x, y)) != color.RGBAModel.Convert(color.Black) {
+			t.Errorf("(DrawBox) want a stair up marked at %d,%d on the first floor, got %v", x, y, img.At(x, y))
+		}
+		x += floorWidth + SquareSize + SquareSize/2
+		if color.RGBAModel.Convert(img.At(x, y)) != color.RGBAModel.Convert(color.Black) {
+			t.Errorf("(DrawBox) want a stair down marked at %d,%d on the second floor, got %v", x, y, img.At(x, y))
+		}
+	}
+
+	// The gap between the floors is left blank.
+	for y := range img.Bounds().Dy() {
+		if x := floorWidth + SquareSize/2; color.RGBAModel.Convert(img.At(x, y)) != color.RGBAModel.Convert(color.White) {
+			t.Fatalf("(DrawBox) want the gap between floors blank, got %v at %d,%d", img.At(x, y), x, y)
+		}
+	}
+}
+
+func TestEncodeBoxSVG(t *testing.T) {
+	mg := NewMazeGenerator(5, 5, WithFloors(3), WithSeed(2))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := mg.Encode(&buf, SVG, true); err != nil {
+		t.Fatalf("(Encode) unexpected error: %v", err)
+	}
+
+	var doc struct {
+		Groups []struct {
+			Polylines []struct{} `xml:"polyline"`
+		} `xml:"g"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("(Encode) invalid SVG: %v", err)
+	}
+	if len(doc.Groups) != 3 {
+		t.Fatalf("(Encode) want a group for each of 3 floors, got %d", len(doc.Groups))
+	}
+
+	// There is a run of the solution for every stretch between stairs.
+	runs, floor := 0, -1
+	for _, cell := range mg.Solution() {
+		if cell/25 != floor {
+			runs, floor = runs+1, cell/25
+		}
+	}
+	got := 0
+	for _, g := range doc.Groups {
+		got += len(g.Polylines)
+	}
+	if got != runs {
+		t.Errorf("(Encode) want %d runs of the solution, got %d", runs, got)
+	}
+}
+
+func TestGenerateBoxFiles(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "maze.png")
+	mg := NewMazeGenerator(6, 4, WithFloors(2), WithSeed(3), WithOpenings(image.Pt(2, 0), image.Pt(5, 1)),
+		WithFilename(filename), WithSolutionFilename(filepath.Join(dir, "solution.svg")))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if want := []int{2, 24 + 11}; !reflect.DeepEqual(mg.Box().Openings, want) {
+		t.Errorf("(Generate) want openings %v, got %v", want, mg.Box().Openings)
+	}
+
+	metadata, err := ReadMetadata(filename)
+	if err != nil {
+		t.Fatalf("(ReadMetadata) unexpected error: %v", err)
+	}
+	if metadata[MetadataFloors] != "2" {
+		t.Errorf("(ReadMetadata) want 2 floors, got %q", metadata[MetadataFloors])
+	}
+	again, err := NewMazeGeneratorFromMetadata(metadata)
+	if err != nil {
+		t.Fatalf("(NewMazeGeneratorFromMetadata) unexpected error: %v", err)
+	}
+	if err := again.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(again.Solution(), mg.Solution()) {
+		t.Errorf("(Generate) want the solution %v from the metadata, got %v", mg.Solution(), again.Solution())
+	}
+
+	text := NewMazeGenerator(4, 4, WithFloors(2), WithFilename(filepath.Join(dir, "maze.txt")))
+	if err := text.Generate(); !errors.Is(err, ErrUnsupportedShape) || !strings.Contains(err.Error(), "txt") {
+		t.Errorf("(Generate) want %v for a text file, got %v", ErrUnsupportedShape, err)
+	}
+}
+
+func TestGenerateBoxUnsupported(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []GeneratorOptions
+		wantErr error
+	}{
+		{name: "algorithm", opts: []GeneratorOptions{WithAlgorithm(Eller)}, wantErr: ErrUnsupportedAlgorithm},
+		{name: "shape", opts: []GeneratorOptions{WithShape(Hex)}, wantErr: ErrUnsupportedShape},
+		{name: "recursion", opts: []GeneratorOptions{WithRecursionLevel(1)}, wantErr: ErrUnsupportedShape},
+		{name: "braid", opts: []GeneratorOptions{WithBraid(0.5)}, wantErr: ErrUnsupportedShape},
+		{name: "compact", opts: []GeneratorOptions{WithCompact()}, wantErr: ErrUnsupportedShape},
+		{name: "farthest openings", opts: []GeneratorOptions{WithFarthestOpenings()}, wantErr: ErrUnsupportedShape},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]GeneratorOptions{WithFloors(2)}, tc.opts...)
+			if err := NewMazeGenerator(6, 6, opts...).Generate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	mg := NewMazeGenerator(4, 4, WithFloors(2))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if _, err := mg.Game(); !errors.Is(err, ErrUnsupportedShape) {
+		t.Errorf("(Game) want %v, got %v", ErrUnsupportedShape, err)
+	}
+}
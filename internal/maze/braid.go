@@ -0,0 +1,95 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+
+	"tsumegolang/pkg/ds/graph"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+var (
+	ErrInvalidBraid = errors.New("braid ratio must be between 0 and 1")
+	ErrInvalidCount = errors.New("number of extra passages must not be negative")
+)
+
+// Braid adds loops to a carved maze by opening a wall from ratio of its dead
+// ends, chosen at random. Walls are opened between neighbors in candidates,
+// preferring neighbors that are dead ends themselves so one passage removes two.
+// A ratio of 1 leaves no dead ends.
+func Braid(paths, candidates *sparsegraph.Graph, ratio float64, rng *rand.Rand) error {
+	if ratio < 0 || ratio > 1 || math.IsNaN(ratio) {
+		return fmt.Errorf("%w: %g", ErrInvalidBraid, ratio)
+	}
+
+	neighbors := adjacency(candidates)
+	degree := make([]int, paths.GetSize())
+	for cell, next := range adjacency(paths) {
+		degree[cell] = len(next)
+	}
+
+	deadEnds := []int{}
+	for cell, d := range degree {
+		if d == 1 {
+			deadEnds = append(deadEnds, cell)
+		}
+	}
+	rng.Shuffle(len(deadEnds), func(i, j int) { deadEnds[i], deadEnds[j] = deadEnds[j], deadEnds[i] })
+
+	for _, cell := range deadEnds[:int(math.Round(ratio*float64(len(deadEnds))))] {
+		if degree[cell] != 1 {
+			continue // already joined by a neighboring dead end
+		}
+
+		walls, deadEndWalls := []int{}, []int{}
+		for _, next := range neighbors[cell] {
+			if _, open := paths.GetEdge(cell, next); open {
+				continue
+			}
+			walls = append(walls, next)
+			if degree[next] == 1 {
+				deadEndWalls = append(deadEndWalls, next)
+			}
+		}
+		if len(deadEndWalls) > 0 {
+			walls = deadEndWalls
+		}
+		if len(walls) == 0 {
+			continue
+		}
+
+		next := walls[rng.IntN(len(walls))]
+		if err := paths.Connect(cell, next, DefaultWeight); err != nil {
+			return err
+		}
+		degree[cell]++
+		degree[next]++
+	}
+
+	return nil
+}
+
+// AddPassages opens n walls of a carved maze at random, between neighbors in
+// candidates, or every wall if there are fewer.
+func AddPassages(paths, candidates *sparsegraph.Graph, n int, rng *rand.Rand) error {
+	if n < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidCount, n)
+	}
+
+	walls := slices.DeleteFunc(candidates.GetAllEdges(), func(e graph.Edge) bool {
+		_, open := paths.GetEdge(e.From.(int), e.To.(int))
+		return open
+	})
+	rng.Shuffle(len(walls), func(i, j int) { walls[i], walls[j] = walls[j], walls[i] })
+
+	for _, e := range walls[:min(n, len(walls))] {
+		if err := paths.Connect(e.From.(int), e.To.(int), DefaultWeight); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package maze_test
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+
+	. "tsumegolang/internal/maze"
+)
+
+// newTestPaths carves a 12x12 maze, returning its passages and its candidate walls.
+func newTestPaths(t *testing.T, rng *rand.Rand) (*sparsegraph.Graph, *sparsegraph.Graph) {
+	t.Helper()
+
+	r, err := NewRectangle(12, 12, ConnectRandom, WithRand(rng))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	paths, err := Kruskal.Carve(r, rng)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return paths, r.Graph
+}
+
+func countDeadEnds(g *sparsegraph.Graph) int {
+	degree := make([]int, g.GetSize())
+	for _, e := range g.GetAllEdges() {
+		degree[e.From.(int)]++
+		degree[e.To.(int)]++
+	}
+	count := 0
+	for _, d := range degree {
+		if d == 1 {
+			count++
+		}
+	}
+	return count
+}
+
+func TestBraid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ratio   float64
+		wantErr error
+	}{
+		{name: "perfect", ratio: 0},
+		{name: "half", ratio: 0.5},
+		{name: "full", ratio: 1},
+		{name: "too large", ratio: 1.5, wantErr: ErrInvalidBraid},
+		{name: "negative", ratio: -0.1, wantErr: ErrInvalidBraid},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(12, 34))
+			paths, candidates := newTestPaths(t, rng)
+			before := countDeadEnds(paths)
+			edges := len(paths.GetAllEdges())
+
+			err := Braid(paths, candidates, tc.ratio, rng)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(Braid) want %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			// Every chosen dead end is removed, possibly along with a neighboring one.
+			after := countDeadEnds(paths)
+			if want := before - int(math.Round(tc.ratio*float64(before))); after > want {
+				t.Errorf("(Braid) want at most %d of %d dead ends left, got %d", want, before, after)
+			}
+			opened := len(paths.GetAllEdges()) - edges
+			if opened > before-after {
+				t.Errorf("(Braid) opened %d walls to remove %d dead ends", opened, before-after)
+			}
+			for _, e := range paths.GetAllEdges() {
+				if _, ok := candidates.GetEdge(e.From.(int), e.To.(int)); !ok {
+					t.Errorf("(Braid) passage %v-%v does not join neighbors", e.From, e.To)
+				}
+			}
+		})
+	}
+}
+
+func TestAddPassages(t *testing.T) {
+	testCases := []struct {
+		name      string
+		n         int
+		wantAdded int
+		wantErr   error
+	}{
+		{name: "none", n: 0, wantAdded: 0},
+		{name: "some", n: 10, wantAdded: 10},
+		{name: "more than there are walls", n: 1000, wantAdded: 2*12*11 - (12*12 - 1)},
+		{name: "negative", n: -1, wantErr: ErrInvalidCount},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(5, 6))
+			paths, candidates := newTestPaths(t, rng)
+			edges := len(paths.GetAllEdges())
+
+			err := AddPassages(paths, candidates, tc.n, rng)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(AddPassages) want %v, got %v", tc.wantErr, err)
+			}
+			if got := len(paths.GetAllEdges()) - edges; err == nil && got != tc.wantAdded {
+				t.Errorf("(AddPassages) want %d new passages, got %d", tc.wantAdded, got)
+			}
+		})
+	}
+}
+
+func TestGenerateBraided(t *testing.T) {
+	for _, shape := range []Shape{Square, Hex} {
+		t.Run(shape.String(), func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "maze.png")
+			mg := NewMazeGenerator(10, 8, WithSeed(21), WithShape(shape), WithBraid(1), WithExtraPassages(5), WithFilename(filename))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			var paths *sparsegraph.Graph
+			var astar []int
+			var err error
+			if shape == Square {
+				paths = mg.Maze().Graph
+				astar, err = AStar.Solve(mg.Maze(), mg.Solution()[0], mg.Solution()[len(mg.Solution())-1])
+			} else {
+				paths = mg.Grid().Graph
+				astar, err = AStar.SolveGrid(mg.Grid(), mg.Solution()[0], mg.Solution()[len(mg.Solution())-1])
+			}
+			if n := countDeadEnds(paths); n != 0 {
+				t.Errorf("want no dead ends in a fully braided maze, got %d", n)
+			}
+			if len(paths.GetAllEdges()) < paths.GetSize() {
+				t.Errorf("want loops, got %d passages for %d cells", len(paths.GetAllEdges()), paths.GetSize())
+			}
+
+			// With loops the solvers may choose different paths, but of the same length.
+			if err != nil || len(astar) != len(mg.Solution()) {
+				t.Errorf("(Solve) want a %d cell path from A*, got %d cells and error %v", len(mg.Solution()), len(astar), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math/rand/v2"
+
+	"tsumegolang/pkg/ds/basic"
+	"tsumegolang/pkg/ds/graph/gridgraph"
+)
+
+var (
+	ErrUnsupportedCompact = errors.New("option is not supported for compact mazes")
+)
+
+// errStopped ends a carving whose passages are no longer wanted.
+var errStopped = errors.New("stopped")
+
+// Compact is a rectangular maze whose passages are held in a gridgraph.Graph, two
+// bits a cell, for mazes too large for the graph of maps of a Rectangle. It has
+// no mask and no sub-mazes.
+type Compact struct {
+	Width    int
+	Height   int
+	Graph    *gridgraph.Graph
+	Openings []int // border cells whose outer wall is open, such as the entrance and exit
+}
+
+// NewCompact returns a width x height maze with every wall standing.
+func NewCompact(width, height int) (*Compact, error) {
+	g, err := gridgraph.NewGraph(width, height)
+	if err != nil {
+		return nil, err
+	}
+	return &Compact{Width: width, Height: height, Graph: g}, nil
+}
+
+// Stream carves a width x height maze with an algorithm that works row by row,
+// yielding the cells each passage joins as it is carved, from the top row down.
+// Only the algorithm's state for the current row is kept. The passages are the
+// ones Carve chooses from rng in the same state; other algorithms return
+// ErrUnsupportedAlgorithm.
+func (a Algorithm) Stream(width, height int, rng *rand.Rand) (iter.Seq2[int, int], error) {
+	carveRows, ok := a.rowCarver()
+	if !ok {
+		return nil, fmt.Errorf("%w: %v does not carve row by row", ErrUnsupportedAlgorithm, a)
+	}
+	if width <= 0 || height <= 0 {
+		return nil, gridgraph.ErrInvalidConfiguration
+	}
+
+	return func(yield func(from, to int) bool) {
+		carveRows(width, height, rng, func(from, to int) error {
+			if !yield(from, to) {
+				return errStopped
+			}
+			return nil
+		})
+	}, nil
+}
+
+// CarveCompact carves a width x height maze with Stream straight into a Compact.
+func (a Algorithm) CarveCompact(width, height int, rng *rand.Rand) (*Compact, error) {
+	passages, err := a.Stream(width, height, rng)
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewCompact(width, height)
+	if err != nil {
+		return nil, err
+	}
+	for from, to := range passages {
+		if err := c.Graph.Connect(from, to, DefaultWeight); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// frame returns a Rectangle with the size and openings of c but no passages, for
+// the methods that only need its shape.
+func (c *Compact) frame() *Rectangle {
+	return &Rectangle{Width: c.Width, Height: c.Height, Openings: c.Openings}
+}
+
+// Solve returns the cells on the shortest path from start to goal, including both
+// ends. The search keeps one byte per cell for the step that reached it, rather
+// than an adjacency list.
+func (c *Compact) Solve(start, goal int) ([]int, error) {
+	n := c.Width * c.Height
+	if start < 0 || start >= n || goal < 0 || goal >= n {
+		return nil, ErrNoSolution
+	}
+
+	// Cells hold the wall they were entered through plus one, or 0 if unseen.
+	steps := [...]int{-c.Width, 1, c.Width, -1} // north, east, south and west
+	entered := make([]uint8, n)
+	entered[start] = 1
+
+	queue := basic.NewQueue[int]()
+	queue.Enqueue(start)
+	for current, ok := queue.Dequeue(); ok && current != goal; current, ok = queue.Dequeue() {
+		for side, step := range steps {
+			next := current + step
+			if c.Graph.Connected(current, next) && entered[next] == 0 && next != start {
+				entered[next] = uint8(side + 1)
+				queue.Enqueue(next)
+			}
+		}
+	}
+	if goal != start && entered[goal] == 0 {
+		return nil, ErrNoSolution
+	}
+
+	path := []int{goal}
+	for current := goal; current != start; {
+		current -= steps[entered[current]-1]
+		path = append(path, current)
+	}
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path, nil
+}
+
+// Analyze is the function Analyze for compact mazes. The diameter and average
+// branch length need a search from every cell and are left at 0.
+func (c *Compact) Analyze(solution []int) Stats {
+	s := Stats{
+		Cells:          c.Width * c.Height,
+		Passages:       c.Graph.EdgeCount(),
+		SolutionLength: len(solution),
+	}
+	degree := func(cell int) int {
+		next := []int{cell - c.Width, cell + c.Width}
+		if c.Width > 1 { // otherwise the cells either side are those above and below
+			next = append(next, cell-1, cell+1)
+		}
+		d := 0
+		for _, n := range next {
+			if c.Graph.Connected(cell, n) {
+				d++
+			}
+		}
+		return d
+	}
+
+	for cell := range s.Cells {
+		switch degree(cell) {
+		case 1:
+			s.DeadEnds++
+		case 2:
+			s.Corridors++
+		case 0:
+		default:
+			s.Junctions++
+		}
+	}
+	// Row-by-row carving always joins every cell.
+	s.Loops = s.Passages - s.Cells + 1
+
+	center := func(cell int) Point {
+		return Point{float64(cell % c.Width), float64(cell / c.Width)}
+	}
+	s.scoreSolution(solution, degree, center)
+	return s
+}
+
+// horizontalWall reports whether there is a wall along the top of cell (x, y),
+// as Rectangle.horizontalWall does.
+func (c *Compact) horizontalWall(x, y int, openings map[int]wall) bool {
+	switch {
+	case y > 0 && y < c.Height:
+		return !c.Graph.Connected((y-1)*c.Width+x, y*c.Width+x)
+	case y == c.Height:
+		open, ok := openings[(y-1)*c.Width+x]
+		return !ok || open != wallSouth
+	}
+	open, ok := openings[x]
+	return !ok || open != wallNorth
+}
+
+// verticalWall reports whether there is a wall along the left of cell (x, y), as
+// Rectangle.verticalWall does.
+func (c *Compact) verticalWall(x, y int, openings map[int]wall) bool {
+	switch {
+	case x > 0 && x < c.Width:
+		return !c.Graph.Connected(y*c.Width+x-1, y*c.Width+x)
+	case x == c.Width:
+		open, ok := openings[y*c.Width+x-1]
+		return !ok || open != wallEast
+	}
+	open, ok := openings[y*c.Width]
+	return !ok || open != wallWest
+}
+
+func (c *Compact) blockRow(int) bool {
+	return false
+}
+
+func (c *Compact) blockColumn(int) bool {
+	return false
+}
+
+// WithCompact carves the maze with a row-by-row algorithm straight into a Compact
+// and draws it a band of rows at a time, so mazes of millions of cells fit in
+// memory. The maze is the one Generate carves from the same seed without it.
+// Only Eller, BinaryTree and Sidewinder stream rows; the default Kruskal and the
+// other algorithms fail with ErrUnsupportedAlgorithm. Shapes, masks, levels,
+// recursion, loops, animation, farthest openings, weights and weaving are not
+// supported, and files must be PNG or SVG.
+func WithCompact() GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.compact = true
+	}
+}
+
+// Compact returns the maze generated with WithCompact, or nil.
+func (mg *MazeGenerator) Compact() *Compact {
+	return mg.compactMaze
+}
+
+// generateCompact is Generate for WithCompact.
+func (mg *MazeGenerator) generateCompact() error {
+	if mg.shape != Square || mg.mask != nil || mg.levels != nil || mg.recursionLevel > 0 ||
+		mg.braid > 0 || mg.extraPassages > 0 || mg.animationFile != "" || mg.openings == farthestOpenings || mg.weightFunc() != nil || mg.weave > 0 {
+		return fmt.Errorf("%w: want a square maze without masks, levels, recursion, loops, animation, farthest openings, weights or weaving", ErrUnsupportedCompact)
+	}
+
+	// A Rectangle draws a random weight for every candidate passage before the
+	// rows are carved; drawing as many here carves the same maze.
+	for range (mg.width-1)*mg.height + mg.width*(mg.height-1) {
+		mg.rng.Float64()
+	}
+	c, err := mg.algorithm.CarveCompact(mg.width, mg.height, mg.rng)
+	if err != nil {
+		return fmt.Errorf("failed to generate maze: %w", err)
+	}
+	mg.compactMaze = c
+
+	frame := c.frame()
+	entrance, exit := 0, c.Width*c.Height-1
+	if mg.openings == pointOpenings {
+		if entrance, exit, err = mg.chosenOpenings(frame.borderCell, entrance, exit); err != nil {
+			return fmt.Errorf("failed to place openings: %w", err)
+		}
+	}
+	c.Openings = []int{entrance, exit}
+	mg.entrance, mg.exit = entrance, exit
+	mg.entrancePoint, mg.exitPoint = frame.cellPoint(entrance), frame.cellPoint(exit)
+
+	if mg.solution, err = c.Solve(entrance, exit); err != nil {
+		return fmt.Errorf("failed to solve maze: %w", err)
+	}
+
+	files := []struct {
+		name string
+		path []int
+	}{
+		{mg.filename, nil},
+		{mg.solutionFile, mg.solution},
+	}
+	for _, file := range files {
+		if file.name == "" {
+			continue
+		}
+		if err := mg.render.WriteCompact(c, file.name, mg.formatFor(file.name), file.path, mg.Metadata()); err != nil {
+			return fmt.Errorf("failed to draw maze: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,317 @@
+package maze_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand/v2"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+// generatePair generates the same maze as a Rectangle and as a Compact.
+func generatePair(t testing.TB, width, height int, opts ...GeneratorOptions) (*MazeGenerator, *MazeGenerator) {
+	t.Helper()
+
+	rect := NewMazeGenerator(width, height, opts...)
+	if err := rect.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	compact := NewMazeGenerator(width, height, append(opts, WithCompact())...)
+	if err := compact.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	return rect, compact
+}
+
+func TestGenerateCompact(t *testing.T) {
+	for _, algorithm := range []Algorithm{Eller, BinaryTree, Sidewinder} {
+		for _, size := range []image.Point{{1, 1}, {9, 1}, {1, 7}, {70, 13}} {
+			t.Run(fmt.Sprintf("%v %v", algorithm, size), func(t *testing.T) {
+				rect, compact := generatePair(t, size.X, size.Y, WithSeed(5), WithAlgorithm(algorithm))
+				c := compact.Compact()
+				if c == nil || compact.Maze() != nil {
+					t.Fatalf("(Generate) want only a compact maze, got %v and %v", c, compact.Maze())
+				}
+
+				want := rect.Maze().Graph.GetAllEdges()
+				got := c.Graph.GetAllEdges()
+				if len(got) != len(want) {
+					t.Fatalf("(Generate) want the %d passages of the same seed, got %d", len(want), len(got))
+				}
+				for k := range want {
+					if want[k].From != got[k].From || want[k].To != got[k].To {
+						t.Fatalf("(Generate) want passage %v, got %v", want[k], got[k])
+					}
+				}
+				if !reflect.DeepEqual(compact.Solution(), rect.Solution()) {
+					t.Errorf("(Generate) want solution %v, got %v", rect.Solution(), compact.Solution())
+				}
+
+				wantStats, gotStats := rect.Stats(), compact.Stats()
+				wantStats.Diameter, wantStats.AverageBranch = 0, 0
+				if gotStats != wantStats {
+					t.Errorf("(Stats) want %+v, got %+v", wantStats, gotStats)
+				}
+			})
+		}
+	}
+}
+
+func TestStream(t *testing.T) {
+	passages, err := Sidewinder.Stream(6, 4, rand.New(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatalf("(Stream) unexpected error: %v", err)
+	}
+	c, err := Sidewinder.CarveCompact(6, 4, rand.New(rand.NewPCG(1, 2)))
+	if err != nil {
+		t.Fatalf("(CarveCompact) unexpected error: %v", err)
+	}
+
+	count, row := 0, 0
+	for from, to := range passages {
+		if !c.Graph.Connected(from, to) {
+			t.Errorf("(Stream) passage %d-%d is not in the carved maze", from, to)
+		}
+		if max(from, to)/6 < row {
+			t.Errorf("(Stream) passage %d-%d comes after row %d", from, to, row)
+		}
+		row = max(from, to) / 6
+		count++
+	}
+	if count != 23 {
+		t.Errorf("(Stream) want 23 passages, got %d", count)
+	}
+
+	// Stopping early stops carving.
+	count = 0
+	for range passages {
+		if count++; count == 3 {
+			break
+		}
+	}
+
+	if _, err := Kruskal.Stream(6, 4, rand.New(rand.NewPCG(1, 2))); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("(Stream) want %v, got %v", ErrUnsupportedAlgorithm, err)
+	}
+
+	for _, algorithm := range []Algorithm{Kruskal, Prim, Backtracker, Wilson, AldousBroder, Eller, BinaryTree, Sidewinder} {
+		_, err := algorithm.Stream(6, 4, rand.New(rand.NewPCG(1, 2)))
+		if got := algorithm.CarvesRows(); got != (err == nil) {
+			t.Errorf("(CarvesRows) want %v for %v, which streams with error %v", err == nil, algorithm, err)
+		}
+	}
+}
+
+func TestCompactSolve(t *testing.T) {
+	c, err := NewCompact(3, 2)
+	if err != nil {
+		t.Fatalf("(NewCompact) unexpected error: %v", err)
+	}
+	for _, e := range [][2]int{{0, 1}, {1, 2}, {2, 5}, {5, 4}} {
+		c.Graph.Connect(e[0], e[1], 1)
+	}
+
+	if got, err := c.Solve(0, 4); err != nil || !reflect.DeepEqual(got, []int{0, 1, 2, 5, 4}) {
+		t.Errorf("(Solve) want [0 1 2 5 4], got %v (%v)", got, err)
+	}
+	if got, err := c.Solve(4, 4); err != nil || !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("(Solve) want [4], got %v (%v)", got, err)
+	}
+	if _, err := c.Solve(0, 3); !errors.Is(err, ErrNoSolution) {
+		t.Errorf("(Solve) want %v, got %v", ErrNoSolution, err)
+	}
+}
+
+func TestEncodeCompactPNG(t *testing.T) {
+	thick := DefaultRenderOptions()
+	thick.CellSize = 10
+	thick.WallThickness = 3
+	thick.Margin = 7
+	thick.RoundCorners = true
+	thick.WallColor = color.RGBA{B: 128, A: 255}
+	thick.PassageColor = color.RGBA{R: 250, G: 245, B: 225, A: 255}
+	thick.Background = color.Transparent
+
+	testCases := []struct {
+		name   string
+		width  int
+		height int
+		render RenderOptions
+		opts   []GeneratorOptions
+	}{
+		{name: "default", width: 12, height: 9, render: DefaultRenderOptions()},
+		// Over a million pixels, drawn in bands that end inside rows of cells.
+		{name: "several bands", width: 110, height: 105, render: thick},
+		{name: "openings", width: 10, height: 6, render: thick,
+			opts: []GeneratorOptions{WithOpenings(image.Pt(4, 0), image.Pt(9, 3))}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]GeneratorOptions{WithSeed(3), WithAlgorithm(Eller), WithRenderOptions(tc.render)}, tc.opts...)
+			rect, compact := generatePair(t, tc.width, tc.height, opts...)
+
+			want, err := tc.render.DrawRectangle(rect.Maze(), rect.Solution())
+			if err != nil {
+				t.Fatalf("(DrawRectangle) unexpected error: %v", err)
+			}
+			var buf bytes.Buffer
+			if err := tc.render.EncodeCompactPNG(&buf, compact.Compact(), compact.Solution(), nil); err != nil {
+				t.Fatalf("(EncodeCompactPNG) unexpected error: %v", err)
+			}
+			got, err := png.Decode(&buf)
+			if err != nil {
+				t.Fatalf("(EncodeCompactPNG) invalid PNG: %v", err)
+			}
+
+			if got.Bounds() != want.Bounds() {
+				t.Fatalf("(EncodeCompactPNG) want bounds %v, got %v", want.Bounds(), got.Bounds())
+			}
+			for y := range want.Bounds().Dy() {
+				for x := range want.Bounds().Dx() {
+					if color.RGBAModel.Convert(got.At(x, y)) != color.RGBAModel.Convert(want.At(x, y)) {
+						t.Fatalf("(EncodeCompactPNG) want %v at (%d, %d), got %v", want.At(x, y), x, y, got.At(x, y))
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeCompactSVG(t *testing.T) {
+	rect, compact := generatePair(t, 15, 8, WithSeed(4), WithAlgorithm(BinaryTree))
+	metadata := rect.Metadata()
+
+	var want, got bytes.Buffer
+	if err := EncodeSVG(&want, rect.Maze(), rect.Solution(), metadata); err != nil {
+		t.Fatalf("(EncodeSVG) unexpected error: %v", err)
+	}
+	if err := DefaultRenderOptions().EncodeCompactSVG(&got, compact.Compact(), compact.Solution(), metadata); err != nil {
+		t.Fatalf("(EncodeCompactSVG) unexpected error: %v", err)
+	}
+	if got.String() != want.String() {
+		t.Errorf("(EncodeCompactSVG) want the SVG of EncodeSVG:\n%s\ngot:\n%s", want.String(), got.String())
+	}
+}
+
+func TestGenerateCompactFiles(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "maze.png")
+	mg := NewMazeGenerator(20, 10, WithCompact(), WithAlgorithm(Sidewinder), WithSeed(8),
+		WithFilename(filename), WithSolutionFilename(filepath.Join(dir, "solution.svg")))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+
+	// The metadata reproduces the maze without the compact representation.
+	metadata, err := ReadMetadata(filename)
+	if err != nil {
+		t.Fatalf("(ReadMetadata) unexpected error: %v", err)
+	}
+	again, err := NewMazeGeneratorFromMetadata(metadata)
+	if err != nil {
+		t.Fatalf("(NewMazeGeneratorFromMetadata) unexpected error: %v", err)
+	}
+	if err := again.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(again.Solution(), mg.Solution()) {
+		t.Errorf("(Generate) want the solution %v from the metadata, got %v", mg.Solution(), again.Solution())
+	}
+
+	text := NewMazeGenerator(4, 4, WithCompact(), WithAlgorithm(Eller), WithFilename(filepath.Join(dir, "maze.txt")))
+	if err := text.Generate(); !errors.Is(err, ErrUnsupportedCompact) {
+		t.Errorf("(Generate) want %v for a text file, got %v", ErrUnsupportedCompact, err)
+	}
+}
+
+func TestGenerateCompactUnsupported(t *testing.T) {
+	heat := DefaultRenderOptions()
+	heat.HeatMap = true
+
+	testCases := []struct {
+		name    string
+		opts    []GeneratorOptions
+		wantErr error
+	}{
+		{name: "algorithm", opts: []GeneratorOptions{WithAlgorithm(Kruskal)}, wantErr: ErrUnsupportedAlgorithm},
+		{name: "shape", opts: []GeneratorOptions{WithShape(Hex)}, wantErr: ErrUnsupportedCompact},
+		{name: "recursion", opts: []GeneratorOptions{WithRecursionLevel(1)}, wantErr: ErrUnsupportedCompact},
+		{name: "braid", opts: []GeneratorOptions{WithBraid(0.5)}, wantErr: ErrUnsupportedCompact},
+		{name: "farthest openings", opts: []GeneratorOptions{WithFarthestOpenings()}, wantErr: ErrUnsupportedCompact},
+		{name: "heat map", opts: []GeneratorOptions{WithRenderOptions(heat), WithFilename(filepath.Join(t.TempDir(), "maze.png"))},
+			wantErr: ErrUnsupportedCompact},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]GeneratorOptions{WithAlgorithm(Eller), WithCompact()}, tc.opts...)
+			if err := NewMazeGenerator(6, 6, opts...).Generate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+// The benchmarks compare the memory and time of generating and drawing mazes as
+// Rectangles and as Compacts; run them with -benchmem.
+
+var benchmarkSizes = []int{100, 300, 1000}
+
+func BenchmarkGenerate(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		for _, compact := range []bool{false, true} {
+			opts := []GeneratorOptions{WithAlgorithm(Sidewinder), WithSeed(1)}
+			name := "rectangle"
+			if compact {
+				opts, name = append(opts, WithCompact()), "compact"
+			}
+			b.Run(fmt.Sprintf("%s/%dx%d", name, size, size), func(b *testing.B) {
+				b.ReportAllocs()
+				for range b.N {
+					if err := NewMazeGenerator(size, size, opts...).Generate(); err != nil {
+						b.Fatalf("(Generate) unexpected error: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkEncodePNG(b *testing.B) {
+	o := DefaultRenderOptions()
+	o.CellSize = 4
+	o.WallThickness = 1
+	for _, size := range benchmarkSizes {
+		rect, compact := generatePair(b, size, size, WithAlgorithm(Sidewinder), WithSeed(1))
+
+		b.Run(fmt.Sprintf("rectangle/%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				m, err := o.DrawRectangle(rect.Maze(), rect.Solution())
+				if err != nil {
+					b.Fatalf("(DrawRectangle) unexpected error: %v", err)
+				}
+				if err := png.Encode(io.Discard, m); err != nil {
+					b.Fatalf("(Encode) unexpected error: %v", err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("compact/%dx%d", size, size), func(b *testing.B) {
+			b.ReportAllocs()
+			for range b.N {
+				if err := o.EncodeCompactPNG(io.Discard, compact.Compact(), compact.Solution(), nil); err != nil {
+					b.Fatalf("(EncodeCompactPNG) unexpected error: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -5,55 +5,456 @@ import (
 	"image"
 	"image/color"
 	"image/draw"
+	"math"
+	"slices"
 )
 
 const (
 	SquareSize = 20 // Size of each square in the rectangle drawing
 )
 
+var (
+	SolutionColor = color.RGBA{R: 220, G: 40, B: 40, A: 255} // Color of the solution path overlay
+)
+
+type wall int
+
+const (
+	wallNorth wall = iota
+	wallEast
+	wallSouth
+	wallWest
+)
+
+// openSides maps each opening in r to the outer wall it removes.
+func (r *Rectangle) openSides() map[int]wall {
+	sides := make(map[int]wall, len(r.Openings))
+	for _, cell := range r.Openings {
+		i, j := r.GraphToRect(cell)
+		switch {
+		case r.outside(i-1, j):
+			sides[cell] = wallNorth
+		case r.outside(i+1, j):
+			sides[cell] = wallSouth
+		case r.outside(i, j-1):
+			sides[cell] = wallWest
+		default:
+			sides[cell] = wallEast
+		}
+	}
+	return sides
+}
+
 func DrawRectangleMaze(r *Rectangle) (image.Image, error) {
+	return DefaultRenderOptions().DrawRectangle(r, nil)
+}
+
+// DrawRectangle draws r, with path as the solution if it is not empty.
+func (o RenderOptions) DrawRectangle(r *Rectangle, path []int) (image.Image, error) {
 	g := r.Graph
 	width := r.Width
 	height := r.Height
 	if g.GetSize() != width*height {
 		return nil, fmt.Errorf("graph size %d does not match specified dimensions %dx%d", g.GetSize(), width, height)
 	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	size, margin := o.CellSize, o.Margin
+	m := image.NewRGBA(image.Rect(0, 0, width*size+2*margin, height*size+2*margin))
+	draw.Draw(m, m.Bounds(), &image.Uniform{o.Background}, image.Point{0, 0}, draw.Src)
+
+	start := 0
+	if len(r.Openings) > 0 {
+		start = r.Openings[0]
+	}
+	for cell, c := range o.cellColors(adjacency(g), start) {
+		if !r.Included(cell) {
+			continue
+		}
+		i, j := r.GraphToRect(cell)
+		rect := image.Rect(j*size, i*size, (j+1)*size, (i+1)*size).Add(image.Pt(margin, margin))
+		draw.Draw(m, rect, &image.Uniform{c}, image.Point{}, draw.Src)
+	}
+
+	o.drawWalls(m, r, width, height, 0, height, r.openSides())
+	o.drawCrossings(m, r)
+
+	if len(path) > 0 {
+		o.drawRectangleSolution(m, r, path)
+	}
+
+	return m, nil
+}
 
-	m := image.NewRGBA(image.Rect(0, 0, width*SquareSize, height*SquareSize))
-	draw.Draw(m, m.Bounds(), &image.Uniform{color.White}, image.Point{0, 0}, draw.Src)
+// DrawBox draws the floors of b side by side, a cell apart and the first on the
+// left, with triangles marking the stairs up and down. path, if not empty, is
+// drawn as the solution on every floor it crosses.
+func (o RenderOptions) DrawBox(b *Box, path []int) (image.Image, error) {
+	if g := b.Graph; g.GetSize() != b.Width*b.Height*b.Floors {
+		return nil, fmt.Errorf("graph size %d does not match specified dimensions %dx%dx%d", g.GetSize(), b.Width, b.Height, b.Floors)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
 
-	for y := 0; y < height; y++ {
-		for x := 0; x < width; x++ {
-			current := y*width + x
-			rectX := x * SquareSize
-			rectY := y * SquareSize
+	size, margin := o.CellSize, o.Margin
+	floorWidth, floorHeight := b.Width*size+2*margin, b.Height*size+2*margin
+	m := image.NewRGBA(image.Rect(0, 0, b.Floors*(floorWidth+size)-size, floorHeight))
+	draw.Draw(m, m.Bounds(), &image.Uniform{o.Background}, image.Point{0, 0}, draw.Src)
+
+	start := 0
+	if len(b.Openings) > 0 {
+		start = b.Openings[0]
+	}
+	colors := o.cellColors(adjacency(b.Graph), start)
+	runs := b.floorRuns(path)
+	for floor := range b.Floors {
+		r, err := b.Floor(floor)
+		if err != nil {
+			return nil, err
+		}
 
-			if x == 0 {
-				draw.Draw(m, image.Rect(rectX, rectY, rectX+1, rectY+SquareSize), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+		// Each floor is drawn on its own, as DrawRectangle would, and copied into place.
+		fm := image.NewRGBA(image.Rect(0, 0, floorWidth, floorHeight))
+		draw.Draw(fm, fm.Bounds(), &image.Uniform{o.Background}, image.Point{0, 0}, draw.Src)
+		for cell, c := range colors[floor*b.area() : (floor+1)*b.area()] {
+			i, j := r.GraphToRect(cell)
+			rect := image.Rect(j*size, i*size, (j+1)*size, (i+1)*size).Add(image.Pt(margin, margin))
+			draw.Draw(fm, rect, &image.Uniform{c}, image.Point{}, draw.Src)
+		}
+		o.drawWalls(fm, r, b.Width, b.Height, 0, b.Height, r.openSides())
+		for _, run := range runs[floor] {
+			o.drawRectangleSolution(fm, r, run)
+		}
+		for _, marker := range b.stairMarkers(floor, float64(size)) {
+			for k := range marker {
+				marker[k].X += float64(margin)
+				marker[k].Y += float64(margin)
 			}
-			if y == 0 && x != 0 {
-				draw.Draw(m, image.Rect(rectX, rectY, rectX+SquareSize, rectY+1), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+			fillPolygon(fm, marker, o.WallColor)
+		}
+
+		left := floor * (floorWidth + size)
+		draw.Draw(m, image.Rect(left, 0, left+floorWidth, floorHeight), fm, image.Point{}, draw.Src)
+	}
+
+	return m, nil
+}
+
+// walls is a rectangular maze as drawWalls sees it: Rectangle, or Compact for
+// mazes drawn a band at a time.
+type walls interface {
+	horizontalWall(x, y int, openings map[int]wall) bool
+	verticalWall(x, y int, openings map[int]wall) bool
+	blockRow(y int) bool
+	blockColumn(x int) bool
+}
+
+// drawWalls draws the walls of a width x height maze along the top and left
+// edges of the cells in rows top to bottom, inclusive, where bottom may equal
+// height for the bottom edge of the maze.
+func (o RenderOptions) drawWalls(m *image.RGBA, w walls, width, height, top, bottom int, openings map[int]wall) {
+	size, t, margin := o.CellSize, o.WallThickness, o.Margin
+
+	// Walls are centered on the edges between cells, moved inwards to stay inside
+	// the maze at its outer edge. A one pixel wall lies along the last pixels of
+	// the cell above or to the left.
+	line := func(edge, cells int) int {
+		return min(max(margin+edge*size-(t+1)/2, margin), margin+cells*size-t)
+	}
+	for y := top; y <= bottom; y++ {
+		for x := 0; x <= width; x++ {
+			if x < width && w.horizontalWall(x, y, openings) {
+				top := line(y, height)
+				o.drawWall(m, image.Rect(margin+x*size, top, margin+(x+1)*size, top+t), o.wallColor(w.blockRow(y)))
 			}
-			if x == width-1 {
-				draw.Draw(m, image.Rect(rectX+SquareSize-1, rectY, rectX+SquareSize, rectY+SquareSize), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+			if y < height && w.verticalWall(x, y, openings) {
+				left := line(x, width)
+				o.drawWall(m, image.Rect(left, margin+y*size, left+t, margin+(y+1)*size), o.wallColor(w.blockColumn(x)))
 			}
-			if y == height-1 && x != width-1 {
-				draw.Draw(m, image.Rect(rectX, rectY+SquareSize-1, rectX+SquareSize, rectY+SquareSize), &image.Uniform{color.Black}, image.Point{}, draw.Src)
+		}
+	}
+}
+
+// drawWall fills rect with c, rounding its ends if asked to.
+func (o RenderOptions) drawWall(m *image.RGBA, rect image.Rectangle, c color.Color) {
+	if !o.RoundCorners || o.WallThickness < 3 {
+		draw.Draw(m, rect, &image.Uniform{c}, image.Point{}, draw.Src)
+		return
+	}
+
+	radius := float64(o.WallThickness) / 2
+	center := func(x, y int) Point { return Point{float64(x), float64(y)} }
+	if rect.Dx() > rect.Dy() {
+		y := (rect.Min.Y + rect.Max.Y) / 2
+		fillCapsule(m, center(rect.Min.X, y), center(rect.Max.X, y), radius, c)
+	} else {
+		x := (rect.Min.X + rect.Max.X) / 2
+		fillCapsule(m, center(x, rect.Min.Y), center(x, rect.Max.Y), radius, c)
+	}
+}
+
+// DrawSolution returns a copy of the maze image m with path drawn through the
+// centers of its cells. Ends of the path at an opening extend out through it.
+func DrawSolution(m image.Image, r *Rectangle, path []int) image.Image {
+	return DefaultRenderOptions().DrawSolution(m, r, path)
+}
+
+// DrawSolution is the function DrawSolution for a maze drawn with the given
+// options, such as those returned by ParseImage.
+func (o RenderOptions) DrawSolution(m image.Image, r *Rectangle, path []int) image.Image {
+	solved := image.NewRGBA(m.Bounds())
+	draw.Draw(solved, solved.Bounds(), m, m.Bounds().Min, draw.Src)
+	if len(path) > 0 {
+		o.drawRectangleSolution(solved, r, path)
+	}
+	return solved
+}
+
+func (o RenderOptions) drawRectangleSolution(m *image.RGBA, r *Rectangle, path []int) {
+	size := o.CellSize
+	thickness := o.solutionThickness()
+	center := func(cell int) image.Point {
+		i, j := r.GraphToRect(cell)
+		return image.Pt(j*size+size/2+o.Margin, i*size+size/2+o.Margin)
+	}
+	line := func(a, b image.Point) {
+		if o.RoundCorners {
+			fillCapsule(m, Point{float64(a.X), float64(a.Y)}, Point{float64(b.X), float64(b.Y)}, float64(thickness)/2, o.SolutionColor)
+			return
+		}
+		rect := image.Rect(a.X, a.Y, b.X, b.Y).Canon()
+		rect.Min = rect.Min.Sub(image.Pt(thickness/2, thickness/2))
+		rect.Max = rect.Max.Add(image.Pt(thickness-thickness/2, thickness-thickness/2))
+		draw.Draw(m, rect, &image.Uniform{o.SolutionColor}, image.Point{}, draw.Src)
+	}
+
+	for k := 1; k < len(path); k++ {
+		a, b := center(path[k-1]), center(path[k])
+		if r.tunnel(path[k-1], path[k]) {
+			// Only the ends of the tunnel show, beside the passage on top.
+			point := func(p image.Point) Point { return Point{float64(p.X), float64(p.Y)} }
+			pixel := func(p Point) image.Point { return image.Pt(int(math.Round(p.X)), int(math.Round(p.Y))) }
+			line(a, pixel(tunnelMouth(point(a), point(b), float64(size))))
+			line(b, pixel(tunnelMouth(point(b), point(a), float64(size))))
+			continue
+		}
+		line(a, b)
+	}
+
+	openings := r.openSides()
+	for _, end := range []int{path[0], path[len(path)-1]} {
+		open, ok := openings[end]
+		if !ok {
+			continue
+		}
+		c := center(end)
+		switch open {
+		case wallNorth:
+			line(c, image.Pt(c.X, c.Y-size/2))
+		case wallEast:
+			line(c, image.Pt(c.X+size/2, c.Y))
+		case wallSouth:
+			line(c, image.Pt(c.X, c.Y+size/2))
+		case wallWest:
+			line(c, image.Pt(c.X-size/2, c.Y))
+		}
+	}
+}
+
+// DrawGridMaze draws the walls of a hex, triangle or polar maze, one pixel per unit.
+func DrawGridMaze(g *Grid) (image.Image, error) {
+	return DefaultRenderOptions().DrawGrid(g, nil)
+}
+
+// DrawGrid is DrawRectangle for hex, triangle and polar mazes, scaled so a cell
+// is about CellSize pixels across.
+func (o RenderOptions) DrawGrid(g *Grid, path []int) (image.Image, error) {
+	if g.Graph.GetSize() != len(g.cells) {
+		return nil, fmt.Errorf("graph size %d does not match the %d cells of the %v grid", g.Graph.GetSize(), len(g.cells), g.Shape)
+	}
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	// Half a wall of padding keeps walls on the outer edge inside the image.
+	size := o.gridPixels(g.Size())
+	pad := 2 * o.gridOffset()
+	m := image.NewRGBA(image.Rect(0, 0, int(math.Ceil(size.X+pad)), int(math.Ceil(size.Y+pad))))
+	draw.Draw(m, m.Bounds(), &image.Uniform{o.Background}, image.Point{0, 0}, draw.Src)
+
+	start := 0
+	if len(g.Openings) > 0 {
+		start = g.Openings[0]
+	}
+	for cell, c := range o.cellColors(adjacency(g.Graph), start) {
+		outline := []Point{}
+		for _, e := range g.cells[cell].edges {
+			for _, p := range e.points[:len(e.points)-1] {
+				outline = append(outline, o.gridPoint(p))
 			}
-			if x < width-1 { // East neighbor
-				east := current + 1
-				if _, connected := g.GetEdge(current, east); !connected {
-					draw.Draw(m, image.Rect(rectX+SquareSize-1, rectY, rectX+SquareSize, rectY+SquareSize), &image.Uniform{color.Black}, image.Point{}, draw.Src)
-				}
+		}
+		fillPolygon(m, outline, c)
+	}
+
+	for _, wall := range g.walls() {
+		for k := 1; k < len(wall); k++ {
+			o.drawGridLine(m, o.gridPoint(wall[k-1]), o.gridPoint(wall[k]), o.WallThickness, o.WallColor)
+		}
+	}
+
+	if len(path) > 0 {
+		o.drawGridSolution(m, g, path)
+	}
+
+	return m, nil
+}
+
+// DrawGridSolution is DrawSolution for hex, triangle and polar mazes.
+func DrawGridSolution(m image.Image, g *Grid, path []int) image.Image {
+	solved := image.NewRGBA(m.Bounds())
+	draw.Draw(solved, solved.Bounds(), m, m.Bounds().Min, draw.Src)
+	DefaultRenderOptions().drawGridSolution(solved, g, path)
+	return solved
+}
+
+func (o RenderOptions) drawGridSolution(m *image.RGBA, g *Grid, path []int) {
+	points := g.solutionPoints(path)
+	for k := 1; k < len(points); k++ {
+		o.drawGridLine(m, o.gridPoint(points[k-1]), o.gridPoint(points[k]), o.solutionThickness(), o.SolutionColor)
+	}
+}
+
+// gridOffset is the distance in pixels from the edge of the image to the drawing.
+func (o RenderOptions) gridOffset() float64 {
+	return float64(o.Margin + (o.WallThickness+1)/2)
+}
+
+// gridPixels scales a size in drawing units to pixels.
+func (o RenderOptions) gridPixels(p Point) Point {
+	scale := float64(o.CellSize) / SquareSize
+	return Point{p.X * scale, p.Y * scale}
+}
+
+// gridPoint maps a point in drawing units to its pixel in the image.
+func (o RenderOptions) gridPoint(p Point) Point {
+	p = o.gridPixels(p)
+	offset := o.gridOffset()
+	return Point{p.X + offset, p.Y + offset}
+}
+
+func (o RenderOptions) drawGridLine(m *image.RGBA, from, to Point, thickness int, c color.Color) {
+	if o.RoundCorners && thickness > 2 {
+		fillCapsule(m, from, to, float64(thickness)/2, c)
+		return
+	}
+	drawLine(m, from, to, thickness, c)
+}
+
+// solutionPoints returns the centers of the cells on path, extended out through
+// the openings at either end.
+func (g *Grid) solutionPoints(path []int) []Point {
+	if len(path) == 0 {
+		return nil
+	}
+
+	points := []Point{}
+	if p, ok := g.openingPoint(path[0]); ok {
+		points = append(points, p)
+	}
+	for _, cell := range path {
+		points = append(points, g.Center(cell))
+	}
+	if p, ok := g.openingPoint(path[len(path)-1]); ok {
+		points = append(points, p)
+	}
+	return points
+}
+
+// drawLine draws a line between two pixels, stamping a square of the given
+// thickness at every step.
+func drawLine(m *image.RGBA, from, to Point, thickness int, c color.Color) {
+	x0, y0 := int(math.Round(from.X)), int(math.Round(from.Y))
+	x1, y1 := int(math.Round(to.X)), int(math.Round(to.Y))
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+
+	src := &image.Uniform{c}
+	for e := dx + dy; ; {
+		rect := image.Rect(x0-thickness/2, y0-thickness/2, x0+thickness-thickness/2, y0+thickness-thickness/2)
+		draw.Draw(m, rect, src, image.Point{}, draw.Src)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+// fillCapsule fills every pixel whose center is within radius of the segment
+// between two points, giving a line with round ends.
+func fillCapsule(m *image.RGBA, from, to Point, radius float64, c color.Color) {
+	bounds := image.Rect(
+		int(math.Floor(min(from.X, to.X)-radius)), int(math.Floor(min(from.Y, to.Y)-radius)),
+		int(math.Ceil(max(from.X, to.X)+radius))+1, int(math.Ceil(max(from.Y, to.Y)+radius))+1,
+	).Intersect(m.Bounds())
+
+	dx, dy := to.X-from.X, to.Y-from.Y
+	length := dx*dx + dy*dy
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			px, py := float64(x)+0.5, float64(y)+0.5
+			t := 0.0
+			if length > 0 {
+				t = min(max(((px-from.X)*dx+(py-from.Y)*dy)/length, 0), 1)
 			}
-			if y < height-1 { // South neighbor
-				south := current + width
-				if _, connected := g.GetEdge(current, south); !connected {
-					draw.Draw(m, image.Rect(rectX, rectY+SquareSize-1, rectX+SquareSize, rectY+SquareSize), &image.Uniform{color.Black}, image.Point{}, draw.Src)
-				}
+			if math.Hypot(px-from.X-t*dx, py-from.Y-t*dy) <= radius {
+				m.Set(x, y, c)
 			}
 		}
 	}
+}
 
-	return m, nil
+// fillPolygon fills the pixels whose centers lie inside the polygon.
+func fillPolygon(m *image.RGBA, points []Point, c color.Color) {
+	if len(points) < 3 {
+		return
+	}
+	top, bottom := points[0].Y, points[0].Y
+	for _, p := range points {
+		top, bottom = min(top, p.Y), max(bottom, p.Y)
+	}
+
+	src := &image.Uniform{c}
+	for y := max(int(math.Floor(top)), m.Bounds().Min.Y); y <= min(int(math.Ceil(bottom)), m.Bounds().Max.Y-1); y++ {
+		scan := float64(y) + 0.5
+		crossings := []float64{}
+		for k, a := range points {
+			b := points[(k+1)%len(points)]
+			if (a.Y <= scan) != (b.Y <= scan) {
+				crossings = append(crossings, a.X+(scan-a.Y)*(b.X-a.X)/(b.Y-a.Y))
+			}
+		}
+		slices.Sort(crossings)
+		for k := 0; k+1 < len(crossings); k += 2 {
+			left := int(math.Ceil(crossings[k] - 0.5))
+			right := int(math.Floor(crossings[k+1] - 0.5))
+			draw.Draw(m, image.Rect(left, y, right+1, y+1), src, image.Point{}, draw.Src)
+		}
+	}
 }
@@ -1,22 +1,136 @@
 package maze
 
 import (
+	"bytes"
 	"fmt"
 	"image"
 	"image/png"
+	"io"
 	"os"
 )
 
-func WriteImageToFile(m *image.Image, filename string) error {
+// WriteImageToFile encodes m as a PNG file, storing each metadata entry in a tEXt chunk.
+func WriteImageToFile(m *image.Image, filename string, metadata map[string]string) error {
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
-	if err := png.Encode(file, *m); err != nil {
+	return encodePNG(file, *m, metadata)
+}
+
+// encodePNG writes m as a PNG, storing each metadata entry in a tEXt chunk.
+func encodePNG(w io.Writer, m image.Image, metadata map[string]string) error {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, m); err != nil {
 		return fmt.Errorf("failed to encode image: %w", err)
 	}
 
+	if err := writePNGText(w, buf.Bytes(), metadata); err != nil {
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+
+	return nil
+}
+
+// WriteMazeToFile writes r in the given format, drawing path as the solution if it
+// is not empty. metadata is embedded where the format allows.
+func WriteMazeToFile(r *Rectangle, filename string, format Format, path []int, metadata map[string]string) error {
+	return DefaultRenderOptions().WriteMaze(r, filename, format, path, metadata)
+}
+
+// WriteMaze is WriteMazeToFile, drawing PNG and SVG files with the given options.
+func (o RenderOptions) WriteMaze(r *Rectangle, filename string, format Format, path []int, metadata map[string]string) error {
+	var buf bytes.Buffer
+	if err := o.EncodeMaze(&buf, r, format, path, metadata); err != nil {
+		return err
+	}
+	return writeFile(filename, buf.Bytes())
+}
+
+// EncodeMaze is WriteMaze writing to w instead of a file.
+func (o RenderOptions) EncodeMaze(w io.Writer, r *Rectangle, format Format, path []int, metadata map[string]string) error {
+	switch format {
+	case PNG:
+		img, err := o.DrawRectangle(r, path)
+		if err != nil {
+			return err
+		}
+		return encodePNG(w, img, metadata)
+	case SVG:
+		return o.EncodeSVG(w, r, path, metadata)
+	case Text:
+		return EncodeText(w, r, path)
+	case JSON:
+		return EncodeJSON(w, r, path, metadata)
+	}
+	return fmt.Errorf("%w: %v", ErrUnknownFormat, format)
+}
+
+// WriteGridToFile is WriteMazeToFile for hex, triangle and polar mazes, which
+// have no text rendering.
+func WriteGridToFile(g *Grid, filename string, format Format, path []int, metadata map[string]string) error {
+	return DefaultRenderOptions().WriteGrid(g, filename, format, path, metadata)
+}
+
+// WriteGrid is WriteGridToFile, drawing PNG and SVG files with the given options.
+func (o RenderOptions) WriteGrid(g *Grid, filename string, format Format, path []int, metadata map[string]string) error {
+	var buf bytes.Buffer
+	if err := o.EncodeGrid(&buf, g, format, path, metadata); err != nil {
+		return err
+	}
+	return writeFile(filename, buf.Bytes())
+}
+
+// EncodeGrid is WriteGrid writing to w instead of a file.
+func (o RenderOptions) EncodeGrid(w io.Writer, g *Grid, format Format, path []int, metadata map[string]string) error {
+	switch format {
+	case PNG:
+		img, err := o.DrawGrid(g, path)
+		if err != nil {
+			return err
+		}
+		return encodePNG(w, img, metadata)
+	case SVG:
+		return o.EncodeGridSVG(w, g, path, metadata)
+	case JSON:
+		return EncodeGridJSON(w, g, path, metadata)
+	case Text:
+		return fmt.Errorf("%w: %v mazes cannot be written as %v", ErrUnsupportedShape, g.Shape, format)
+	}
+	return fmt.Errorf("%w: %v", ErrUnknownFormat, format)
+}
+
+// WriteBox is WriteMaze for boxes, which can only be written as PNG or SVG.
+func (o RenderOptions) WriteBox(b *Box, filename string, format Format, path []int, metadata map[string]string) error {
+	var buf bytes.Buffer
+	if err := o.EncodeBox(&buf, b, format, path, metadata); err != nil {
+		return err
+	}
+	return writeFile(filename, buf.Bytes())
+}
+
+// EncodeBox is WriteBox writing to w instead of a file.
+func (o RenderOptions) EncodeBox(w io.Writer, b *Box, format Format, path []int, metadata map[string]string) error {
+	switch format {
+	case PNG:
+		img, err := o.DrawBox(b, path)
+		if err != nil {
+			return err
+		}
+		return encodePNG(w, img, metadata)
+	case SVG:
+		return o.EncodeBoxSVG(w, b, path, metadata)
+	case Text, JSON:
+		return fmt.Errorf("%w: mazes with floors cannot be written as %v", ErrUnsupportedShape, format)
+	}
+	return fmt.Errorf("%w: %v", ErrUnknownFormat, format)
+}
+
+func writeFile(filename string, data []byte) error {
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
 	return nil
 }
@@ -0,0 +1,109 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Format selects how a maze is written to a file.
+type Format int
+
+const (
+	PNG  Format = iota // raster image with the settings in tEXt chunks
+	SVG                // vector image for printing at any size
+	Text               // Unicode box-drawing characters for terminals
+	JSON               // dimensions and passage list for other programs
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown maze format")
+)
+
+var formatNames = []string{
+	PNG:  "png",
+	SVG:  "svg",
+	Text: "txt",
+	JSON: "json",
+}
+
+func (f Format) String() string {
+	if f < 0 || int(f) >= len(formatNames) {
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+	return formatNames[f]
+}
+
+// ParseFormat returns the Format with the given name, as listed by FormatNames.
+func ParseFormat(name string) (Format, error) {
+	i := slices.Index(formatNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownFormat, name)
+	}
+	return Format(i), nil
+}
+
+// FormatNames lists the names accepted by ParseFormat.
+func FormatNames() []string {
+	return slices.Clone(formatNames)
+}
+
+// FormatFromFilename picks the Format matching the file's extension, falling back
+// to PNG when the extension is missing or unknown.
+func FormatFromFilename(filename string) Format {
+	ext := strings.TrimPrefix(filepath.Ext(filename), ".")
+	if f, err := ParseFormat(ext); err == nil {
+		return f
+	}
+	return PNG
+}
+
+// horizontalWall reports whether there is a wall along the top edge of cell (x, y).
+// y may equal Height for the bottom edge of the maze. The edge of the maze is a
+// wall except at an opening; there is nothing to draw between two left out cells.
+func (r *Rectangle) horizontalWall(x, y int, openings map[int]wall) bool {
+	above, below := !r.outside(y-1, x), !r.outside(y, x)
+	switch {
+	case above && below:
+		a, b := r.RectToGraph(y-1, x), r.RectToGraph(y, x)
+		if _, connected := r.Graph.GetEdge(a, b); connected {
+			return false
+		}
+		// The mouth of a tunnel under a crossing is drawn with the crossing.
+		_, underA := r.tunnels(a)
+		_, underB := r.tunnels(b)
+		return !underA && !underB
+	case above:
+		open, ok := openings[r.RectToGraph(y-1, x)]
+		return !ok || open != wallSouth
+	case below:
+		open, ok := openings[r.RectToGraph(y, x)]
+		return !ok || open != wallNorth
+	}
+	return false
+}
+
+// verticalWall reports whether there is a wall along the left edge of cell (x, y).
+// x may equal Width for the right edge of the maze.
+func (r *Rectangle) verticalWall(x, y int, openings map[int]wall) bool {
+	left, right := !r.outside(y, x-1), !r.outside(y, x)
+	switch {
+	case left && right:
+		a, b := r.RectToGraph(y, x-1), r.RectToGraph(y, x)
+		if _, connected := r.Graph.GetEdge(a, b); connected {
+			return false
+		}
+		underA, _ := r.tunnels(a)
+		underB, _ := r.tunnels(b)
+		return !underA && !underB
+	case left:
+		open, ok := openings[r.RectToGraph(y, x-1)]
+		return !ok || open != wallEast
+	case right:
+		open, ok := openings[r.RectToGraph(y, x)]
+		return !ok || open != wallWest
+	}
+	return false
+}
@@ -0,0 +1,197 @@
+package maze_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+// newTestMaze returns a 3x2 maze with an entrance at the top-left and an exit at
+// the bottom-right, as drawn in TestEncodeText.
+func newTestMaze(t *testing.T) *Rectangle {
+	t.Helper()
+
+	r, err := NewRectangle(3, 2, NoConnect)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, passage := range [][2]int{{0, 1}, {0, 3}, {3, 4}, {4, 5}, {2, 5}} {
+		if err := r.Graph.Connect(passage[0], passage[1], DefaultWeight); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	r.Openings = []int{0, 5}
+	return r
+}
+
+func TestFormatFromFilename(t *testing.T) {
+	testCases := []struct {
+		filename string
+		want     Format
+	}{
+		{filename: "maze.png", want: PNG},
+		{filename: "maze.svg", want: SVG},
+		{filename: "dir/maze.TXT", want: Text},
+		{filename: "maze.json", want: JSON},
+		{filename: "maze", want: PNG},
+		{filename: "maze.gif", want: PNG},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.filename, func(t *testing.T) {
+			if got := FormatFromFilename(tc.filename); got != tc.want {
+				t.Errorf("(FormatFromFilename) want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeText(t *testing.T) {
+	r := newTestMaze(t)
+
+	testCases := []struct {
+		name string
+		path []int
+		want string
+	}{
+		{
+			name: "maze",
+			want: "" +
+				"╷   ╶───┬───┐\n" +
+				"│       │   │\n" +
+				"│   ╶───┘   │\n" +
+				"│           │\n" +
+				"└───────╴   ╵\n",
+		},
+		{
+			name: "solution",
+			path: []int{0, 3, 4, 5},
+			want: "" +
+				"╷   ╶───┬───┐\n" +
+				"│ •     │   │\n" +
+				"│   ╶───┘   │\n" +
+				"│ •   •   • │\n" +
+				"└───────╴   ╵\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeText(&buf, r, tc.path); err != nil {
+				t.Fatalf("(EncodeText) unexpected error: %v", err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("(EncodeText) want\n%s\ngot\n%s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	r := newTestMaze(t)
+
+	var buf bytes.Buffer
+	if err := EncodeJSON(&buf, r, []int{0, 3, 4, 5}, map[string]string{MetadataSeed: "7"}); err != nil {
+		t.Fatalf("(EncodeJSON) unexpected error: %v", err)
+	}
+
+	var got struct {
+		Width    int
+		Height   int
+		Openings []struct{ X, Y int }
+		Passages [][2]int
+		Solution []int
+		Metadata map[string]string
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("(EncodeJSON) invalid JSON: %v", err)
+	}
+
+	if got.Width != 3 || got.Height != 2 {
+		t.Errorf("(EncodeJSON) want 3x2, got %dx%d", got.Width, got.Height)
+	}
+	if len(got.Openings) != 2 || got.Openings[1].X != 2 || got.Openings[1].Y != 1 {
+		t.Errorf("(EncodeJSON) want openings at (0,0) and (2,1), got %v", got.Openings)
+	}
+	if len(got.Passages) != 5 {
+		t.Errorf("(EncodeJSON) want 5 passages, got %v", got.Passages)
+	}
+	for _, p := range got.Passages {
+		if _, ok := r.Graph.GetEdge(p[0], p[1]); !ok {
+			t.Errorf("(EncodeJSON) passage %v is not in the maze", p)
+		}
+	}
+	if len(got.Solution) != 4 || got.Metadata[MetadataSeed] != "7" {
+		t.Errorf("(EncodeJSON) want solution and metadata, got %v and %v", got.Solution, got.Metadata)
+	}
+}
+
+func TestEncodeSVG(t *testing.T) {
+	r := newTestMaze(t)
+
+	var buf bytes.Buffer
+	if err := EncodeSVG(&buf, r, []int{0, 3, 4, 5}, map[string]string{MetadataSeed: "7"}); err != nil {
+		t.Fatalf("(EncodeSVG) unexpected error: %v", err)
+	}
+
+	var doc struct {
+		XMLName xml.Name
+		Desc    string `xml:"desc"`
+		Paths   []struct {
+			D string `xml:"d,attr"`
+		} `xml:"path"`
+		Polyline []struct {
+			Points string `xml:"points,attr"`
+		} `xml:"polyline"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("(EncodeSVG) invalid XML: %v", err)
+	}
+
+	if doc.XMLName.Local != "svg" {
+		t.Errorf("(EncodeSVG) want an svg document, got %q", doc.XMLName.Local)
+	}
+	if !strings.Contains(doc.Desc, MetadataSeed+": 7") {
+		t.Errorf("(EncodeSVG) want metadata in the description, got %q", doc.Desc)
+	}
+	// Outer walls less the two openings: 3+3+2+2-2 segments, plus the 2 inner walls.
+	if len(doc.Paths) != 1 || strings.Count(doc.Paths[0].D, "M") != 10 {
+		t.Errorf("(EncodeSVG) want 10 wall segments, got %v", doc.Paths)
+	}
+	// Four cell centers plus a point outside each opening.
+	if len(doc.Polyline) != 1 || len(strings.Fields(doc.Polyline[0].Points)) != 6 {
+		t.Errorf("(EncodeSVG) want a 6 point solution, got %v", doc.Polyline)
+	}
+}
+
+func TestGenerateFormats(t *testing.T) {
+	for _, name := range FormatNames() {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			filename := filepath.Join(dir, "maze."+name)
+			solution := filepath.Join(dir, "solution."+name)
+
+			mg := NewMazeGenerator(6, 4, WithSeed(3), WithFilename(filename), WithSolutionFilename(solution))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			for _, f := range []string{filename, solution} {
+				data, err := os.ReadFile(f)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if len(data) == 0 {
+					t.Errorf("(Generate) %s is empty", f)
+				}
+			}
+		})
+	}
+}
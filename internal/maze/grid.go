@@ -0,0 +1,315 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+// Shape selects the tiling a maze is carved from.
+type Shape int
+
+const (
+	Square   Shape = iota // Rectangle; four neighbors per cell
+	Hex                   // hexagons in offset rows; six neighbors per cell
+	Triangle              // alternating upward and downward triangles ("delta"); three neighbors per cell
+	Polar                 // rings of cells around a center cell ("theta")
+)
+
+var (
+	ErrUnknownShape     = errors.New("unknown maze shape")
+	ErrUnsupportedShape = errors.New("option is not supported for this maze shape")
+)
+
+var shapeNames = []string{
+	Square:   "square",
+	Hex:      "hex",
+	Triangle: "triangle",
+	Polar:    "polar",
+}
+
+func (s Shape) String() string {
+	if s < 0 || int(s) >= len(shapeNames) {
+		return fmt.Sprintf("Shape(%d)", int(s))
+	}
+	return shapeNames[s]
+}
+
+// ParseShape returns the Shape with the given name, as listed by ShapeNames.
+func ParseShape(name string) (Shape, error) {
+	i := slices.Index(shapeNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownShape, name)
+	}
+	return Shape(i), nil
+}
+
+// ShapeNames lists the names accepted by ParseShape.
+func ShapeNames() []string {
+	return slices.Clone(shapeNames)
+}
+
+// Point is a position in drawing units; a square cell is SquareSize units wide.
+type Point struct {
+	X, Y float64
+}
+
+type gridEdge struct {
+	points   []Point // outline of the side, from one corner to the next
+	neighbor int     // cell across the side, or -1 on the outer border
+}
+
+type gridCell struct {
+	position image.Point // (column, row); for Polar, (index within the ring, ring)
+	center   Point
+	edges    []gridEdge
+}
+
+// Grid is a maze on a hexagonal, triangular or polar tiling. As with Rectangle,
+// Graph holds the candidate edges between neighboring cells until a maze is
+// carved into it.
+type Grid struct {
+	Shape    Shape
+	Width    int // columns; unused by Polar
+	Height   int // rows; rings for Polar
+	Graph    *sparsegraph.Graph
+	Openings []int // border cells whose outer wall is open
+
+	cells []gridCell
+	index map[image.Point]int
+	size  Point // extent of the drawing
+}
+
+// cellCount returns the number of cells in a maze of shape and size without
+// laying them out, or a number past limit as soon as there are more.
+func cellCount(shape Shape, width, height, limit int) int {
+	if shape != Polar {
+		if width > limit/height {
+			return limit + 1
+		}
+		return width * height
+	}
+	cells := 0
+	for count := range polarRingCounts(height) {
+		if cells += count; cells > limit {
+			break
+		}
+	}
+	return cells
+}
+
+// NewGrid builds an undirected graph over the cells of the given shape, connecting
+// every pair of neighbors according to init. It fails with
+// sparsegraph.ErrInvalidConfiguration if some cells share no side with the rest.
+func NewGrid(shape Shape, width, height int, init EdgeInit, opts ...RectangleOption) (*Grid, error) {
+	config := rectangleConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.rng == nil {
+		config.rng = newRand(rand.Uint64())
+	}
+	if height < 1 || (shape != Polar && width < 1) {
+		return nil, sparsegraph.ErrInvalidConfiguration
+	}
+
+	g := &Grid{
+		Shape:  shape,
+		Width:  width,
+		Height: height,
+	}
+	switch shape {
+	case Hex:
+		g.cells, g.size = hexCells(width, height)
+	case Triangle:
+		g.cells, g.size = triangleCells(width, height)
+	case Polar:
+		g.cells, g.size = polarCells(height)
+	default:
+		return nil, fmt.Errorf("%w: %v is not a grid shape", ErrUnknownShape, shape)
+	}
+
+	if !g.connected() {
+		return nil, fmt.Errorf("%w: the cells of a %dx%d %v grid are not all connected", sparsegraph.ErrInvalidConfiguration, width, height, shape)
+	}
+
+	var err error
+	g.Graph, err = sparsegraph.NewGraph(len(g.cells), false)
+	if err != nil {
+		return nil, err
+	}
+
+	g.index = make(map[image.Point]int, len(g.cells))
+	for i, cell := range g.cells {
+		g.index[cell.position] = i
+		if init == NoConnect {
+			continue
+		}
+		for _, e := range cell.edges {
+			if e.neighbor > i {
+				if err := g.Graph.Connect(i, e.neighbor, getNewWeight(init, config.rng)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// connected reports whether every cell can be reached from the first across
+// shared sides. A triangle grid one column wide falls apart into pairs.
+func (g *Grid) connected() bool {
+	adj := make([][]int, len(g.cells))
+	for i, cell := range g.cells {
+		for _, e := range cell.edges {
+			if e.neighbor >= 0 {
+				adj[i] = append(adj[i], e.neighbor)
+			}
+		}
+	}
+	return connected(adj)
+}
+
+// Size returns the width and height of the drawing in units.
+func (g *Grid) Size() Point {
+	return g.size
+}
+
+// CellAt returns the cell at a (column, row) position.
+func (g *Grid) CellAt(p image.Point) (int, bool) {
+	cell, ok := g.index[p]
+	return cell, ok
+}
+
+// CellPoint returns the (column, row) position of a cell.
+func (g *Grid) CellPoint(cell int) image.Point {
+	return g.cells[cell].position
+}
+
+// Center returns the middle of a cell in drawing units.
+func (g *Grid) Center(cell int) Point {
+	return g.cells[cell].center
+}
+
+// IsBorder reports whether the cell has a side on the outer edge of the grid.
+func (g *Grid) IsBorder(cell int) bool {
+	return g.borderEdge(cell) >= 0
+}
+
+// BorderCells lists the cells on the outer edge in ascending order.
+func (g *Grid) BorderCells() []int {
+	cells := []int{}
+	for i := range g.cells {
+		if g.IsBorder(i) {
+			cells = append(cells, i)
+		}
+	}
+	return cells
+}
+
+// borderEdge returns the index of the cell's first side on the outer edge, or -1.
+func (g *Grid) borderEdge(cell int) int {
+	for k, e := range g.cells[cell].edges {
+		if e.neighbor < 0 {
+			return k
+		}
+	}
+	return -1
+}
+
+// walls returns the outline of every side that is not a passage or an opening.
+// Sides shared by two cells are returned once.
+func (g *Grid) walls() [][]Point {
+	open := make(map[int]int, len(g.Openings))
+	for _, cell := range g.Openings {
+		open[cell] = g.borderEdge(cell)
+	}
+
+	walls := [][]Point{}
+	for i, cell := range g.cells {
+		for k, e := range cell.edges {
+			switch {
+			case e.neighbor < 0:
+				if side, ok := open[i]; ok && side == k {
+					continue
+				}
+			case e.neighbor < i:
+				continue
+			default:
+				if _, connected := g.Graph.GetEdge(i, e.neighbor); connected {
+					continue
+				}
+			}
+			walls = append(walls, e.points)
+		}
+	}
+	return walls
+}
+
+// openingPoint returns the middle of the opened side of cell, if it has one.
+func (g *Grid) openingPoint(cell int) (Point, bool) {
+	if !slices.Contains(g.Openings, cell) {
+		return Point{}, false
+	}
+	k := g.borderEdge(cell)
+	if k < 0 {
+		return Point{}, false
+	}
+	return midpoint(g.cells[cell].edges[k].points), true
+}
+
+// midpoint returns the middle of a polyline whose segments have equal lengths.
+func midpoint(points []Point) Point {
+	n := len(points)
+	if n%2 == 1 {
+		return points[n/2]
+	}
+	a, b := points[n/2-1], points[n/2]
+	return Point{(a.X + b.X) / 2, (a.Y + b.Y) / 2}
+}
+
+// maxStep returns the longest distance between the centers of neighboring cells.
+func (g *Grid) maxStep() float64 {
+	step := 0.0
+	for i, cell := range g.cells {
+		for _, e := range cell.edges {
+			if e.neighbor > i {
+				step = max(step, distance(cell.center, g.cells[e.neighbor].center))
+			}
+		}
+	}
+	return step
+}
+
+func distance(a, b Point) float64 {
+	return math.Hypot(a.X-b.X, a.Y-b.Y)
+}
+
+// polygonEdges builds the sides of a polygon from its corners, the k-th side
+// running from corner k to corner k+1 with the k-th neighbor across it.
+func polygonEdges(corners []Point, neighbors []int) []gridEdge {
+	edges := make([]gridEdge, len(corners))
+	for k := range corners {
+		edges[k] = gridEdge{
+			points:   []Point{corners[k], corners[(k+1)%len(corners)]},
+			neighbor: neighbors[k],
+		}
+	}
+	return edges
+}
+
+func centroid(points []Point) Point {
+	var c Point
+	for _, p := range points {
+		c.X += p.X
+		c.Y += p.Y
+	}
+	return Point{c.X / float64(len(points)), c.Y / float64(len(points))}
+}
@@ -0,0 +1,308 @@
+package maze_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"image"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	"tsumegolang/pkg/ds/disjointset"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestParseShape(t *testing.T) {
+	for _, name := range ShapeNames() {
+		shape, err := ParseShape(name)
+		if err != nil {
+			t.Fatalf("(ParseShape) %q: unexpected error: %v", name, err)
+		}
+		if shape.String() != name {
+			t.Errorf("(ParseShape) want %q, got %q", name, shape.String())
+		}
+	}
+	if _, err := ParseShape("octagon"); !errors.Is(err, ErrUnknownShape) {
+		t.Errorf("(ParseShape) want %v, got %v", ErrUnknownShape, err)
+	}
+}
+
+func TestNewGrid(t *testing.T) {
+	testCases := []struct {
+		name      string
+		shape     Shape
+		width     int
+		height    int
+		wantCells int
+		wantEdges int
+		wantErr   error
+	}{
+		{
+			name:      "hex",
+			shape:     Hex,
+			width:     5,
+			height:    4,
+			wantCells: 20,
+			wantEdges: 4*4 + 3*9, // along the rows, then two per cell between rows less the row end
+		},
+		{
+			name:      "triangle",
+			shape:     Triangle,
+			width:     6,
+			height:    3,
+			wantCells: 18,
+			wantEdges: 3*5 + 2*3, // along the rows, then below each upward triangle
+		},
+		{
+			name:      "polar",
+			shape:     Polar,
+			height:    4,
+			wantCells: 1 + 6 + 12 + 24,
+			wantEdges: 2 * (6 + 12 + 24), // around each ring, then inwards from each cell
+		},
+		{
+			name:      "narrow triangle",
+			shape:     Triangle,
+			width:     1,
+			height:    2,
+			wantCells: 2,
+			wantEdges: 1,
+		},
+		{
+			name:    "disconnected triangle",
+			shape:   Triangle,
+			width:   1,
+			height:  3,
+			wantErr: sparsegraph.ErrInvalidConfiguration,
+		},
+		{
+			name:    "square",
+			shape:   Square,
+			width:   3,
+			height:  3,
+			wantErr: ErrUnknownShape,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGrid(tc.shape, tc.width, tc.height, ConnectConst)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(NewGrid) want %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if g.Graph.GetSize() != tc.wantCells {
+				t.Errorf("(NewGrid) want %d cells, got %d", tc.wantCells, g.Graph.GetSize())
+			}
+			if got := len(g.Graph.GetAllEdges()); got != tc.wantEdges {
+				t.Errorf("(NewGrid) want %d edges, got %d", tc.wantEdges, got)
+			}
+			for cell := range g.Graph.GetSize() {
+				if got, ok := g.CellAt(g.CellPoint(cell)); !ok || got != cell {
+					t.Errorf("(CellAt) cell %d at %v: got %d", cell, g.CellPoint(cell), got)
+				}
+			}
+		})
+	}
+}
+
+func TestCarveGrid(t *testing.T) {
+	for _, shape := range []Shape{Hex, Triangle, Polar} {
+		for _, algorithm := range []Algorithm{Kruskal, Backtracker, Prim, Wilson, AldousBroder} {
+			t.Run(shape.String()+" "+algorithm.String(), func(t *testing.T) {
+				rng := rand.New(rand.NewPCG(2, 9))
+				g, err := NewGrid(shape, 7, 5, ConnectRandom, WithRand(rng))
+				if err != nil {
+					t.Fatalf("(NewGrid) unexpected error: %v", err)
+				}
+				paths, err := algorithm.CarveGraph(g.Graph, rng)
+				if err != nil {
+					t.Fatalf("(CarveGraph) unexpected error: %v", err)
+				}
+
+				n := g.Graph.GetSize()
+				edges := paths.GetAllEdges()
+				if len(edges) != n-1 {
+					t.Fatalf("want %d edges in a spanning tree of %d cells, got %d", n-1, n, len(edges))
+				}
+				ds, _ := disjointset.NewDisjointSet(disjointset.WithCapacity(n))
+				ds.AddMany(n)
+				for _, e := range edges {
+					from, to := e.From.(int), e.To.(int)
+					if _, ok := g.Graph.GetEdge(from, to); !ok {
+						t.Fatalf("edge %d-%d does not join neighboring cells", from, to)
+					}
+					a, _ := ds.Find(from)
+					b, _ := ds.Find(to)
+					if a == b {
+						t.Fatalf("edge %d-%d closes a loop", from, to)
+					}
+					ds.Union(a, b)
+				}
+			})
+		}
+	}
+
+	g, _ := NewGrid(Hex, 3, 3, ConnectConst)
+	if _, err := Eller.CarveGraph(g.Graph, rand.New(rand.NewPCG(1, 1))); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("(CarveGraph) want %v, got %v", ErrUnsupportedAlgorithm, err)
+	}
+}
+
+func TestGenerateShapes(t *testing.T) {
+	testCases := []struct {
+		name         string
+		shape        Shape
+		opts         []GeneratorOptions
+		wantEntrance image.Point
+		wantExit     image.Point
+		wantErr      error
+	}{
+		{
+			name:         "hex",
+			shape:        Hex,
+			wantEntrance: image.Pt(0, 0),
+			wantExit:     image.Pt(5, 4),
+		},
+		{
+			name:         "triangle with chosen openings",
+			shape:        Triangle,
+			opts:         []GeneratorOptions{WithOpenings(image.Pt(1, 0), image.Pt(0, 3))},
+			wantEntrance: image.Pt(1, 0),
+			wantExit:     image.Pt(0, 3),
+		},
+		{
+			name:         "polar to the center",
+			shape:        Polar,
+			opts:         []GeneratorOptions{WithAlgorithm(Backtracker)},
+			wantEntrance: image.Pt(0, 4),
+			wantExit:     image.Pt(0, 0),
+		},
+		{
+			name:    "interior opening",
+			shape:   Hex,
+			opts:    []GeneratorOptions{WithOpenings(image.Pt(2, 2), image.Pt(5, 4))},
+			wantErr: ErrNotOnBorder,
+		},
+		{
+			name:    "recursive",
+			shape:   Triangle,
+			opts:    []GeneratorOptions{WithRecursionLevel(1)},
+			wantErr: ErrUnsupportedShape,
+		},
+		{
+			name:    "row by row algorithm",
+			shape:   Polar,
+			opts:    []GeneratorOptions{WithAlgorithm(Sidewinder)},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			opts := append([]GeneratorOptions{
+				WithSeed(8),
+				WithShape(tc.shape),
+				WithFilename(filepath.Join(dir, "maze.png")),
+				WithSolutionFilename(filepath.Join(dir, "solved.svg")),
+			}, tc.opts...)
+			mg := NewMazeGenerator(6, 5, opts...)
+
+			err := mg.Generate()
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if mg.Entrance() != tc.wantEntrance || mg.Exit() != tc.wantExit {
+				t.Errorf("(Generate) want openings %v and %v, got %v and %v", tc.wantEntrance, tc.wantExit, mg.Entrance(), mg.Exit())
+			}
+			g := mg.Grid()
+			solution := mg.Solution()
+			entrance, _ := g.CellAt(tc.wantEntrance)
+			exit, _ := g.CellAt(tc.wantExit)
+			if solution[0] != entrance || solution[len(solution)-1] != exit {
+				t.Errorf("(Generate) solution does not join the openings: %v", solution)
+			}
+			for k := 1; k < len(solution); k++ {
+				if _, ok := g.Graph.GetEdge(solution[k-1], solution[k]); !ok {
+					t.Errorf("(Generate) solution steps through a wall between %d and %d", solution[k-1], solution[k])
+				}
+			}
+
+			// Every solver agrees on the only path through a perfect maze.
+			path, err := AStar.SolveGrid(g, entrance, exit)
+			if err != nil || len(path) != len(solution) {
+				t.Errorf("(SolveGrid) want a %d cell path, got %d cells and error %v", len(solution), len(path), err)
+			}
+		})
+	}
+}
+
+func TestEncodeGrid(t *testing.T) {
+	for _, shape := range []Shape{Hex, Triangle, Polar} {
+		t.Run(shape.String(), func(t *testing.T) {
+			rng := rand.New(rand.NewPCG(4, 4))
+			g, _ := NewGrid(shape, 4, 3, ConnectRandom, WithRand(rng))
+			g.Graph, _ = Kruskal.CarveGraph(g.Graph, rng)
+			g.Openings = []int{0}
+			path, _ := BFS.SolveGrid(g, 0, g.Graph.GetSize()-1)
+
+			m, err := DrawGridMaze(g)
+			if err != nil {
+				t.Fatalf("(DrawGridMaze) unexpected error: %v", err)
+			}
+			size := g.Size()
+			if m.Bounds().Dx() < int(size.X) || m.Bounds().Dy() < int(size.Y) {
+				t.Errorf("(DrawGridMaze) image %v is smaller than the drawing %v", m.Bounds(), size)
+			}
+			center := g.Center(path[len(path)/2])
+			solved := DrawGridSolution(m, g, path)
+			if got := solved.At(int(center.X)+1, int(center.Y)+1); got != SolutionColor {
+				t.Errorf("(DrawGridSolution) want %v at the center of a cell on the path, got %v", SolutionColor, got)
+			}
+
+			var buf bytes.Buffer
+			if err := EncodeGridSVG(&buf, g, path, map[string]string{MetadataShape: shape.String()}); err != nil {
+				t.Fatalf("(EncodeGridSVG) unexpected error: %v", err)
+			}
+			var svg struct {
+				Desc string `xml:"desc"`
+			}
+			if err := xml.Unmarshal(buf.Bytes(), &svg); err != nil {
+				t.Fatalf("(EncodeGridSVG) invalid XML: %v", err)
+			}
+
+			buf.Reset()
+			if err := EncodeGridJSON(&buf, g, path, nil); err != nil {
+				t.Fatalf("(EncodeGridJSON) unexpected error: %v", err)
+			}
+			var doc struct {
+				Shape    string   `json:"shape"`
+				Passages [][2]int `json:"passages"`
+			}
+			if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatalf("(EncodeGridJSON) invalid JSON: %v", err)
+			}
+			if doc.Shape != shape.String() || len(doc.Passages) != g.Graph.GetSize()-1 {
+				t.Errorf("(EncodeGridJSON) want a %v maze with %d passages, got %q with %d", shape, g.Graph.GetSize()-1, doc.Shape, len(doc.Passages))
+			}
+		})
+	}
+
+	g, _ := NewGrid(Hex, 2, 2, ConnectConst)
+	err := WriteGridToFile(g, filepath.Join(t.TempDir(), "maze.txt"), Text, nil, nil)
+	if !errors.Is(err, ErrUnsupportedShape) {
+		t.Errorf("(WriteGridToFile) want %v, got %v", ErrUnsupportedShape, err)
+	}
+}
@@ -0,0 +1,56 @@
+package maze
+
+import (
+	"image"
+	"math"
+)
+
+// hexCells lays out pointy-top hexagons in rows, shifting odd rows half a cell to
+// the right. Each hexagon is SquareSize wide. Sides run clockwise from the
+// north-east: NE, E, SE, SW, W, NW.
+func hexCells(width, height int) ([]gridCell, Point) {
+	w := float64(SquareSize)
+	radius := w / math.Sqrt(3)
+
+	index := func(col, row int) int {
+		if col < 0 || row < 0 || col >= width || row >= height {
+			return -1
+		}
+		return row*width + col
+	}
+
+	cells := make([]gridCell, width*height)
+	for row := range height {
+		shift := row % 2 // odd rows sit half a cell to the right
+		for col := range width {
+			center := Point{
+				X: w * (float64(col) + 0.5 + 0.5*float64(shift)),
+				Y: radius * (1 + 1.5*float64(row)),
+			}
+			corners := make([]Point, 6)
+			for k := range corners {
+				angle := math.Pi / 180 * float64(60*k-90)
+				corners[k] = Point{center.X + radius*math.Cos(angle), center.Y + radius*math.Sin(angle)}
+			}
+			neighbors := []int{
+				index(col+shift, row-1),   // NE
+				index(col+1, row),         // E
+				index(col+shift, row+1),   // SE
+				index(col+shift-1, row+1), // SW
+				index(col-1, row),         // W
+				index(col+shift-1, row-1), // NW
+			}
+			cells[index(col, row)] = gridCell{
+				position: image.Pt(col, row),
+				center:   center,
+				edges:    polygonEdges(corners, neighbors),
+			}
+		}
+	}
+
+	size := Point{X: w * float64(width), Y: radius * (2 + 1.5*float64(height-1))}
+	if height > 1 {
+		size.X += w / 2
+	}
+	return cells, size
+}
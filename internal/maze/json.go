@@ -0,0 +1,94 @@
+package maze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type jsonPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+// jsonMaze is the document written by EncodeJSON. Cells are numbered row by row,
+// so cell (x, y) is y*width + x, and cells left out by a mask are listed as
+// excluded. In woven mazes a passage between cells two apart tunnels under the
+// cell between them. Other shapes list the position of every cell.
+type jsonMaze struct {
+	Shape    string            `json:"shape,omitempty"`
+	Width    int               `json:"width"`
+	Height   int               `json:"height"`
+	Cells    []jsonPoint       `json:"cells,omitempty"`
+	Excluded []jsonPoint       `json:"excluded,omitempty"`
+	Openings []jsonPoint       `json:"openings"`
+	Passages [][2]int          `json:"passages"`
+	Solution []int             `json:"solution,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// EncodeJSON writes the maze's dimensions, openings and passages, plus path and
+// metadata when they are not empty.
+func EncodeJSON(w io.Writer, r *Rectangle, path []int, metadata map[string]string) error {
+	doc := jsonMaze{
+		Width:    r.Width,
+		Height:   r.Height,
+		Openings: make([]jsonPoint, len(r.Openings)),
+		Passages: [][2]int{},
+		Solution: path,
+		Metadata: metadata,
+	}
+	for k, cell := range r.Openings {
+		i, j := r.GraphToRect(cell)
+		doc.Openings[k] = jsonPoint{X: j, Y: i}
+	}
+	for cell := range r.Width * r.Height {
+		if !r.Included(cell) {
+			i, j := r.GraphToRect(cell)
+			doc.Excluded = append(doc.Excluded, jsonPoint{X: j, Y: i})
+		}
+	}
+	for _, e := range r.Graph.GetAllEdges() {
+		doc.Passages = append(doc.Passages, [2]int{e.From.(int), e.To.(int)})
+	}
+
+	return writeJSON(w, doc)
+}
+
+// EncodeGridJSON is EncodeJSON for hex, triangle and polar mazes. Openings and
+// cells are (column, row) positions, or (index within the ring, ring) for polar mazes.
+func EncodeGridJSON(w io.Writer, g *Grid, path []int, metadata map[string]string) error {
+	doc := jsonMaze{
+		Shape:    g.Shape.String(),
+		Width:    g.Width,
+		Height:   g.Height,
+		Cells:    make([]jsonPoint, len(g.cells)),
+		Openings: make([]jsonPoint, len(g.Openings)),
+		Passages: [][2]int{},
+		Solution: path,
+		Metadata: metadata,
+	}
+	for cell := range g.cells {
+		p := g.CellPoint(cell)
+		doc.Cells[cell] = jsonPoint{X: p.X, Y: p.Y}
+	}
+	for k, cell := range g.Openings {
+		p := g.CellPoint(cell)
+		doc.Openings[k] = jsonPoint{X: p.X, Y: p.Y}
+	}
+	for _, e := range g.Graph.GetAllEdges() {
+		doc.Passages = append(doc.Passages, [2]int{e.From.(int), e.To.(int)})
+	}
+
+	return writeJSON(w, doc)
+}
+
+func writeJSON(w io.Writer, doc jsonMaze) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode maze: %w", err)
+	}
+
+	return nil
+}
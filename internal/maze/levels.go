@@ -0,0 +1,222 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidLevel = errors.New("invalid maze level")
+)
+
+// Level describes one level of a hierarchical maze: a grid of sub-mazes built
+// from the levels below it or, at the innermost level, a grid of cells.
+type Level struct {
+	Width       int       // sub-mazes across, or cells at the innermost level
+	Height      int       // sub-mazes down, or cells at the innermost level
+	Algorithm   Algorithm // carves the tree joining the sub-mazes, or the cells at the innermost level
+	Connections int       // passages between each pair of sub-mazes joined by the tree; unused at the innermost level
+}
+
+// String writes the level as "WxH:algorithm:connections", as read by ParseLevel.
+func (l Level) String() string {
+	return fmt.Sprintf("%dx%d:%v:%d", l.Width, l.Height, l.Algorithm, l.Connections)
+}
+
+// ParseLevel reads a level written as "WxH", "WxH:algorithm" or
+// "WxH:algorithm:connections". The algorithm defaults to Kruskal and the
+// connections to 1.
+func ParseLevel(s string) (Level, error) {
+	level := Level{Algorithm: Kruskal, Connections: 1}
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return level, fmt.Errorf("%w: %q: want WxH:algorithm:connections", ErrInvalidLevel, s)
+	}
+
+	width, height, ok := strings.Cut(parts[0], "x")
+	var err error
+	if ok {
+		if level.Width, err = strconv.Atoi(width); err == nil {
+			level.Height, err = strconv.Atoi(height)
+		}
+	}
+	if !ok || err != nil {
+		return level, fmt.Errorf("%w: %q: want a size such as 4x4", ErrInvalidLevel, s)
+	}
+
+	if len(parts) > 1 {
+		if level.Algorithm, err = ParseAlgorithm(parts[1]); err != nil {
+			return level, fmt.Errorf("%w: %q: %w", ErrInvalidLevel, s, err)
+		}
+	}
+	if len(parts) > 2 {
+		if level.Connections, err = strconv.Atoi(parts[2]); err != nil {
+			return level, fmt.Errorf("%w: %q: want a number of connections", ErrInvalidLevel, s)
+		}
+	}
+
+	return level, nil
+}
+
+// ParseLevels reads comma-separated levels, outermost first, as written by
+// FormatLevels.
+func ParseLevels(s string) ([]Level, error) {
+	levels := []Level{}
+	for _, part := range strings.Split(s, ",") {
+		level, err := ParseLevel(strings.TrimSpace(part))
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, level)
+	}
+	return levels, nil
+}
+
+// FormatLevels writes levels separated by commas.
+func FormatLevels(levels []Level) string {
+	parts := make([]string, len(levels))
+	for k, level := range levels {
+		parts[k] = level.String()
+	}
+	return strings.Join(parts, ",")
+}
+
+// validateLevels checks that every level has cells and that the sub-mazes of
+// each level have room for its connections on the sides they share.
+func validateLevels(levels []Level) error {
+	if len(levels) == 0 {
+		return fmt.Errorf("%w: no levels", ErrInvalidLevel)
+	}
+	for k, level := range levels {
+		if level.Width < 1 || level.Height < 1 {
+			return fmt.Errorf("%w: level %d is %dx%d", ErrInvalidLevel, k, level.Width, level.Height)
+		}
+	}
+
+	for k, level := range levels[:len(levels)-1] {
+		block := levelsSize(levels[k+1:])
+		side := 0
+		if level.Width > 1 {
+			side = block.Y
+		}
+		if level.Height > 1 && (side == 0 || block.X < side) {
+			side = block.X
+		}
+		if level.Connections < 1 || (side > 0 && level.Connections > side) {
+			return fmt.Errorf("%w: level %d has %d connections between %dx%d sub-mazes", ErrInvalidLevel, k, level.Connections, block.X, block.Y)
+		}
+	}
+	return nil
+}
+
+// levelsSize returns the width and height in cells of a maze with the given levels.
+func levelsSize(levels []Level) image.Point {
+	size := image.Pt(1, 1)
+	for _, level := range levels {
+		size.X *= level.Width
+		size.Y *= level.Height
+	}
+	return size
+}
+
+// carveLevels builds a hierarchical maze. The outermost level's algorithm carves
+// a tree over its grid of sub-mazes; each sub-maze is carved from the levels
+// below it, and each pair joined by the tree is connected by passages through
+// the wall between them. Every passage is reported to record, if set, and
+// carving stops with the error record returns.
+func carveLevels(levels []Level, rng *rand.Rand, record func(from, to int) error) (*Rectangle, error) {
+	level := levels[0]
+	if len(levels) == 1 {
+		r, err := NewRectangle(level.Width, level.Height, ConnectRandom, WithRand(rng))
+		if err != nil {
+			return nil, err
+		}
+		paths, err := level.Algorithm.carve(r, rng, record)
+		if err != nil {
+			return nil, err
+		}
+		r.Graph = paths
+		return r, nil
+	}
+
+	blocks, err := NewRectangle(level.Width, level.Height, ConnectRandom, WithRand(rng))
+	if err != nil {
+		return nil, err
+	}
+	tree, err := level.Algorithm.Carve(blocks, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	block := levelsSize(levels[1:])
+	r, err := NewRectangle(level.Width*block.X, level.Height*block.Y, NoConnect)
+	if err != nil {
+		return nil, err
+	}
+	innermost := levels[len(levels)-1]
+	r.Block = image.Pt(innermost.Width, innermost.Height)
+
+	connect := func(from, to int) error {
+		if err := r.Graph.Connect(from, to, DefaultWeight); err != nil {
+			return err
+		}
+		if record != nil {
+			return record(from, to)
+		}
+		return nil
+	}
+
+	for b := range level.Width * level.Height {
+		bi, bj := blocks.GraphToRect(b)
+		cell := func(local int) int {
+			return r.RectToGraph(bi*block.Y+local/block.X, bj*block.X+local%block.X)
+		}
+
+		// Passages of the sub-maze are recorded as it is carved, so they are
+		// only copied here.
+		var recordSub func(from, to int) error
+		if record != nil {
+			recordSub = func(from, to int) error { return record(cell(from), cell(to)) }
+		}
+		sub, err := carveLevels(levels[1:], rng, recordSub)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range sub.Graph.GetAllEdges() {
+			if err := r.Graph.Connect(cell(e.From.(int)), cell(e.To.(int)), e.Weight); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, e := range tree.GetAllEdges() {
+		from, to := e.From.(int), e.To.(int)
+		if from > to {
+			continue
+		}
+		fi, fj := blocks.GraphToRect(from)
+		ti, tj := blocks.GraphToRect(to)
+		if fi == ti {
+			// Side by side: open rows of the wall between them.
+			for _, k := range rng.Perm(block.Y)[:level.Connections] {
+				row := fi*block.Y + k
+				if err := connect(r.RectToGraph(row, tj*block.X-1), r.RectToGraph(row, tj*block.X)); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		for _, k := range rng.Perm(block.X)[:level.Connections] {
+			col := fj*block.X + k
+			if err := connect(r.RectToGraph(ti*block.Y-1, col), r.RectToGraph(ti*block.Y, col)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return r, nil
+}
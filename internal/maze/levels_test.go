@@ -0,0 +1,237 @@
+package maze_test
+
+import (
+	"errors"
+	"image/color"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestParseLevels(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    []Level
+		wantErr error
+	}{
+		{
+			input: "3x2:backtracker:2,4x4",
+			want: []Level{
+				{Width: 3, Height: 2, Algorithm: Backtracker, Connections: 2},
+				{Width: 4, Height: 4, Algorithm: Kruskal, Connections: 1},
+			},
+		},
+		{
+			input: "5x1:eller",
+			want:  []Level{{Width: 5, Height: 1, Algorithm: Eller, Connections: 1}},
+		},
+		{input: "4", wantErr: ErrInvalidLevel},
+		{input: "4xa", wantErr: ErrInvalidLevel},
+		{input: "4x4:maze-o-matic", wantErr: ErrInvalidLevel},
+		{input: "4x4:kruskal:many", wantErr: ErrInvalidLevel},
+		{input: "4x4:kruskal:1:2", wantErr: ErrInvalidLevel},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseLevels(tc.input)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(ParseLevels) want error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("(ParseLevels) want %v, got %v", tc.want, got)
+			}
+
+			again, err := ParseLevels(FormatLevels(got))
+			if err != nil || !reflect.DeepEqual(again, got) {
+				t.Errorf("(FormatLevels) want %v back, got %v (%v)", got, again, err)
+			}
+		})
+	}
+}
+
+func TestGenerateLevels(t *testing.T) {
+	testCases := []struct {
+		name   string
+		levels []Level
+		// Passages crossing the walls between the innermost sub-mazes.
+		wantCrossing int
+		// Each connection beyond the first between two sub-mazes adds a loop.
+		wantLoops int
+	}{
+		{
+			name: "two levels",
+			levels: []Level{
+				{Width: 3, Height: 2, Algorithm: Backtracker, Connections: 1},
+				{Width: 4, Height: 3, Algorithm: Eller},
+			},
+			wantCrossing: 5,
+		},
+		{
+			name: "more connections",
+			levels: []Level{
+				{Width: 2, Height: 2, Algorithm: Prim, Connections: 3},
+				{Width: 5, Height: 4, Algorithm: Wilson},
+			},
+			wantCrossing: 9,
+			wantLoops:    6,
+		},
+		{
+			name: "three levels",
+			levels: []Level{
+				{Width: 2, Height: 1, Algorithm: Kruskal, Connections: 1},
+				{Width: 2, Height: 2, Algorithm: Sidewinder, Connections: 1},
+				{Width: 3, Height: 3, Algorithm: BinaryTree},
+			},
+			wantCrossing: 7,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mg := NewMazeGenerator(1, 1, WithSeed(11), WithLevels(tc.levels...),
+				WithFilename(filepath.Join(t.TempDir(), "maze.png")))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			r := mg.Maze()
+			innermost := tc.levels[len(tc.levels)-1]
+			wantWidth, wantHeight := innermost.Width, innermost.Height
+			for _, level := range tc.levels[:len(tc.levels)-1] {
+				wantWidth *= level.Width
+				wantHeight *= level.Height
+			}
+			if r.Width != wantWidth || r.Height != wantHeight {
+				t.Fatalf("(Generate) want %dx%d, got %dx%d", wantWidth, wantHeight, r.Width, r.Height)
+			}
+
+			edges := r.Graph.GetAllEdges()
+			crossing := 0
+			for _, e := range edges {
+				from, to := e.From.(int), e.To.(int)
+				if from%r.Width/innermost.Width != to%r.Width/innermost.Width ||
+					from/r.Width/innermost.Height != to/r.Width/innermost.Height {
+					crossing++
+				}
+			}
+			if crossing != tc.wantCrossing {
+				t.Errorf("(Generate) want %d passages between sub-mazes, got %d", tc.wantCrossing, crossing)
+			}
+
+			if tc.wantLoops == 0 {
+				assertSpanningTree(t, r, edges)
+			} else if want := r.Width*r.Height - 1 + tc.wantLoops; len(edges) != want {
+				t.Errorf("(Generate) want %d passages, got %d", want, len(edges))
+			}
+		})
+	}
+}
+
+func TestGenerateLevelsInvalid(t *testing.T) {
+	levels := []Level{
+		{Width: 2, Height: 2, Algorithm: Kruskal, Connections: 1},
+		{Width: 3, Height: 3, Algorithm: Kruskal},
+	}
+
+	testCases := []struct {
+		name    string
+		opts    []GeneratorOptions
+		wantErr error
+	}{
+		{
+			name: "too many connections",
+			opts: []GeneratorOptions{WithLevels(
+				Level{Width: 2, Height: 1, Algorithm: Kruskal, Connections: 4},
+				Level{Width: 3, Height: 3, Algorithm: Kruskal},
+			)},
+			wantErr: ErrInvalidLevel,
+		},
+		{
+			name: "no connections",
+			opts: []GeneratorOptions{WithLevels(
+				Level{Width: 2, Height: 2, Algorithm: Kruskal},
+				Level{Width: 3, Height: 3, Algorithm: Kruskal},
+			)},
+			wantErr: ErrInvalidLevel,
+		},
+		{
+			name:    "empty level",
+			opts:    []GeneratorOptions{WithLevels(Level{Width: 0, Height: 3})},
+			wantErr: ErrInvalidLevel,
+		},
+		{
+			name:    "with recursion",
+			opts:    []GeneratorOptions{WithLevels(levels...), WithRecursionLevel(1)},
+			wantErr: ErrInvalidLevel,
+		},
+		{
+			name:    "hex",
+			opts:    []GeneratorOptions{WithLevels(levels...), WithShape(Hex)},
+			wantErr: ErrUnsupportedShape,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mg := NewMazeGenerator(1, 1, append(tc.opts, WithFilename(filepath.Join(t.TempDir(), "maze.png")))...)
+			if err := mg.Generate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDrawBlockColor(t *testing.T) {
+	mg := NewMazeGenerator(1, 1, WithSeed(4), WithLevels(
+		Level{Width: 2, Height: 1, Algorithm: Kruskal, Connections: 1},
+		Level{Width: 3, Height: 3, Algorithm: Kruskal},
+	), WithFilename(filepath.Join(t.TempDir(), "maze.png")))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	r := mg.Maze()
+
+	block := color.RGBA{R: 40, G: 80, B: 220, A: 255}
+	o := DefaultRenderOptions()
+	o.BlockColor = block
+	m, err := o.DrawRectangle(r, nil)
+	if err != nil {
+		t.Fatalf("(DrawRectangle) unexpected error: %v", err)
+	}
+
+	// Of the three walls between the two sub-mazes, one is open and the others
+	// are drawn in the block color, one pixel left of column 3.
+	closed := 0
+	for row := range 3 {
+		if _, open := r.Graph.GetEdge(r.RectToGraph(row, 2), r.RectToGraph(row, 3)); open {
+			continue
+		}
+		closed++
+		if got := m.At(3*SquareSize-1, row*SquareSize+SquareSize/2); color.RGBAModel.Convert(got) != color.RGBAModel.Convert(block) {
+			t.Errorf("(DrawRectangle) want the wall in row %d in the block color, got %v", row, got)
+		}
+	}
+	if closed != 2 {
+		t.Errorf("want 2 closed walls between the sub-mazes, got %d", closed)
+	}
+}
+
+func TestGenerateRecursive(t *testing.T) {
+	mg := NewMazeGenerator(3, 2, WithSeed(6), WithRecursionLevel(1),
+		WithFilename(filepath.Join(t.TempDir(), "maze.png")))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+
+	r := mg.Maze()
+	if r.Width != 12 || r.Height != 8 {
+		t.Fatalf("(Generate) want 12x8, got %dx%d", r.Width, r.Height)
+	}
+	assertSpanningTree(t, r, r.Graph.GetAllEdges())
+}
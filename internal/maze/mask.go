@@ -0,0 +1,213 @@
+package maze
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	maskExcluded = 'X' // marks a cell left out of a text mask
+	maskIncluded = '.'
+)
+
+var (
+	ErrInvalidMask      = errors.New("invalid maze mask")
+	ErrEmptyMask        = errors.New("mask excludes every cell")
+	ErrDisconnectedMask = errors.New("mask leaves disconnected islands")
+	ErrUnsupportedMask  = errors.New("option is not supported for masked mazes")
+)
+
+// Mask marks the cells of a rectangle that are left out of a maze, so the maze
+// takes the shape of the remaining cells.
+type Mask struct {
+	Width    int
+	Height   int
+	excluded []bool // indexed like Rectangle cells
+}
+
+// NewMask returns a mask of the given size that includes every cell.
+func NewMask(width, height int) (*Mask, error) {
+	if width < 1 || height < 1 {
+		return nil, fmt.Errorf("%w: size %dx%d", ErrInvalidMask, width, height)
+	}
+	return &Mask{
+		Width:    width,
+		Height:   height,
+		excluded: make([]bool, width*height),
+	}, nil
+}
+
+// Exclude leaves cell (x, y) out of the maze.
+func (m *Mask) Exclude(x, y int) {
+	m.excluded[y*m.Width+x] = true
+}
+
+// Excluded reports whether cell (x, y) is left out of the maze. Cells outside the
+// mask are excluded.
+func (m *Mask) Excluded(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.Width || y >= m.Height {
+		return true
+	}
+	return m.excluded[y*m.Width+x]
+}
+
+// includedBounds returns the smallest rectangle of cells holding every cell the
+// mask includes.
+func (m *Mask) includedBounds() image.Rectangle {
+	bounds := image.Rectangle{}
+	for y := range m.Height {
+		for x := range m.Width {
+			if !m.Excluded(x, y) {
+				bounds = bounds.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return bounds
+}
+
+// Islands groups the included cells into regions joined through their north,
+// east, south and west neighbors, ordered by their first cell row by row. A mask
+// a maze can be carved from has exactly one island.
+func (m *Mask) Islands() [][]image.Point {
+	seen := make([]bool, len(m.excluded))
+	islands := [][]image.Point{}
+	for start := range m.excluded {
+		if seen[start] || m.excluded[start] {
+			continue
+		}
+
+		island := []image.Point{}
+		seen[start] = true
+		stack := []image.Point{{start % m.Width, start / m.Width}}
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			island = append(island, p)
+			for _, d := range []image.Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+				q := p.Add(d)
+				if m.Excluded(q.X, q.Y) || seen[q.Y*m.Width+q.X] {
+					continue
+				}
+				seen[q.Y*m.Width+q.X] = true
+				stack = append(stack, q)
+			}
+		}
+		islands = append(islands, island)
+	}
+	return islands
+}
+
+// Validate checks that the included cells form a single island.
+func (m *Mask) Validate() error {
+	islands := m.Islands()
+	switch len(islands) {
+	case 0:
+		return ErrEmptyMask
+	case 1:
+		return nil
+	}
+
+	sizes := make([]string, len(islands))
+	for k, island := range islands {
+		sizes[k] = fmt.Sprintf("%d cells from %v", len(island), island[0])
+	}
+	return fmt.Errorf("%w: %d islands of %s", ErrDisconnectedMask, len(islands), strings.Join(sizes, ", "))
+}
+
+// String returns the mask as text, as read by ParseMaskText.
+func (m *Mask) String() string {
+	var sb strings.Builder
+	for y := range m.Height {
+		for x := range m.Width {
+			if m.Excluded(x, y) {
+				sb.WriteRune(maskExcluded)
+			} else {
+				sb.WriteRune(maskIncluded)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// ParseMaskText reads a mask with one line per row and one character per cell,
+// where 'X' marks an excluded cell. Rows shorter than the longest are excluded
+// past their end, and blank lines at the end are ignored.
+func ParseMaskText(r io.Reader) (*Mask, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		lines = append(lines, strings.TrimRight(scanner.Text(), "\r"))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidMask, err)
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	width := 0
+	for _, line := range lines {
+		width = max(width, len([]rune(line)))
+	}
+	m, err := NewMask(width, len(lines))
+	if err != nil {
+		return nil, err
+	}
+	for y, line := range lines {
+		row := []rune(line)
+		for x := range width {
+			if x >= len(row) || row[x] == maskExcluded || row[x] == 'x' {
+				m.Exclude(x, y)
+			}
+		}
+	}
+	return m, nil
+}
+
+// ParseMaskImage reads a mask with one pixel per cell, excluding dark and
+// transparent pixels.
+func ParseMaskImage(img image.Image) (*Mask, error) {
+	bounds := img.Bounds()
+	m, err := NewMask(bounds.Dx(), bounds.Dy())
+	if err != nil {
+		return nil, err
+	}
+	for y := range m.Height {
+		for x := range m.Width {
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			_, _, _, alpha := c.RGBA()
+			gray := color.Gray16Model.Convert(c).(color.Gray16)
+			if alpha < 0x8000 || gray.Y < 0x8000 {
+				m.Exclude(x, y)
+			}
+		}
+	}
+	return m, nil
+}
+
+// ReadMask reads a PNG mask, or a text mask from any other file.
+func ReadMask(filename string) (*Mask, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mask: %w", err)
+	}
+	defer file.Close()
+
+	if strings.EqualFold(filepath.Ext(filename), ".png") {
+		img, err := png.Decode(file)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidMask, err)
+		}
+		return ParseMaskImage(img)
+	}
+	return ParseMaskText(file)
+}
@@ -0,0 +1,181 @@
+package maze_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestParseMask(t *testing.T) {
+	text := "..X\n.\n...\n\n"
+	fromText, err := ParseMaskText(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("(ParseMaskText) unexpected error: %v", err)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, 3, 3))
+	for y := range 3 {
+		for x := range 3 {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	for _, p := range []image.Point{{2, 0}, {1, 1}, {2, 1}} {
+		img.SetGray(p.X, p.Y, color.Gray{Y: 20})
+	}
+	path := filepath.Join(t.TempDir(), "mask.png")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create mask: %v", err)
+	}
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("Failed to encode mask: %v", err)
+	}
+	file.Close()
+	fromImage, err := ReadMask(path)
+	if err != nil {
+		t.Fatalf("(ReadMask) unexpected error: %v", err)
+	}
+
+	// Short rows are excluded past their end, and the trailing blank line is ignored.
+	want := "..X\n.XX\n...\n"
+	for name, m := range map[string]*Mask{"text": fromText, "image": fromImage} {
+		if m.Width != 3 || m.Height != 3 {
+			t.Errorf("%s: want a 3x3 mask, got %dx%d", name, m.Width, m.Height)
+		}
+		if got := m.String(); got != want {
+			t.Errorf("%s: want mask\n%s\ngot\n%s", name, want, got)
+		}
+	}
+}
+
+func TestMaskIslands(t *testing.T) {
+	testCases := []struct {
+		name        string
+		mask        string
+		wantIslands int
+		wantErr     error
+	}{
+		{
+			name:        "one island",
+			mask:        "X..\n.X.\n...\n",
+			wantIslands: 1,
+		},
+		{
+			name:        "diagonal cells are not joined",
+			mask:        ".X\nX.\n",
+			wantIslands: 2,
+			wantErr:     ErrDisconnectedMask,
+		},
+		{
+			name:    "nothing included",
+			mask:    "XX\n",
+			wantErr: ErrEmptyMask,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := ParseMaskText(strings.NewReader(tc.mask))
+			if err != nil {
+				t.Fatalf("(ParseMaskText) unexpected error: %v", err)
+			}
+			if got := len(m.Islands()); got != tc.wantIslands {
+				t.Errorf("(Islands) want %d islands, got %d", tc.wantIslands, got)
+			}
+			if err := m.Validate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Validate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestGenerateMasked(t *testing.T) {
+	mask, _ := ParseMaskText(strings.NewReader("XX....\n......\n..XX..\n..XX..\n......\n....XX\n"))
+
+	for _, algorithm := range []Algorithm{Kruskal, Backtracker, Prim, Wilson, AldousBroder} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "maze.txt")
+			mg := NewMazeGenerator(0, 0, WithSeed(6), WithMask(mask), WithAlgorithm(algorithm), WithFilename(filename))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			r := mg.Maze()
+			included := 0
+			for cell := range r.Width * r.Height {
+				if r.Included(cell) {
+					included++
+				}
+			}
+			if included != 28 {
+				t.Errorf("want 28 included cells, got %d", included)
+			}
+
+			edges := r.Graph.GetAllEdges()
+			if len(edges) != included-1 {
+				t.Errorf("want %d passages joining the included cells, got %d", included-1, len(edges))
+			}
+			for _, e := range edges {
+				if !r.Included(e.From.(int)) || !r.Included(e.To.(int)) {
+					t.Errorf("passage %v-%v leads into a masked cell", e.From, e.To)
+				}
+			}
+			if mg.Entrance() != image.Pt(2, 0) || mg.Exit() != image.Pt(3, 5) {
+				t.Errorf("want openings at the first and last included cells, got %v and %v", mg.Entrance(), mg.Exit())
+			}
+		})
+	}
+}
+
+func TestGenerateMaskedUnsupported(t *testing.T) {
+	mask, _ := ParseMaskText(strings.NewReader("...\n.X.\n...\n"))
+	split, _ := ParseMaskText(strings.NewReader(".X.\n.X.\n"))
+	testCases := []struct {
+		name    string
+		opts    []GeneratorOptions
+		wantErr error
+	}{
+		{
+			name:    "disconnected",
+			opts:    []GeneratorOptions{WithMask(split)},
+			wantErr: ErrDisconnectedMask,
+		},
+		{
+			name:    "row by row algorithm",
+			opts:    []GeneratorOptions{WithMask(mask), WithAlgorithm(Sidewinder)},
+			wantErr: ErrUnsupportedAlgorithm,
+		},
+		{
+			name:    "recursive",
+			opts:    []GeneratorOptions{WithMask(mask), WithRecursionLevel(1)},
+			wantErr: ErrUnsupportedMask,
+		},
+		{
+			name:    "hex",
+			opts:    []GeneratorOptions{WithMask(mask), WithShape(Hex)},
+			wantErr: ErrUnsupportedShape,
+		},
+		{
+			name:    "opening on a hole",
+			opts:    []GeneratorOptions{WithMask(mask), WithOpenings(image.Pt(1, 1), image.Pt(2, 2))},
+			wantErr: ErrNotOnBorder,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]GeneratorOptions{WithFilename(filepath.Join(t.TempDir(), "maze.png"))}, tc.opts...)
+			err := NewMazeGenerator(0, 0, opts...).Generate()
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
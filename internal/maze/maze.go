@@ -1,9 +1,15 @@
 package maze
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"image"
+	"io"
+	"math/rand/v2"
+	"os"
 
-	"tsumegolang/pkg/algo/graph/kruskal"
+	"tsumegolang/pkg/ds/graph"
 	"tsumegolang/pkg/ds/graph/sparsegraph"
 )
 
@@ -13,26 +19,82 @@ const (
 )
 
 type MazeGenerator struct {
+	width          int // requested dimensions; rect grows to the full maze when recursing
+	height         int
 	rect           *Rectangle
+	shape          Shape
+	grid           *Grid // the maze when shape is not Square
+	mask           *Mask
+	braid          float64
+	render         RenderOptions
+	extraPassages  int
 	recursionLevel int
+	levels         []Level
 	filename       string
+	algorithm      Algorithm
+	seed           uint64
+	rng            *rand.Rand // shared with sub-mazes so one seed determines the whole maze
+	openings       openingPlacement
+	entrance       int
+	exit           int
+	entrancePoint  image.Point
+	exitPoint      image.Point
+	entranceSet    bool // otherwise the entrance is at its default cell
+	exitSet        bool
+	solver         Solver
+	solutionFile   string
+	solution       []int
+	format         Format
+	formatSet      bool // otherwise the format follows each file's extension
+	animationFile  string
+	animation      AnimationOptions
+	carved         [][2]int // passages in the order they were carved, when animating
+	compact        bool
+	compactMaze    *Compact // the maze when compact is set
+	floors         int
+	box            *Box // the maze when there is more than one floor
+	weights        WeightFunc
+	bias           *Bias
+	weave          float64
+	err            error // from building rect, reported by Generate
+	ctx            context.Context
 }
 
 type GeneratorOptions func(*MazeGenerator)
 
 func NewMazeGenerator(width, height int, opts ...GeneratorOptions) *MazeGenerator {
-	rect, _ := NewRectangle(width, height, NoConnect)
 	mg := &MazeGenerator{
-		rect: rect,
+		width:  width,
+		height: height,
+		seed:   rand.Uint64(),
+		render: DefaultRenderOptions(),
 	}
 
 	for _, opt := range opts {
 		opt(mg)
 	}
+	// Compact mazes never build the much larger Rectangle, and boxes build one
+	// per floor as they are drawn.
+	if mg.rect == nil && mg.err == nil && !mg.compact && mg.floors < 2 {
+		var err error
+		if mg.rect, err = NewRectangle(width, height, NoConnect); err != nil {
+			mg.err = fmt.Errorf("%w: %dx%d maze", err, width, height)
+		}
+	}
 
 	return mg
 }
 
+// WithContext stops Generate with the error of ctx once it is done, checking as
+// passages are carved and between the steps that follow.
+func WithContext(ctx context.Context) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.ctx = ctx
+	}
+}
+
+// WithFilename sets the file the maze is written to. Without one, Generate
+// writes no maze.
 func WithFilename(filename string) GeneratorOptions {
 	return func(mg *MazeGenerator) {
 		mg.filename = filename
@@ -45,24 +107,202 @@ func WithRecursionLevel(level int) GeneratorOptions {
 	}
 }
 
+// WithAlgorithm selects the algorithm used to carve each maze. When recursing, it
+// carves the innermost sub-mazes; the sub-mazes are always joined with Kruskal.
+func WithAlgorithm(algorithm Algorithm) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.algorithm = algorithm
+	}
+}
+
+// WithShape selects the tiling of the maze. For Polar mazes the height is the
+// number of rings and the width is ignored. Only Square mazes can recurse.
+func WithShape(shape Shape) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.shape = shape
+	}
+}
+
+// WithMask shapes the maze after mask, leaving out the cells it excludes. The
+// maze takes the size of the mask, and the included cells must form one island.
+func WithMask(mask *Mask) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.mask = mask
+		mg.width, mg.height = mask.Width, mask.Height
+		mg.rect, mg.err = NewRectangle(mask.Width, mask.Height, NoConnect, WithExcluded(mask))
+	}
+}
+
+// WithLevels builds a hierarchical maze from levels, outermost first, in place of
+// WithRecursionLevel. The maze takes the size of all the levels together.
+func WithLevels(levels ...Level) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.levels = levels
+		size := levelsSize(levels)
+		mg.width, mg.height = size.X, size.Y
+		// Invalid levels are reported when the maze is generated.
+		mg.rect, _ = NewRectangle(size.X, size.Y, NoConnect)
+	}
+}
+
+// WithBraid opens a wall from ratio, between 0 and 1, of the dead ends, so the
+// maze has loops and more than one way through.
+func WithBraid(ratio float64) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.braid = ratio
+	}
+}
+
+// WithExtraPassages opens n more walls at random after braiding.
+func WithExtraPassages(n int) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.extraPassages = n
+	}
+}
+
+// WithSeed makes generation reproducible: the same seed and options always give
+// the same maze. Without it, a random seed is chosen and reported by Seed.
+func WithSeed(seed uint64) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.seed = seed
+	}
+}
+
+// Seed returns the seed the maze is generated from.
+func (mg *MazeGenerator) Seed() uint64 {
+	return mg.seed
+}
+
+// WithSolver selects how the path between the openings is found.
+func WithSolver(solver Solver) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.solver = solver
+	}
+}
+
+// WithSolutionFilename writes a second image with the solution drawn over the maze.
+func WithSolutionFilename(filename string) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.solutionFile = filename
+	}
+}
+
+// WithFormat writes the maze and solution files in the given format instead of
+// the one matching their extensions.
+func WithFormat(format Format) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.format = format
+		mg.formatSet = true
+	}
+}
+
+// WithRenderOptions changes how PNG and SVG files are drawn.
+func WithRenderOptions(render RenderOptions) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.render = render
+	}
+}
+
+// WithAnimation writes an animated GIF of the maze being carved, and solved if
+// opts ask for it, to filename.
+func WithAnimation(filename string, opts AnimationOptions) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.animationFile = filename
+		mg.animation = opts
+	}
+}
+
+// Maze returns the generated maze, or the empty grid before Generate is called.
+// It is not used by mazes of other shapes, compact mazes or mazes with floors;
+// see Grid, Compact and Box.
+func (mg *MazeGenerator) Maze() *Rectangle {
+	return mg.rect
+}
+
+// Grid returns the generated maze when the shape is not Square, or nil.
+func (mg *MazeGenerator) Grid() *Grid {
+	return mg.grid
+}
+
+// Solution returns the cells from the entrance to the exit, once generated.
+func (mg *MazeGenerator) Solution() []int {
+	return mg.solution
+}
+
 func (mg *MazeGenerator) Generate() error {
-	if err := mg.generate(mg.recursionLevel); err != nil {
+	mg.rng = newRand(mg.seed)
+	if mg.floors > 1 {
+		return mg.generateBox()
+	}
+	if mg.compact {
+		return mg.generateCompact()
+	}
+	if mg.shape == Square && mg.err != nil {
+		return fmt.Errorf("failed to generate maze: %w", mg.err)
+	}
+	if err := mg.checkWeights(); err != nil {
+		return fmt.Errorf("failed to generate maze: %w", err)
+	}
+	if err := mg.checkWeave(); err != nil {
+		return fmt.Errorf("failed to generate maze: %w", err)
+	}
+	generate := mg.generate
+	if mg.shape != Square {
+		generate = mg.generateGrid
+	}
+	if err := generate(mg.recursionLevel); err != nil {
+		return fmt.Errorf("failed to generate maze: %w", err)
+	}
+
+	if err := mg.addLoops(); err != nil {
+		return fmt.Errorf("failed to braid maze: %w", err)
+	}
+	if err := mg.canceled(); err != nil {
 		return fmt.Errorf("failed to generate maze: %w", err)
 	}
 
+	if err := mg.placeOpenings(); err != nil {
+		return fmt.Errorf("failed to place openings: %w", err)
+	}
+
+	var solution []int
+	var err error
+	if mg.grid != nil {
+		solution, err = mg.solver.SolveGrid(mg.grid, mg.entrance, mg.exit)
+	} else {
+		solution, err = mg.solver.Solve(mg.rect, mg.entrance, mg.exit)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to solve maze: %w", err)
+	}
+	mg.solution = solution
+
 	if err := mg.drawMaze(); err != nil {
 		return fmt.Errorf("failed to draw maze: %w", err)
 	}
 
+	if err := mg.writeAnimation(); err != nil {
+		return fmt.Errorf("failed to animate maze: %w", err)
+	}
+
 	return nil
 }
 
 func (mg *MazeGenerator) generate(recursionLevel int) error {
+	if mg.levels != nil {
+		return mg.generateLevels(recursionLevel)
+	}
+	if mg.mask != nil {
+		return mg.generateMasked(recursionLevel)
+	}
+
+	algorithm := mg.algorithm
 	if recursionLevel > 0 {
 		submazes := makeSubmazeMatrix(mg.rect.Width, mg.rect.Height)
-		for i := range mg.rect.Width {
-			for j := range mg.rect.Height {
-				submazes[i][j] = NewMazeGenerator(defaultWidth, defaultHeight)
+		for i := range mg.rect.Height {
+			for j := range mg.rect.Width {
+				submazes[i][j] = NewMazeGenerator(defaultWidth, defaultHeight, WithAlgorithm(mg.algorithm))
+				submazes[i][j].rng = mg.rng
 				err := submazes[i][j].generate(recursionLevel - 1)
 				if err != nil {
 					return err
@@ -71,31 +311,154 @@ func (mg *MazeGenerator) generate(recursionLevel int) error {
 		}
 
 		rect, _ := NewRectangle(mg.rect.Width*submazes[0][0].rect.Width, mg.rect.Height*submazes[0][0].rect.Height, NoConnect)
+		rect.Block = image.Pt(defaultWidth, defaultHeight)
 		mg.rect = rect
 		mg.join(submazes)
+		algorithm = Kruskal
 	} else {
-		rect, err := NewRectangle(mg.rect.Width, mg.rect.Height, ConnectRandom)
+		rect, err := NewRectangle(mg.rect.Width, mg.rect.Height, ConnectRandom, WithRand(mg.rng), WithWeightFunc(mg.weightFunc()))
 		if err != nil {
 			return err
 		}
+		if err := layCrossings(rect, mg.weave, mg.rng); err != nil {
+			return err
+		}
 
 		mg.rect = rect
 	}
 
-	baseGraph := mg.rect.Graph
-	paths, err := sparsegraph.NewGraph(baseGraph.GetSize(), true)
+	paths, err := algorithm.carve(mg.rect, mg.rng, mg.recordCarving())
+	if err != nil {
+		return err
+	}
+
+	mg.rect.Graph = paths
+	return nil
+}
+
+func (mg *MazeGenerator) generateMasked(recursionLevel int) error {
+	if recursionLevel > 0 {
+		return fmt.Errorf("%w: masked mazes cannot recurse", ErrUnsupportedMask)
+	}
+	if err := mg.mask.Validate(); err != nil {
+		return err
+	}
+
+	rect, err := NewRectangle(mg.mask.Width, mg.mask.Height, ConnectRandom, WithRand(mg.rng), WithExcluded(mg.mask),
+		WithWeightFunc(mg.weightFunc()))
 	if err != nil {
 		return err
 	}
-	err = kruskal.MST(baseGraph, paths)
+	if err := layCrossings(rect, mg.weave, mg.rng); err != nil {
+		return err
+	}
+
+	paths, err := mg.algorithm.carve(rect, mg.rng, mg.recordCarving())
 	if err != nil {
 		return err
 	}
 
-	mg.rect.Graph = paths
+	rect.Graph = paths
+	mg.rect = rect
+	return nil
+}
+
+func (mg *MazeGenerator) generateLevels(recursionLevel int) error {
+	if recursionLevel > 0 {
+		return fmt.Errorf("%w: levels replace the recursion level", ErrInvalidLevel)
+	}
+	if mg.mask != nil {
+		return fmt.Errorf("%w: masked mazes cannot have levels", ErrUnsupportedMask)
+	}
+	if err := validateLevels(mg.levels); err != nil {
+		return err
+	}
+
+	rect, err := carveLevels(mg.levels, mg.rng, mg.recordCarving())
+	if err != nil {
+		return err
+	}
+
+	mg.rect = rect
 	return nil
 }
 
+func (mg *MazeGenerator) generateGrid(recursionLevel int) error {
+	if mg.mask != nil {
+		return fmt.Errorf("%w: %v mazes cannot be masked", ErrUnsupportedShape, mg.shape)
+	}
+	if recursionLevel > 0 || mg.levels != nil {
+		return fmt.Errorf("%w: %v mazes cannot recurse", ErrUnsupportedShape, mg.shape)
+	}
+
+	grid, err := NewGrid(mg.shape, mg.width, mg.height, ConnectRandom, WithRand(mg.rng))
+	if err != nil {
+		return err
+	}
+
+	paths, err := mg.algorithm.carveGraph(grid.Graph, mg.rng, mg.recordCarving())
+	if err != nil {
+		return err
+	}
+
+	grid.Graph = paths
+	mg.grid = grid
+	return nil
+}
+
+// recordCarving returns a function noting each passage as it is carved and
+// stopping the carving once the context of WithContext is done, or nil when there
+// is neither an animation to write nor a context. Sub-mazes are not recorded; the
+// whole maze is carved again once they are joined.
+func (mg *MazeGenerator) recordCarving() func(from, to int) error {
+	if mg.animationFile == "" && mg.ctx == nil {
+		return nil
+	}
+	mg.carved = nil
+	return func(from, to int) error {
+		if mg.animationFile != "" {
+			mg.carved = append(mg.carved, [2]int{from, to})
+		}
+		return mg.canceled()
+	}
+}
+
+// canceled returns the error of the context of WithContext once it is done.
+func (mg *MazeGenerator) canceled() error {
+	if mg.ctx == nil {
+		return nil
+	}
+	return mg.ctx.Err()
+}
+
+// addLoops applies WithBraid and WithExtraPassages to the carved maze.
+func (mg *MazeGenerator) addLoops() error {
+	if mg.braid == 0 && mg.extraPassages == 0 {
+		return nil
+	}
+
+	// The candidate walls are every pair of neighbors in a maze of the same shape.
+	var paths, candidates *sparsegraph.Graph
+	if mg.grid != nil {
+		grid, err := NewGrid(mg.grid.Shape, mg.grid.Width, mg.grid.Height, ConnectConst)
+		if err != nil {
+			return err
+		}
+		paths, candidates = mg.grid.Graph, grid.Graph
+	} else {
+		rect, err := NewRectangle(mg.rect.Width, mg.rect.Height, ConnectConst, WithExcluded(mg.rect.Mask))
+		if err != nil {
+			return err
+		}
+		paths, candidates = mg.rect.Graph, rect.Graph
+	}
+
+	if err := Braid(paths, candidates, mg.braid, mg.rng); err != nil {
+		return err
+	}
+	return AddPassages(paths, candidates, mg.extraPassages, mg.rng)
+}
+
 func makeSubmazeMatrix(width, height int) [][]*MazeGenerator {
 	matrix := make([][]*MazeGenerator, height)
 	for i := range matrix {
@@ -105,36 +468,41 @@ func makeSubmazeMatrix(width, height int) [][]*MazeGenerator {
 	return matrix
 }
 
+// join copies the passages of the sub-mazes, a matrix of rows, into mg.rect and
+// adds a candidate edge across every wall between neighboring sub-mazes.
 func (mg *MazeGenerator) join(matrix [][]*MazeGenerator) {
 	for i := range matrix {
 		for j := range matrix[i] {
 			r := matrix[i][j].rect
-			rw := r.Width
-			rh := r.Height
-			iBase := i * rw
-			jBase := j * rh
-			for ri := range rw {
-				for rj := range rh {
-					point := r.RectToGraph(ri, rj)
-					east := r.RectToGraph(ri+1, rj)
-					south := r.RectToGraph(ri, rj+1)
-					if e, ok := r.Graph.GetEdge(point, east); ok || (ri+1 == rw && i+1 < len(matrix)) {
-						newPoint := mg.rect.RectToGraph(iBase+ri, jBase+rj)
-						newEast := mg.rect.RectToGraph(iBase+ri+1, jBase+rj)
-						weight := e.Weight
-						if !ok {
-							weight = getNewWeight(ConnectRandom)
+			rowBase := i * r.Height
+			colBase := j * r.Width
+			for row := range r.Height {
+				for col := range r.Width {
+					point := r.RectToGraph(row, col)
+					newPoint := mg.rect.RectToGraph(rowBase+row, colBase+col)
+
+					var south, east graph.Edge
+					var southOK, eastOK bool
+					if row+1 < r.Height {
+						south, southOK = r.Graph.GetEdge(point, r.RectToGraph(row+1, col))
+					}
+					if col+1 < r.Width {
+						east, eastOK = r.Graph.GetEdge(point, r.RectToGraph(row, col+1))
+					}
+
+					if southOK || (row+1 == r.Height && i+1 < len(matrix)) {
+						weight := south.Weight
+						if !southOK {
+							weight = getNewWeight(ConnectRandom, mg.rng)
 						}
-						mg.rect.Graph.Connect(newPoint, newEast, weight)
+						mg.rect.Graph.Connect(newPoint, mg.rect.RectToGraph(rowBase+row+1, colBase+col), weight)
 					}
-					if e, ok := r.Graph.GetEdge(point, south); ok || (rj+1 == rh && j+1 < len(matrix[i])) {
-						newPoint := mg.rect.RectToGraph(iBase+ri, jBase+rj)
-						newSouth := mg.rect.RectToGraph(iBase+ri, jBase+rj+1)
-						weight := e.Weight
-						if !ok {
-							weight = getNewWeight(ConnectRandom)
+					if eastOK || (col+1 == r.Width && j+1 < len(matrix[i])) {
+						weight := east.Weight
+						if !eastOK {
+							weight = getNewWeight(ConnectRandom, mg.rng)
 						}
-						mg.rect.Graph.Connect(newPoint, newSouth, weight)
+						mg.rect.Graph.Connect(newPoint, mg.rect.RectToGraph(rowBase+row, colBase+col+1), weight)
 					}
 				}
 			}
@@ -142,11 +510,82 @@ func (mg *MazeGenerator) join(matrix [][]*MazeGenerator) {
 	}
 }
 
+// drawMaze writes the maze and its solution to the files set for them, if any.
 func (mg *MazeGenerator) drawMaze() error {
-	img, err := DrawRectangleMaze(mg.rect)
+	files := []struct {
+		name string
+		path []int
+	}{
+		{mg.filename, nil},
+		{mg.solutionFile, mg.solution},
+	}
+	for _, file := range files {
+		if file.name == "" {
+			continue
+		}
+		var err error
+		if mg.grid != nil {
+			err = mg.render.WriteGrid(mg.grid, file.name, mg.formatFor(file.name), file.path, mg.Metadata())
+		} else {
+			err = mg.render.WriteMaze(mg.rect, file.name, mg.formatFor(file.name), file.path, mg.Metadata())
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Encode writes the generated maze to w in the given format, as Generate writes
+// its files, with the solution drawn if solution is set.
+func (mg *MazeGenerator) Encode(w io.Writer, format Format, solution bool) error {
+	var path []int
+	if solution {
+		path = mg.solution
+	}
+	switch {
+	case mg.box != nil:
+		return mg.render.EncodeBox(w, mg.box, format, path, mg.Metadata())
+	case mg.compactMaze != nil:
+		return mg.render.EncodeCompact(w, mg.compactMaze, format, path, mg.Metadata())
+	case mg.grid != nil:
+		return mg.render.EncodeGrid(w, mg.grid, format, path, mg.Metadata())
+	}
+	return mg.render.EncodeMaze(w, mg.rect, format, path, mg.Metadata())
+}
+
+func (mg *MazeGenerator) writeAnimation() error {
+	if mg.animationFile == "" {
+		return nil
+	}
+
+	rec := Recording{Carved: mg.carved, Solution: mg.solution}
+	var buf bytes.Buffer
+	var err error
+	if mg.grid != nil {
+		if mg.animation.Solve {
+			rec.Explored = mg.solver.explore(mg.grid.Graph, mg.entrance, mg.exit, mg.grid.stepsTo(mg.exit))
+		}
+		err = mg.render.EncodeGridAnimation(&buf, mg.grid, rec, mg.animation)
+	} else {
+		if mg.animation.Solve {
+			rec.Explored = mg.solver.explore(mg.rect.Graph, mg.entrance, mg.exit, mg.rect.manhattan(mg.exit))
+		}
+		err = mg.render.EncodeAnimation(&buf, mg.rect, rec, mg.animation)
+	}
 	if err != nil {
 		return err
 	}
 
-	return WriteImageToFile(&img, mg.filename)
+	if err := os.WriteFile(mg.animationFile, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return nil
+}
+
+func (mg *MazeGenerator) formatFor(filename string) Format {
+	if mg.formatSet {
+		return mg.format
+	}
+	return FormatFromFilename(filename)
 }
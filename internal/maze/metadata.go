@@ -0,0 +1,261 @@
+package maze
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Metadata keys written into maze images. Together they are enough to regenerate the maze.
+const (
+	MetadataSeed      = "Maze-Seed"
+	MetadataWidth     = "Maze-Width"
+	MetadataHeight    = "Maze-Height"
+	MetadataAlgorithm = "Maze-Algorithm"
+	MetadataRecursion = "Maze-Recursion"
+	MetadataEntrance  = "Maze-Entrance"
+	MetadataExit      = "Maze-Exit"
+	MetadataShape     = "Maze-Shape"
+	MetadataMask      = "Maze-Mask"
+	MetadataBraid     = "Maze-Braid"
+	MetadataPassages  = "Maze-Passages"
+	MetadataLevels    = "Maze-Levels"
+	MetadataFloors    = "Maze-Floors"
+	MetadataBias      = "Maze-Bias"
+	MetadataWeave     = "Maze-Weave"
+)
+
+var (
+	ErrNotPNG          = errors.New("not a PNG file")
+	ErrInvalidMetadata = errors.New("invalid maze metadata")
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Metadata describes how the maze was generated, keyed by the Metadata* constants.
+// MetadataMask holds the mask as text, MetadataLevels the levels of a
+// hierarchical maze, MetadataFloors the floors of a box, MetadataBias the bias
+// set by WithBias and MetadataWeave the density of crossings, each only when
+// there is one.
+func (mg *MazeGenerator) Metadata() map[string]string {
+	metadata := map[string]string{
+		MetadataSeed:      strconv.FormatUint(mg.seed, 10),
+		MetadataWidth:     strconv.Itoa(mg.width),
+		MetadataHeight:    strconv.Itoa(mg.height),
+		MetadataAlgorithm: mg.algorithm.String(),
+		MetadataRecursion: strconv.Itoa(mg.recursionLevel),
+		MetadataEntrance:  formatPoint(mg.entrancePoint),
+		MetadataExit:      formatPoint(mg.exitPoint),
+		MetadataShape:     mg.shape.String(),
+		MetadataBraid:     strconv.FormatFloat(mg.braid, 'g', -1, 64),
+		MetadataPassages:  strconv.Itoa(mg.extraPassages),
+	}
+	if mg.mask != nil {
+		metadata[MetadataMask] = mg.mask.String()
+	}
+	if mg.levels != nil {
+		metadata[MetadataLevels] = FormatLevels(mg.levels)
+	}
+	if mg.floors > 1 {
+		metadata[MetadataFloors] = strconv.Itoa(mg.floors)
+	}
+	if mg.bias != nil {
+		metadata[MetadataBias] = mg.bias.String()
+	}
+	if mg.weave > 0 {
+		metadata[MetadataWeave] = strconv.FormatFloat(mg.weave, 'g', -1, 64)
+	}
+	return metadata
+}
+
+func formatPoint(p image.Point) string {
+	return fmt.Sprintf("%d,%d", p.X, p.Y)
+}
+
+// ParsePoint reads a point written as "x,y".
+func ParsePoint(s string) (image.Point, error) {
+	var p image.Point
+	if _, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y); err != nil {
+		return p, fmt.Errorf("invalid point %q: want x,y", s)
+	}
+	return p, nil
+}
+
+// NewMazeGeneratorFromMetadata returns a generator that reproduces the maze described by
+// metadata, as read by ReadMetadata. opts are applied afterwards, e.g. to pick a new filename.
+func NewMazeGeneratorFromMetadata(metadata map[string]string, opts ...GeneratorOptions) (*MazeGenerator, error) {
+	seed, err := strconv.ParseUint(metadata[MetadataSeed], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataSeed, err)
+	}
+	width, err := strconv.Atoi(metadata[MetadataWidth])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataWidth, err)
+	}
+	height, err := strconv.Atoi(metadata[MetadataHeight])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataHeight, err)
+	}
+	algorithm, err := ParseAlgorithm(metadata[MetadataAlgorithm])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataAlgorithm, err)
+	}
+	recursion, err := strconv.Atoi(metadata[MetadataRecursion])
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataRecursion, err)
+	}
+
+	// Mazes written before shapes were added are square.
+	shape := Square
+	if metadata[MetadataShape] != "" {
+		if shape, err = ParseShape(metadata[MetadataShape]); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataShape, err)
+		}
+	}
+
+	base := []GeneratorOptions{
+		WithSeed(seed),
+		WithAlgorithm(algorithm),
+		WithRecursionLevel(recursion),
+		WithShape(shape),
+	}
+	// Mazes written before braiding was added have no loops.
+	if metadata[MetadataBraid] != "" {
+		braid, err := strconv.ParseFloat(metadata[MetadataBraid], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataBraid, err)
+		}
+		base = append(base, WithBraid(braid))
+	}
+	if metadata[MetadataPassages] != "" {
+		passages, err := strconv.Atoi(metadata[MetadataPassages])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataPassages, err)
+		}
+		base = append(base, WithExtraPassages(passages))
+	}
+	if metadata[MetadataMask] != "" {
+		mask, err := ParseMaskText(strings.NewReader(metadata[MetadataMask]))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataMask, err)
+		}
+		base = append(base, WithMask(mask))
+	}
+	if metadata[MetadataLevels] != "" {
+		levels, err := ParseLevels(metadata[MetadataLevels])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataLevels, err)
+		}
+		base = append(base, WithLevels(levels...))
+	}
+	if metadata[MetadataFloors] != "" {
+		floors, err := strconv.Atoi(metadata[MetadataFloors])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataFloors, err)
+		}
+		base = append(base, WithFloors(floors))
+	}
+	if metadata[MetadataBias] != "" {
+		bias, err := ParseBias(metadata[MetadataBias])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataBias, err)
+		}
+		base = append(base, WithBias(bias))
+	}
+	if metadata[MetadataWeave] != "" {
+		weave, err := strconv.ParseFloat(metadata[MetadataWeave], 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataWeave, err)
+		}
+		base = append(base, WithWeave(weave))
+	}
+	if metadata[MetadataEntrance] != "" || metadata[MetadataExit] != "" {
+		entrance, err := ParsePoint(metadata[MetadataEntrance])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataEntrance, err)
+		}
+		exit, err := ParsePoint(metadata[MetadataExit])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidMetadata, MetadataExit, err)
+		}
+		base = append(base, WithOpenings(entrance, exit))
+	}
+	opts = append(base, opts...)
+	return NewMazeGenerator(width, height, opts...), nil
+}
+
+// writePNGText copies the PNG stream in png to w, adding a tEXt chunk for every
+// metadata entry right after the IHDR chunk.
+func writePNGText(w io.Writer, png []byte, metadata map[string]string) error {
+	if !bytes.HasPrefix(png, pngSignature) {
+		return ErrNotPNG
+	}
+	// The signature is followed by IHDR: 4 bytes of length, 4 of type, 13 of data and 4 of CRC.
+	ihdrEnd := len(pngSignature) + 4 + 4 + 13 + 4
+	if len(png) < ihdrEnd {
+		return ErrNotPNG
+	}
+
+	if _, err := w.Write(png[:ihdrEnd]); err != nil {
+		return err
+	}
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		data := append([]byte(key), 0)
+		data = append(data, metadata[key]...)
+		if err := writePNGChunk(w, "tEXt", data); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(png[ihdrEnd:])
+	return err
+}
+
+func writePNGChunk(w io.Writer, chunkType string, data []byte) error {
+	chunk := binary.BigEndian.AppendUint32(nil, uint32(len(data)))
+	chunk = append(chunk, chunkType...)
+	chunk = append(chunk, data...)
+	chunk = binary.BigEndian.AppendUint32(chunk, crc32.ChecksumIEEE(chunk[4:]))
+	_, err := w.Write(chunk)
+	return err
+}
+
+// ReadMetadata returns the tEXt entries of a PNG file, such as those written for a maze.
+func ReadMetadata(filename string) (map[string]string, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if !bytes.HasPrefix(data, pngSignature) {
+		return nil, ErrNotPNG
+	}
+
+	metadata := map[string]string{}
+	for rest := data[len(pngSignature):]; len(rest) >= 12; {
+		length := int(binary.BigEndian.Uint32(rest[:4]))
+		if length > len(rest)-12 {
+			return nil, ErrNotPNG
+		}
+		chunkType := string(rest[4:8])
+		chunkData := rest[8 : 8+length]
+		if chunkType == "tEXt" {
+			if key, value, ok := bytes.Cut(chunkData, []byte{0}); ok {
+				metadata[string(key)] = string(value)
+			}
+		}
+		if chunkType == "IEND" {
+			break
+		}
+		rest = rest[12+length:]
+	}
+
+	return metadata, nil
+}
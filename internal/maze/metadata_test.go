@@ -0,0 +1,136 @@
+package maze_test
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestMetadataRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name   string
+		width  int
+		height int
+		opts   []GeneratorOptions
+	}{
+		{
+			name:   "default algorithm",
+			width:  12,
+			height: 9,
+			opts:   []GeneratorOptions{WithSeed(7)},
+		},
+		{
+			name:   "backtracker",
+			width:  5,
+			height: 15,
+			opts:   []GeneratorOptions{WithSeed(1234567890123), WithAlgorithm(Backtracker)},
+		},
+		{
+			name:   "recursive",
+			width:  3,
+			height: 3,
+			opts:   []GeneratorOptions{WithSeed(99), WithAlgorithm(Wilson), WithRecursionLevel(1)},
+		},
+		{
+			name:   "polar",
+			width:  1,
+			height: 5,
+			opts:   []GeneratorOptions{WithSeed(42), WithShape(Polar)},
+		},
+		{
+			name: "masked",
+			opts: []GeneratorOptions{WithSeed(3), WithMask(newTestMask(t))},
+		},
+		{
+			name: "levels",
+			opts: []GeneratorOptions{WithSeed(8), WithLevels(
+				Level{Width: 3, Height: 2, Algorithm: Backtracker, Connections: 2},
+				Level{Width: 4, Height: 3, Algorithm: Sidewinder},
+			)},
+		},
+		{
+			name:   "braided",
+			width:  8,
+			height: 8,
+			opts:   []GeneratorOptions{WithSeed(77), WithBraid(0.4), WithExtraPassages(2)},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			original := filepath.Join(dir, "original.png")
+			regenerated := filepath.Join(dir, "regenerated.png")
+
+			mg := NewMazeGenerator(tc.width, tc.height, append(tc.opts, WithFilename(original))...)
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			metadata, err := ReadMetadata(original)
+			if err != nil {
+				t.Fatalf("(ReadMetadata) unexpected error: %v", err)
+			}
+			for key, want := range mg.Metadata() {
+				if got := metadata[key]; got != want {
+					t.Errorf("(ReadMetadata) %s: want %q, got %q", key, want, got)
+				}
+			}
+
+			mg2, err := NewMazeGeneratorFromMetadata(metadata, WithFilename(regenerated))
+			if err != nil {
+				t.Fatalf("(NewMazeGeneratorFromMetadata) unexpected error: %v", err)
+			}
+			if err := mg2.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			want, _ := os.ReadFile(original)
+			got, _ := os.ReadFile(regenerated)
+			if !bytes.Equal(want, got) {
+				t.Errorf("regenerated maze differs from the original")
+			}
+			if _, err := png.Decode(bytes.NewReader(got)); err != nil {
+				t.Errorf("(png.Decode) unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestNewMazeGeneratorFromMetadataInvalid(t *testing.T) {
+	metadata := NewMazeGenerator(3, 3, WithSeed(1)).Metadata()
+	metadata[MetadataAlgorithm] = "maze-o-matic"
+
+	if _, err := NewMazeGeneratorFromMetadata(metadata); !errors.Is(err, ErrInvalidMetadata) {
+		t.Errorf("(NewMazeGeneratorFromMetadata) want %v, got %v", ErrInvalidMetadata, err)
+	}
+}
+
+func TestReadMetadataNotPNG(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "maze.txt")
+	if err := os.WriteFile(filename, []byte("not a maze"), 0644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := ReadMetadata(filename); !errors.Is(err, ErrNotPNG) {
+		t.Errorf("(ReadMetadata) want %v, got %v", ErrNotPNG, err)
+	}
+}
+
+func newTestMask(t *testing.T) *Mask {
+	t.Helper()
+
+	mask, err := NewMask(6, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	mask.Exclude(0, 0)
+	mask.Exclude(2, 1)
+	mask.Exclude(5, 3)
+	return mask
+}
@@ -0,0 +1,205 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+)
+
+var (
+	ErrNotOnBorder = errors.New("opening is not on the border of the maze")
+)
+
+type openingPlacement int
+
+const (
+	cornerOpenings   openingPlacement = iota // top-left and bottom-right
+	pointOpenings                            // either chosen with WithEntrance or WithExit
+	farthestOpenings                         // the border cells farthest apart
+)
+
+// WithOpenings places the entrance and exit at the given cells, which must be on
+// the border of the final maze. Points are (column, row), starting at (0, 0) in
+// the top-left corner. By default the maze opens at the top-left and bottom-right,
+// or the first and last cells a mask includes.
+func WithOpenings(entrance, exit image.Point) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		WithEntrance(entrance)(mg)
+		WithExit(exit)(mg)
+	}
+}
+
+// WithEntrance places the entrance as WithOpenings does, leaving the exit at its
+// default cell unless WithExit chooses it.
+func WithEntrance(entrance image.Point) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.openings = pointOpenings
+		mg.entrancePoint = entrance
+		mg.entranceSet = true
+	}
+}
+
+// WithExit places the exit as WithOpenings does, leaving the entrance at its
+// default cell unless WithEntrance chooses it.
+func WithExit(exit image.Point) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.openings = pointOpenings
+		mg.exitPoint = exit
+		mg.exitSet = true
+	}
+}
+
+// WithFarthestOpenings places the entrance and exit at a pair of border cells whose
+// path through the maze is as long as possible.
+func WithFarthestOpenings() GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.openings = farthestOpenings
+	}
+}
+
+// Entrance returns the (column, row) of the entrance once the maze is generated.
+func (mg *MazeGenerator) Entrance() image.Point {
+	return mg.entrancePoint
+}
+
+// Exit returns the (column, row) of the exit once the maze is generated.
+func (mg *MazeGenerator) Exit() image.Point {
+	return mg.exitPoint
+}
+
+// placeOpenings resolves the entrance and exit against the generated maze and
+// records them as its first two openings.
+func (mg *MazeGenerator) placeOpenings() error {
+	if mg.grid != nil {
+		return mg.placeGridOpenings()
+	}
+
+	r := mg.rect
+	var entrance, exit int
+	switch mg.openings {
+	case cornerOpenings, pointOpenings:
+		entrance, exit = 0, r.RectToGraph(r.Height-1, r.Width-1)
+		for !r.Included(entrance) {
+			entrance++
+		}
+		for !r.Included(exit) {
+			exit--
+		}
+		var err error
+		if entrance, exit, err = mg.chosenOpenings(r.borderCell, entrance, exit); err != nil {
+			return err
+		}
+	case farthestOpenings:
+		entrance, exit = r.farthestBorderCells()
+	}
+
+	r.Openings = []int{entrance, exit}
+	mg.entrance, mg.exit = entrance, exit
+	mg.entrancePoint = r.cellPoint(entrance)
+	mg.exitPoint = r.cellPoint(exit)
+	return nil
+}
+
+// placeGridOpenings is placeOpenings for hex, triangle and polar mazes. By default
+// a polar maze is entered from the outer ring and solved at its center, which
+// has no opening; the center, (0, 0), may also be chosen as the exit.
+func (mg *MazeGenerator) placeGridOpenings() error {
+	g := mg.grid
+	var entrance, exit int
+	switch mg.openings {
+	case cornerOpenings, pointOpenings:
+		entrance, exit = 0, len(g.cells)-1
+		if g.Shape == Polar {
+			entrance, _ = g.CellAt(image.Pt(0, g.Height-1))
+			exit = 0
+		}
+		borderCell := func(p image.Point) (int, error) {
+			if g.Shape == Polar && p == (image.Point{}) {
+				return 0, nil // the center
+			}
+			return g.borderCell(p)
+		}
+		var err error
+		if entrance, exit, err = mg.chosenOpenings(borderCell, entrance, exit); err != nil {
+			return err
+		}
+	case farthestOpenings:
+		entrance, exit = farthestCells(adjacency(g.Graph), g.BorderCells())
+	}
+
+	g.Openings = []int{entrance}
+	if g.IsBorder(exit) {
+		g.Openings = append(g.Openings, exit)
+	}
+	mg.entrance, mg.exit = entrance, exit
+	mg.entrancePoint = g.CellPoint(entrance)
+	mg.exitPoint = g.CellPoint(exit)
+	return nil
+}
+
+// chosenOpenings replaces the default entrance and exit with the cells chosen by
+// WithEntrance and WithExit, as found by borderCell.
+func (mg *MazeGenerator) chosenOpenings(borderCell func(image.Point) (int, error), entrance, exit int) (int, int, error) {
+	var err error
+	if mg.entranceSet {
+		if entrance, err = borderCell(mg.entrancePoint); err != nil {
+			return 0, 0, err
+		}
+	}
+	if mg.exitSet {
+		if exit, err = borderCell(mg.exitPoint); err != nil {
+			return 0, 0, err
+		}
+	}
+	return entrance, exit, nil
+}
+
+func (r *Rectangle) borderCell(p image.Point) (int, error) {
+	if p.X < 0 || p.Y < 0 || p.X >= r.Width || p.Y >= r.Height {
+		return 0, fmt.Errorf("%w: %v is outside the %dx%d maze", ErrNotOnBorder, p, r.Width, r.Height)
+	}
+	cell := r.RectToGraph(p.Y, p.X)
+	if !r.IsBorder(cell) { // including cells left out by the mask
+		return 0, fmt.Errorf("%w: %v", ErrNotOnBorder, p)
+	}
+	return cell, nil
+}
+
+func (g *Grid) borderCell(p image.Point) (int, error) {
+	cell, ok := g.CellAt(p)
+	if !ok {
+		return 0, fmt.Errorf("%w: %v is outside the %v maze", ErrNotOnBorder, p, g.Shape)
+	}
+	if !g.IsBorder(cell) {
+		return 0, fmt.Errorf("%w: %v", ErrNotOnBorder, p)
+	}
+	return cell, nil
+}
+
+func (r *Rectangle) cellPoint(cell int) image.Point {
+	i, j := r.GraphToRect(cell)
+	return image.Pt(j, i)
+}
+
+func (r *Rectangle) farthestBorderCells() (int, int) {
+	return farthestCells(adjacency(r.Graph), r.BorderCells())
+}
+
+// farthestCells picks two border cells far apart along the passages in adj, by
+// walking to the farthest border cell twice. In a perfect maze this finds the
+// longest path between border cells.
+func farthestCells(adj [][]int, border []int) (int, int) {
+	farthest := func(from int) int {
+		dist := distances(adj, from)
+		best := from
+		for _, cell := range border {
+			if dist[cell] > dist[best] {
+				best = cell
+			}
+		}
+		return best
+	}
+
+	a := farthest(border[0])
+	return a, farthest(a)
+}
@@ -0,0 +1,425 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"slices"
+)
+
+const (
+	// alignedWalls is the share of wall pixels that must lie on the cell grid for
+	// a cell size to be accepted. The rest are allowed for round caps and the like.
+	alignedWalls = 0.99
+	// wallTolerance is how far, out of 0xffff, each channel of a wall pixel may be
+	// from the color of the outer walls.
+	wallTolerance = 0x1000
+)
+
+var (
+	ErrUnrecognizedImage = errors.New("image is not a recognizable maze drawing")
+)
+
+// ImageOption gives ParseImage a size it would otherwise detect, or the mask it
+// cannot.
+type ImageOption func(*imageConfig)
+
+type imageConfig struct {
+	cellSize      int
+	wallThickness int
+	mask          *Mask
+}
+
+// WithCellSize sets the width of a cell in pixels.
+func WithCellSize(size int) ImageOption {
+	return func(c *imageConfig) {
+		c.cellSize = size
+	}
+}
+
+// WithWallThickness sets the thickness of the walls in pixels.
+func WithWallThickness(thickness int) ImageOption {
+	return func(c *imageConfig) {
+		c.wallThickness = thickness
+	}
+}
+
+// WithImageMask sets the mask the maze was drawn with. Its excluded cells are
+// left out, and the walls around them are the outer walls of the maze.
+func WithImageMask(mask *Mask) ImageOption {
+	return func(c *imageConfig) {
+		c.mask = mask
+	}
+}
+
+// ParseImage reads a rectangular maze drawn by DrawRectangleMaze or DrawRectangle
+// back into a Rectangle, with the options it appears to be drawn with. The outer
+// walls must be darker than the passages and the background, and every wall the
+// same color; anything else drawn over the maze, such as a solution, is ignored.
+// Gaps in the outer wall become the Openings, in the order of their cells.
+// Only square cells are recognized, woven mazes are not, and a masked maze is
+// read only when its mask is given with WithImageMask.
+func ParseImage(img image.Image, opts ...ImageOption) (*Rectangle, RenderOptions, error) {
+	config := imageConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	// The dark pixels span the maze; the most common of them around its edge is
+	// the color of the walls.
+	render := DefaultRenderOptions()
+	dark := darkerThanMiddle(img)
+	extent, ok := newWallMap(img, dark).extent()
+	if !ok {
+		return nil, render, fmt.Errorf("%w: no walls found", ErrUnrecognizedImage)
+	}
+	render.WallColor = outlineColor(img, extent, dark)
+	walls := newWallMap(img, near(render.WallColor))
+	if extent, ok = walls.extent(); !ok {
+		return nil, render, fmt.Errorf("%w: no walls found", ErrUnrecognizedImage)
+	}
+
+	thickness := config.wallThickness
+	if thickness == 0 {
+		thickness = walls.thickness(extent)
+	}
+
+	// Walls inside the rectangle straddle the edges of the cells, so where the
+	// mask leaves out the outer cells, they stick out of the cells they enclose.
+	var included image.Rectangle
+	if config.mask != nil {
+		included = config.mask.includedBounds()
+		if included.Min.X > 0 {
+			extent.Min.X += (thickness + 1) / 2
+		}
+		if included.Min.Y > 0 {
+			extent.Min.Y += (thickness + 1) / 2
+		}
+		if included.Max.X < config.mask.Width {
+			extent.Max.X -= thickness / 2
+		}
+		if included.Max.Y < config.mask.Height {
+			extent.Max.Y -= thickness / 2
+		}
+	}
+
+	size := config.cellSize
+	if size == 0 {
+		if size = walls.cellSize(extent, thickness); size == 0 {
+			return nil, render, fmt.Errorf("%w: walls spanning %dx%d pixels are not a grid of square cells; hex, triangle and polar drawings are not supported", ErrUnrecognizedImage, extent.Dx(), extent.Dy())
+		}
+	}
+	if size < 1 || extent.Dx()%size != 0 || extent.Dy()%size != 0 {
+		return nil, render, fmt.Errorf("%w: walls spanning %dx%d pixels do not fit cells of %d pixels", ErrUnrecognizedImage, extent.Dx(), extent.Dy(), size)
+	}
+
+	// origin is the top left corner of the rectangle, which the mask may leave
+	// without walls.
+	width, height := extent.Dx()/size, extent.Dy()/size
+	origin := extent.Min
+	rectOpts := []RectangleOption{}
+	if config.mask != nil {
+		if included.Dx() != width || included.Dy() != height {
+			return nil, render, fmt.Errorf("%w: walls spanning %dx%d cells do not fit the mask, which includes %dx%d", ErrUnrecognizedImage, width, height, included.Dx(), included.Dy())
+		}
+		origin = origin.Sub(included.Min.Mul(size))
+		width, height = config.mask.Width, config.mask.Height
+		rectOpts = append(rectOpts, WithExcluded(config.mask))
+	}
+
+	render.CellSize = size
+	render.WallThickness = thickness
+	render.Margin = origin.X
+	if err := render.Validate(); err != nil {
+		return nil, render, fmt.Errorf("%w: %w", ErrUnrecognizedImage, err)
+	}
+
+	r, err := NewRectangle(width, height, NoConnect, rectOpts...)
+	if err != nil {
+		return nil, render, err
+	}
+
+	// Walls are sampled a quarter, half and three quarters of the way along, so a
+	// solution crossing an opening cannot close it.
+	line := func(origin, edge, cells int) int {
+		return wallLine(origin, edge, cells, size, thickness)
+	}
+	wallAt := func(along func(k int) image.Point) bool {
+		dark := 0
+		for k := 1; k <= 3; k++ {
+			if walls.at(along(k)) {
+				dark++
+			}
+		}
+		return dark >= 2
+	}
+	horizontal := func(x, y int) bool {
+		top := line(origin.Y, y, r.Height) + thickness/2
+		return wallAt(func(k int) image.Point { return image.Pt(origin.X+x*size+k*size/4, top) })
+	}
+	vertical := func(x, y int) bool {
+		left := line(origin.X, x, r.Width) + thickness/2
+		return wallAt(func(k int) image.Point { return image.Pt(left, origin.Y+y*size+k*size/4) })
+	}
+
+	// Between the walls on its edges a cell is empty, unless it is a crossing of
+	// a woven maze, whose passages are drawn inset.
+	if cell, ok := walls.crossing(origin, r, size, thickness); ok {
+		return nil, render, fmt.Errorf("%w: walls run through the middle of cell %v of %d pixels; woven mazes are not supported", ErrUnrecognizedImage, cell, size)
+	}
+
+	for y := range r.Height {
+		for x := range r.Width {
+			cell := r.RectToGraph(y, x)
+			if !r.Included(cell) {
+				continue
+			}
+			if !r.outside(y, x+1) && !vertical(x+1, y) {
+				if err := r.Graph.Connect(cell, cell+1, DefaultWeight); err != nil {
+					return nil, render, err
+				}
+			}
+			if !r.outside(y+1, x) && !horizontal(x, y+1) {
+				if err := r.Graph.Connect(cell, cell+r.Width, DefaultWeight); err != nil {
+					return nil, render, err
+				}
+			}
+
+			open := (r.outside(y-1, x) && !horizontal(x, y)) || (r.outside(y+1, x) && !horizontal(x, y+1)) ||
+				(r.outside(y, x-1) && !vertical(x, y)) || (r.outside(y, x+1) && !vertical(x+1, y))
+			if open {
+				r.Openings = append(r.Openings, cell)
+			}
+		}
+	}
+
+	// Cells walled off from the rest are most likely left out by a mask, whose
+	// outline would otherwise be taken for openings. The crossings of a woven maze
+	// line up with cells a quarter of their size, whose corners are walled off too.
+	if config.mask == nil {
+		if slices.Contains(distances(adjacency(r.Graph), 0), -1) {
+			if width%4 == 0 && height%4 == 0 {
+				if woven, err := NewRectangle(width/4, height/4, NoConnect); err == nil {
+					if cell, ok := walls.crossing(origin, woven, 4*size, thickness); ok {
+						return nil, render, fmt.Errorf("%w: walls run through the middle of cell %v of %d pixels; woven mazes are not supported", ErrUnrecognizedImage, cell, 4*size)
+					}
+				}
+			}
+			return nil, render, fmt.Errorf("%w: some cells are walled off from the rest; masked mazes can only be read with their mask", ErrUnrecognizedImage)
+		}
+	}
+
+	return r, render, nil
+}
+
+// wallMap marks the pixels of an image that are walls.
+type wallMap struct {
+	bounds image.Rectangle // the image's bounds moved to the origin
+	dark   []bool
+}
+
+func newWallMap(img image.Image, isWall func(c color.Color) bool) wallMap {
+	bounds := img.Bounds()
+	w := wallMap{bounds: bounds.Sub(bounds.Min), dark: make([]bool, bounds.Dx()*bounds.Dy())}
+	for y := range bounds.Dy() {
+		for x := range bounds.Dx() {
+			w.dark[y*bounds.Dx()+x] = isWall(img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return w
+}
+
+func (w wallMap) at(p image.Point) bool {
+	return p.In(w.bounds) && w.dark[p.Y*w.bounds.Dx()+p.X]
+}
+
+// wallLine returns where the wall on an edge between cells size across starts,
+// counting edges from origin, with the outer walls kept inside the maze.
+func wallLine(origin, edge, cells, size, thickness int) int {
+	return min(max(origin+edge*size-(thickness+1)/2, origin), origin+cells*size-thickness)
+}
+
+// crossing returns the (column, row) of a cell of r, drawn size pixels across
+// from origin, with walls inside it rather than on its edges.
+func (w wallMap) crossing(origin image.Point, r *Rectangle, size, thickness int) (image.Point, bool) {
+	for y := range r.Height {
+		for x := range r.Width {
+			if !r.Included(r.RectToGraph(y, x)) {
+				continue
+			}
+			left, top := wallLine(origin.X, x, r.Width, size, thickness), wallLine(origin.Y, y, r.Height, size, thickness)
+			right, bottom := wallLine(origin.X, x+1, r.Width, size, thickness), wallLine(origin.Y, y+1, r.Height, size, thickness)
+			for py := top + thickness; py < bottom; py++ {
+				for px := left + thickness; px < right; px++ {
+					if w.at(image.Pt(px, py)) {
+						return image.Pt(x, y), true
+					}
+				}
+			}
+		}
+	}
+	return image.Point{}, false
+}
+
+// darkerThanMiddle returns whether a color is closer to the darkest opaque color
+// of img than to the lightest.
+func darkerThanMiddle(img image.Image) func(c color.Color) bool {
+	gray := func(c color.Color) (int, bool) {
+		if _, _, _, alpha := c.RGBA(); alpha < 0x8000 {
+			return 0, false
+		}
+		return int(color.Gray16Model.Convert(c).(color.Gray16).Y), true
+	}
+
+	bounds := img.Bounds()
+	darkest, lightest := 0xffff, 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if g, ok := gray(img.At(x, y)); ok {
+				darkest, lightest = min(darkest, g), max(lightest, g)
+			}
+		}
+	}
+	return func(c color.Color) bool {
+		g, ok := gray(c)
+		return ok && darkest < lightest && g < (darkest+lightest)/2
+	}
+}
+
+// outlineColor returns the most common dark color around the edge of extent in
+// img, the color of the outer walls.
+func outlineColor(img image.Image, extent image.Rectangle, dark func(c color.Color) bool) color.Color {
+	counts := map[color.RGBA64]int{}
+	add := func(x, y int) {
+		c := color.RGBA64Model.Convert(img.At(img.Bounds().Min.X+x, img.Bounds().Min.Y+y)).(color.RGBA64)
+		if dark(c) {
+			counts[c]++
+		}
+	}
+	for x := extent.Min.X; x < extent.Max.X; x++ {
+		add(x, extent.Min.Y)
+		add(x, extent.Max.Y-1)
+	}
+	for y := extent.Min.Y; y < extent.Max.Y; y++ {
+		add(extent.Min.X, y)
+		add(extent.Max.X-1, y)
+	}
+
+	var outline color.RGBA64
+	best := 0
+	for c, n := range counts {
+		if n > best || (n == best && colorKey(c) < colorKey(outline)) {
+			outline, best = c, n
+		}
+	}
+	return outline
+}
+
+func colorKey(c color.RGBA64) uint64 {
+	return uint64(c.R)<<48 | uint64(c.G)<<32 | uint64(c.B)<<16 | uint64(c.A)
+}
+
+// near returns whether a color is within wallTolerance of target on every channel.
+func near(target color.Color) func(c color.Color) bool {
+	tr, tg, tb, ta := target.RGBA()
+	return func(c color.Color) bool {
+		r, g, b, a := c.RGBA()
+		diff := func(x, y uint32) bool {
+			return abs(int(x)-int(y)) <= wallTolerance
+		}
+		return diff(r, tr) && diff(g, tg) && diff(b, tb) && diff(a, ta)
+	}
+}
+
+// extent returns the smallest rectangle holding every wall pixel.
+func (w wallMap) extent() (image.Rectangle, bool) {
+	extent := image.Rectangle{}
+	found := false
+	for y := range w.bounds.Dy() {
+		for x := range w.bounds.Dx() {
+			if !w.at(image.Pt(x, y)) {
+				continue
+			}
+			pixel := image.Rect(x, y, x+1, y+1)
+			if found {
+				extent = extent.Union(pixel)
+			} else {
+				extent, found = pixel, true
+			}
+		}
+	}
+	return extent, found
+}
+
+// thickness returns the most common length of the runs of wall pixels along
+// each row. Rows through the middle of cells cross only vertical walls, so their
+// runs are as long as walls are thick.
+func (w wallMap) thickness(extent image.Rectangle) int {
+	counts := map[int]int{}
+	for y := extent.Min.Y; y < extent.Max.Y; y++ {
+		run := 0
+		for x := extent.Min.X; x <= extent.Max.X; x++ {
+			if x < extent.Max.X && w.at(image.Pt(x, y)) {
+				run++
+				continue
+			}
+			if run > 0 {
+				counts[run]++
+			}
+			run = 0
+		}
+	}
+
+	thickness, best := 1, 0
+	for run, n := range counts {
+		if n > best || (n == best && run < thickness) {
+			thickness, best = run, n
+		}
+	}
+	return thickness
+}
+
+// cellSize returns the largest cell size dividing the extent for which nearly
+// all wall pixels lie on the grid lines, or 0 if there is none.
+func (w wallMap) cellSize(extent image.Rectangle, thickness int) int {
+	candidates := []int{}
+	for size := thickness + 1; size <= min(extent.Dx(), extent.Dy()); size++ {
+		if extent.Dx()%size == 0 && extent.Dy()%size == 0 {
+			candidates = append(candidates, size)
+		}
+	}
+	slices.Reverse(candidates)
+
+	for _, size := range candidates {
+		bands := func(length int) []bool {
+			in := make([]bool, length)
+			cells := length / size
+			for edge := 0; edge <= cells; edge++ {
+				start := min(max(edge*size-(thickness+1)/2, 0), cells*size-thickness)
+				for k := start; k < start+thickness; k++ {
+					in[k] = true
+				}
+			}
+			return in
+		}
+		columns, rows := bands(extent.Dx()), bands(extent.Dy())
+
+		total, aligned := 0, 0
+		for y := extent.Min.Y; y < extent.Max.Y; y++ {
+			for x := extent.Min.X; x < extent.Max.X; x++ {
+				if !w.at(image.Pt(x, y)) {
+					continue
+				}
+				total++
+				if columns[x-extent.Min.X] || rows[y-extent.Min.Y] {
+					aligned++
+				}
+			}
+		}
+		if float64(aligned) >= alignedWalls*float64(total) {
+			return size
+		}
+	}
+	return 0
+}
@@ -0,0 +1,212 @@
+package maze_test
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestParseImage(t *testing.T) {
+	testCases := []struct {
+		name     string
+		width    int
+		height   int
+		opts     []GeneratorOptions
+		render   func(*RenderOptions)
+		solution bool
+	}{
+		{name: "default", width: 9, height: 6},
+		{name: "square", width: 5, height: 5, opts: []GeneratorOptions{WithAlgorithm(Backtracker)}},
+		{name: "solution", width: 8, height: 8, solution: true},
+		{
+			name:   "styled",
+			width:  7,
+			height: 4,
+			opts:   []GeneratorOptions{WithFarthestOpenings()},
+			render: func(o *RenderOptions) {
+				o.CellSize, o.WallThickness, o.Margin = 12, 3, 5
+				o.WallColor, o.PassageColor, o.Background = color.RGBA{R: 20, G: 30, B: 80, A: 255}, color.White, color.RGBA{R: 250, G: 245, B: 225, A: 255}
+			},
+			solution: true,
+		},
+		{
+			name:   "round heat map",
+			width:  6,
+			height: 9,
+			render: func(o *RenderOptions) {
+				o.CellSize, o.WallThickness, o.RoundCorners, o.HeatMap = 16, 4, true, true
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mg := NewMazeGenerator(tc.width, tc.height, append(tc.opts, WithSeed(21),
+				WithFilename(filepath.Join(t.TempDir(), "maze.png")))...)
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+			want := mg.Maze()
+
+			render := DefaultRenderOptions()
+			if tc.render != nil {
+				tc.render(&render)
+			}
+			var path []int
+			if tc.solution {
+				path = mg.Solution()
+			}
+			img, err := render.DrawRectangle(want, path)
+			if err != nil {
+				t.Fatalf("(DrawRectangle) unexpected error: %v", err)
+			}
+
+			got, detected, err := ParseImage(img)
+			if err != nil {
+				t.Fatalf("(ParseImage) unexpected error: %v", err)
+			}
+			if got.Width != want.Width || got.Height != want.Height {
+				t.Fatalf("(ParseImage) want %dx%d, got %dx%d", want.Width, want.Height, got.Width, got.Height)
+			}
+			if !reflect.DeepEqual(passages(got), passages(want)) {
+				t.Errorf("(ParseImage) want passages %v, got %v", passages(want), passages(got))
+			}
+			if !reflect.DeepEqual(got.Openings, slices.Sorted(slices.Values(want.Openings))) {
+				t.Errorf("(ParseImage) want openings %v, got %v", want.Openings, got.Openings)
+			}
+			if detected.CellSize != render.CellSize || detected.WallThickness != render.WallThickness || detected.Margin != render.Margin {
+				t.Errorf("(ParseImage) want cell size %d, thickness %d and margin %d, got %d, %d and %d",
+					render.CellSize, render.WallThickness, render.Margin, detected.CellSize, detected.WallThickness, detected.Margin)
+			}
+		})
+	}
+}
+
+// passages returns the cells joined by each passage of r, without the weights.
+func passages(r *Rectangle) [][2]any {
+	pairs := [][2]any{}
+	for _, e := range r.Graph.GetAllEdges() {
+		pairs = append(pairs, [2]any{e.From, e.To})
+	}
+	return pairs
+}
+
+func TestParseImageOptions(t *testing.T) {
+	r := newTestMaze(t)
+	img, err := DrawRectangleMaze(r)
+	if err != nil {
+		t.Fatalf("(DrawRectangleMaze) unexpected error: %v", err)
+	}
+
+	if got, _, err := ParseImage(img, WithCellSize(SquareSize), WithWallThickness(1)); err != nil || got.Width != 3 || got.Height != 2 {
+		t.Errorf("(ParseImage) want a 3x2 maze, got %v (%v)", got, err)
+	}
+	if _, _, err := ParseImage(img, WithCellSize(7)); !errors.Is(err, ErrUnrecognizedImage) {
+		t.Errorf("(ParseImage) want %v for the wrong cell size, got %v", ErrUnrecognizedImage, err)
+	}
+	blank := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	if _, _, err := ParseImage(blank); !errors.Is(err, ErrUnrecognizedImage) {
+		t.Errorf("(ParseImage) want %v for a blank image, got %v", ErrUnrecognizedImage, err)
+	}
+}
+
+func TestParseImageMasked(t *testing.T) {
+	mask, err := ParseMaskText(strings.NewReader("" +
+		"XXXXXXXXXX\n" +
+		"XX......XX\n" +
+		"X........X\n" +
+		"....XX....\n" +
+		"....XX....\n" +
+		"X........X\n" +
+		"XX......XX\n"))
+	if err != nil {
+		t.Fatalf("(ParseMaskText) unexpected error: %v", err)
+	}
+
+	for _, thickness := range []int{1, 2, 3} {
+		t.Run(fmt.Sprintf("thickness %d", thickness), func(t *testing.T) {
+			mg := NewMazeGenerator(0, 0, WithMask(mask), WithSeed(5))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+			want := mg.Maze()
+
+			render := DefaultRenderOptions()
+			render.CellSize, render.WallThickness, render.Margin = 12, thickness, 4
+			img, err := render.DrawRectangle(want, mg.Solution())
+			if err != nil {
+				t.Fatalf("(DrawRectangle) unexpected error: %v", err)
+			}
+
+			if _, _, err := ParseImage(img); !errors.Is(err, ErrUnrecognizedImage) {
+				t.Errorf("(ParseImage) want %v without the mask, got %v", ErrUnrecognizedImage, err)
+			}
+
+			got, detected, err := ParseImage(img, WithImageMask(mask))
+			if err != nil {
+				t.Fatalf("(ParseImage) unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(passages(got), passages(want)) {
+				t.Errorf("(ParseImage) want passages %v, got %v", passages(want), passages(got))
+			}
+			if !reflect.DeepEqual(got.Openings, slices.Sorted(slices.Values(want.Openings))) {
+				t.Errorf("(ParseImage) want openings %v, got %v", want.Openings, got.Openings)
+			}
+			if detected.CellSize != render.CellSize || detected.WallThickness != thickness || detected.Margin != render.Margin {
+				t.Errorf("(ParseImage) want cell size %d, thickness %d and margin %d, got %d, %d and %d",
+					render.CellSize, thickness, render.Margin, detected.CellSize, detected.WallThickness, detected.Margin)
+			}
+			if _, err := BFS.Solve(got, got.Openings[0], got.Openings[len(got.Openings)-1]); err != nil {
+				t.Errorf("(Solve) unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseImageOtherShapes(t *testing.T) {
+	for _, shape := range []Shape{Hex, Triangle, Polar} {
+		t.Run(shape.String(), func(t *testing.T) {
+			mg := NewMazeGenerator(8, 6, WithShape(shape), WithSeed(3))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+			img, err := DrawGridMaze(mg.Grid())
+			if err != nil {
+				t.Fatalf("(DrawGridMaze) unexpected error: %v", err)
+			}
+			if _, _, err := ParseImage(img); !errors.Is(err, ErrUnrecognizedImage) {
+				t.Errorf("(ParseImage) want %v, got %v", ErrUnrecognizedImage, err)
+			}
+		})
+	}
+}
+
+func TestParseImageWoven(t *testing.T) {
+	mg := NewMazeGenerator(10, 8, WithSeed(7), WithWeave(0.6))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if len(mg.Maze().Crossings()) == 0 {
+		t.Fatal("(Generate) want a maze with crossings")
+	}
+	render := DefaultRenderOptions()
+	img, err := render.DrawRectangle(mg.Maze(), nil)
+	if err != nil {
+		t.Fatalf("(DrawRectangle) unexpected error: %v", err)
+	}
+
+	for _, opts := range [][]ImageOption{nil, {WithCellSize(render.CellSize)}} {
+		_, _, err := ParseImage(img, opts...)
+		if !errors.Is(err, ErrUnrecognizedImage) || !strings.Contains(err.Error(), "woven") {
+			t.Errorf("(ParseImage) want %v for a woven maze, got %v", ErrUnrecognizedImage, err)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package maze
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	textPlayerMark = "@"
+	textHintMark   = "+"
+	textTrailMark  = "·"
+)
+
+// Direction is a step from a cell of a rectangular maze to its neighbor.
+type Direction int
+
+const (
+	North Direction = iota
+	East
+	South
+	West
+)
+
+// Game is a walk through a rectangular maze from its entrance to its exit, one
+// step at a time.
+type Game struct {
+	maze     *Rectangle
+	entrance int
+	exit     int
+	position int
+	moves    int
+	visited  map[int]bool
+	hint     int   // the next step toward the exit, or -1 when not asked for
+	solution []int // drawn once the player gives up
+}
+
+// NewGame starts a walk through r from entrance to exit.
+func NewGame(r *Rectangle, entrance, exit int) *Game {
+	return &Game{
+		maze:     r,
+		entrance: entrance,
+		exit:     exit,
+		position: entrance,
+		visited:  map[int]bool{entrance: true},
+		hint:     -1,
+	}
+}
+
+// Game starts a walk through the generated maze. Only square mazes on a single
+// floor can be played.
+func (mg *MazeGenerator) Game() (*Game, error) {
+	if mg.grid != nil || mg.box != nil || mg.rect == nil {
+		return nil, fmt.Errorf("%w: only square mazes on one floor can be played", ErrUnsupportedShape)
+	}
+	return NewGame(mg.rect, mg.entrance, mg.exit), nil
+}
+
+// Position returns the cell the player is in.
+func (g *Game) Position() int {
+	return g.position
+}
+
+// Moves returns the number of steps taken.
+func (g *Game) Moves() int {
+	return g.moves
+}
+
+// Won reports whether the player has reached the exit.
+func (g *Game) Won() bool {
+	return g.position == g.exit
+}
+
+// GaveUp reports whether the player has asked for the solution.
+func (g *Game) GaveUp() bool {
+	return g.solution != nil
+}
+
+// Over reports whether the player can no longer move.
+func (g *Game) Over() bool {
+	return g.Won() || g.GaveUp()
+}
+
+// Move steps in direction d and reports whether there was a passage to step
+// through. The player cannot move once the game is over.
+func (g *Game) Move(d Direction) bool {
+	if g.Over() {
+		return false
+	}
+	i, j := g.maze.GraphToRect(g.position)
+	di, dj := 0, 0
+	switch d {
+	case North:
+		di = -1
+	case East:
+		dj = 1
+	case South:
+		di = 1
+	case West:
+		dj = -1
+	}
+	next, ok := g.step(i+di, j+dj)
+	if !ok {
+		// In a woven maze the step may dive under a crossing to the cell beyond.
+		next, ok = g.step(i+2*di, j+2*dj)
+	}
+	if !ok {
+		return false
+	}
+
+	g.position = next
+	g.moves++
+	g.visited[next] = true
+	g.hint = -1
+	return true
+}
+
+// step returns the cell in row i and column j and whether a passage joins it to
+// the player's.
+func (g *Game) step(i, j int) (int, bool) {
+	if g.maze.outside(i, j) {
+		return 0, false
+	}
+	next := g.maze.RectToGraph(i, j)
+	_, ok := g.maze.Graph.GetEdge(g.position, next)
+	return next, ok
+}
+
+// Hint returns the next cell on the shortest path from the player to the exit,
+// and marks it until the player moves.
+func (g *Game) Hint() (int, error) {
+	path, err := BFS.Solve(g.maze, g.position, g.exit)
+	if err != nil {
+		return 0, err
+	}
+	g.hint = path[min(1, len(path)-1)]
+	return g.hint, nil
+}
+
+// GiveUp ends the game, showing the whole solution from the entrance to the exit.
+func (g *Game) GiveUp() error {
+	path, err := BFS.Solve(g.maze, g.entrance, g.exit)
+	if err != nil {
+		return err
+	}
+	g.solution = path
+	g.hint = -1
+	return nil
+}
+
+// Render draws the maze as EncodeText does, marking the player, the cells
+// visited, the hint and, after giving up, the solution.
+func (g *Game) Render(w io.Writer) error {
+	onSolution := make(map[int]bool, len(g.solution))
+	for _, cell := range g.solution {
+		onSolution[cell] = true
+	}
+	return encodeText(w, g.maze, func(cell int) string {
+		switch {
+		case cell == g.position:
+			return textPlayerMark
+		case cell == g.hint:
+			return textHintMark
+		case onSolution[cell]:
+			return textSolutionMark
+		case g.visited[cell]:
+			return textTrailMark
+		}
+		return " "
+	})
+}
@@ -0,0 +1,132 @@
+package maze_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestGameMove(t *testing.T) {
+	testCases := []struct {
+		name      string
+		moves     []Direction
+		wantOK    []bool
+		wantCell  int
+		wantMoves int
+		wantWon   bool
+	}{
+		{name: "into a wall", moves: []Direction{North, West, South}, wantOK: []bool{false, false, true}, wantCell: 3, wantMoves: 1},
+		{name: "back and forth", moves: []Direction{East, East, West}, wantOK: []bool{true, false, true}, wantCell: 0, wantMoves: 2},
+		{name: "to the exit", moves: []Direction{South, East, East, North}, wantOK: []bool{true, true, true, false}, wantCell: 5, wantMoves: 3, wantWon: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewGame(newTestMaze(t), 0, 5)
+			for k, d := range tc.moves {
+				if ok := g.Move(d); ok != tc.wantOK[k] {
+					t.Errorf("(Move) want move %d to return %t, got %t", k, tc.wantOK[k], ok)
+				}
+			}
+			if g.Position() != tc.wantCell || g.Moves() != tc.wantMoves || g.Won() != tc.wantWon {
+				t.Errorf("(Move) want cell %d after %d moves, won %t; got cell %d after %d, won %t",
+					tc.wantCell, tc.wantMoves, tc.wantWon, g.Position(), g.Moves(), g.Won())
+			}
+		})
+	}
+}
+
+func TestGameHint(t *testing.T) {
+	g := NewGame(newTestMaze(t), 0, 5)
+	g.Move(East)
+
+	// The way out of the dead end is back through the entrance.
+	if next, err := g.Hint(); err != nil || next != 0 {
+		t.Errorf("(Hint) want 0, got %d (%v)", next, err)
+	}
+	var sb strings.Builder
+	if err := g.Render(&sb); err != nil {
+		t.Fatalf("(Render) unexpected error: %v", err)
+	}
+	want := "" +
+		"╷   ╶───┬───┐\n" +
+		"│ +   @ │   │\n" +
+		"│   ╶───┘   │\n" +
+		"│           │\n" +
+		"└───────╴   ╵\n"
+	if sb.String() != want {
+		t.Errorf("(Render) want:\n%s\ngot:\n%s", want, sb.String())
+	}
+
+	// Moving clears the hint and leaves a trail.
+	g.Move(West)
+	g.Move(South)
+	sb.Reset()
+	g.Render(&sb)
+	if strings.Contains(sb.String(), "+") || strings.Count(sb.String(), "·") != 2 {
+		t.Errorf("(Render) want a trail of 2 cells and no hint, got:\n%s", sb.String())
+	}
+}
+
+func TestGameGiveUp(t *testing.T) {
+	g := NewGame(newTestMaze(t), 0, 5)
+	g.Move(East)
+	g.Hint()
+	if err := g.GiveUp(); err != nil {
+		t.Fatalf("(GiveUp) unexpected error: %v", err)
+	}
+	if !g.Over() || g.Won() {
+		t.Errorf("(GiveUp) want the game over and not won")
+	}
+	if g.Move(West) {
+		t.Errorf("(Move) want no moves after giving up")
+	}
+
+	var sb strings.Builder
+	g.Render(&sb)
+	want := "" +
+		"╷   ╶───┬───┐\n" +
+		"│ •   @ │   │\n" +
+		"│   ╶───┘   │\n" +
+		"│ •   •   • │\n" +
+		"└───────╴   ╵\n"
+	if sb.String() != want {
+		t.Errorf("(Render) want:\n%s\ngot:\n%s", want, sb.String())
+	}
+}
+
+func TestGeneratorGame(t *testing.T) {
+	mg := NewMazeGenerator(6, 5, WithSeed(2))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	g, err := mg.Game()
+	if err != nil {
+		t.Fatalf("(Game) unexpected error: %v", err)
+	}
+
+	// Following the hints leads out along the solution.
+	steps := map[int]Direction{-6: North, 1: East, 6: South, -1: West}
+	for !g.Won() && g.Moves() < 30 {
+		next, err := g.Hint()
+		if err != nil {
+			t.Fatalf("(Hint) unexpected error: %v", err)
+		}
+		if !g.Move(steps[next-g.Position()]) {
+			t.Fatalf("(Hint) want a passage from %d to %d", g.Position(), next)
+		}
+	}
+	if !g.Won() || g.Moves() != len(mg.Solution())-1 {
+		t.Errorf("(Hint) want the hints to reach the exit in %d moves, got %d", len(mg.Solution())-1, g.Moves())
+	}
+
+	hex := NewMazeGenerator(4, 4, WithShape(Hex))
+	if err := hex.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if _, err := hex.Game(); !errors.Is(err, ErrUnsupportedShape) {
+		t.Errorf("(Game) want %v, got %v", ErrUnsupportedShape, err)
+	}
+}
@@ -0,0 +1,102 @@
+package maze
+
+import (
+	"image"
+	"iter"
+	"math"
+	"slices"
+)
+
+// polarArcStep is the longest straight segment used to draw an arc.
+const polarArcStep = 4.0
+
+// polarRingCounts yields the number of cells in each ring of a polar maze, from
+// the center out.
+func polarRingCounts(rings int) iter.Seq[int] {
+	return func(yield func(int) bool) {
+		count := 1
+		for ring := range rings {
+			if ring > 0 {
+				circumference := 2 * math.Pi * float64(ring) * SquareSize
+				count *= max(int(math.Round(circumference/float64(count)/SquareSize)), 1)
+			}
+			if !yield(count) {
+				return
+			}
+		}
+	}
+}
+
+// polarCells lays out rings of cells SquareSize deep around a single center cell.
+// Each ring splits the cells of the one inside it so that cells stay roughly
+// SquareSize wide. Cells are numbered ring by ring, clockwise from the east. Sides
+// run inner arc, clockwise radius, outer arcs, counter-clockwise radius.
+func polarCells(rings int) ([]gridCell, Point) {
+	depth := float64(SquareSize)
+	origin := Point{X: depth * float64(rings), Y: depth * float64(rings)}
+
+	counts := slices.Collect(polarRingCounts(rings))
+	starts := make([]int, rings)
+	for ring := 1; ring < rings; ring++ {
+		starts[ring] = starts[ring-1] + counts[ring-1]
+	}
+
+	at := func(radius, angle float64) Point {
+		return Point{origin.X + radius*math.Cos(angle), origin.Y + radius*math.Sin(angle)}
+	}
+	arc := func(radius, from, to float64) []Point {
+		segments := 2 * max(int(math.Ceil(radius*math.Abs(to-from)/(2*polarArcStep))), 1)
+		points := make([]Point, segments+1)
+		for k := range points {
+			points[k] = at(radius, from+(to-from)*float64(k)/float64(segments))
+		}
+		return points
+	}
+	angle := func(ring, k int) float64 {
+		return 2 * math.Pi * float64(k) / float64(counts[ring])
+	}
+
+	// outerEdges are the arcs on the outside of cell k, one per cell of the next
+	// ring that borders it, or a single border arc on the last ring.
+	outerEdges := func(ring, k int) []gridEdge {
+		radius := float64(ring+1) * depth
+		if ring == rings-1 {
+			return []gridEdge{{points: arc(radius, angle(ring, k+1), angle(ring, k)), neighbor: -1}}
+		}
+		ratio := counts[ring+1] / counts[ring]
+		edges := []gridEdge{}
+		for child := k*ratio + ratio - 1; child >= k*ratio; child-- {
+			edges = append(edges, gridEdge{
+				points:   arc(radius, angle(ring+1, child+1), angle(ring+1, child)),
+				neighbor: starts[ring+1] + child,
+			})
+		}
+		return edges
+	}
+
+	cells := make([]gridCell, 0, starts[rings-1]+counts[rings-1])
+	cells = append(cells, gridCell{
+		position: image.Pt(0, 0),
+		center:   origin,
+		edges:    outerEdges(0, 0),
+	})
+	for ring := 1; ring < rings; ring++ {
+		n := counts[ring]
+		inner, outer := float64(ring)*depth, float64(ring+1)*depth
+		ratio := n / counts[ring-1]
+		for k := range n {
+			from, to := angle(ring, k), angle(ring, k+1)
+			edges := []gridEdge{{points: arc(inner, from, to), neighbor: starts[ring-1] + k/ratio}}
+			edges = append(edges, gridEdge{points: []Point{at(inner, to), at(outer, to)}, neighbor: starts[ring] + (k+1)%n})
+			edges = append(edges, outerEdges(ring, k)...)
+			edges = append(edges, gridEdge{points: []Point{at(outer, from), at(inner, from)}, neighbor: starts[ring] + (k+n-1)%n})
+			cells = append(cells, gridCell{
+				position: image.Pt(k, ring),
+				center:   at((inner+outer)/2, (from+to)/2),
+				edges:    edges,
+			})
+		}
+	}
+
+	return cells, Point{X: 2 * origin.X, Y: 2 * origin.Y}
+}
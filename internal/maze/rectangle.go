@@ -1,7 +1,9 @@
 package maze
 
 import (
-	"math/rand"
+	"fmt"
+	"image"
+	"math/rand/v2"
 
 	"tsumegolang/pkg/ds/graph/sparsegraph"
 )
@@ -19,14 +21,59 @@ const (
 )
 
 type Rectangle struct {
-	Width  int
-	Height int
-	Graph  *sparsegraph.Graph
+	Width    int
+	Height   int
+	Graph    *sparsegraph.Graph
+	Openings []int       // border cells whose outer wall is open, such as the entrance and exit
+	Mask     *Mask       // cells left out of the maze, which have no edges; nil if none are
+	Block    image.Point // size in cells of the smallest sub-mazes of a hierarchical maze; zero otherwise
+}
+
+type RectangleOption func(*rectangleConfig)
+
+type rectangleConfig struct {
+	rng     *rand.Rand
+	mask    *Mask
+	weights WeightFunc
+}
+
+// WithRand sets the source of random edge weights, making ConnectRandom reproducible.
+func WithRand(rng *rand.Rand) RectangleOption {
+	return func(c *rectangleConfig) {
+		c.rng = rng
+	}
+}
+
+// WithExcluded leaves the cells excluded by mask, which must be the same size as
+// the rectangle, out of the graph.
+func WithExcluded(mask *Mask) RectangleOption {
+	return func(c *rectangleConfig) {
+		c.mask = mask
+	}
+}
+
+// WithWeightFunc scales the weight of every edge by f, biasing the mazes carved
+// from the rectangle by Kruskal and Prim.
+func WithWeightFunc(f WeightFunc) RectangleOption {
+	return func(c *rectangleConfig) {
+		c.weights = f
+	}
 }
 
 // NewRectangle generates a rectangular, undirected graph with the specified width and height,
 // where each vertex connects to its north, east, south, and west neighbors.
-func NewRectangle(width, height int, init EdgeInit) (*Rectangle, error) {
+func NewRectangle(width, height int, init EdgeInit, opts ...RectangleOption) (*Rectangle, error) {
+	config := rectangleConfig{}
+	for _, opt := range opts {
+		opt(&config)
+	}
+	if config.rng == nil {
+		config.rng = newRand(rand.Uint64())
+	}
+	if width < 1 || height < 1 {
+		return nil, sparsegraph.ErrInvalidConfiguration
+	}
+
 	g, err := sparsegraph.NewGraph(width*height, false)
 	if err != nil {
 		return nil, err
@@ -36,6 +83,10 @@ func NewRectangle(width, height int, init EdgeInit) (*Rectangle, error) {
 		Width:  width,
 		Height: height,
 		Graph:  g,
+		Mask:   config.mask,
+	}
+	if r.Mask != nil && (r.Mask.Width != width || r.Mask.Height != height) {
+		return nil, fmt.Errorf("%w: %dx%d mask for a %dx%d rectangle", ErrInvalidMask, r.Mask.Width, r.Mask.Height, width, height)
 	}
 
 	if init == NoConnect {
@@ -45,20 +96,29 @@ func NewRectangle(width, height int, init EdgeInit) (*Rectangle, error) {
 	for y := range height {
 		for x := range width {
 			current := y*width + x
+			if !r.Included(current) {
+				continue
+			}
 
 			// Since the graph is undirected, we only need to consider the east and south neighbors.
-			if x < width-1 { // East neighbor
+			if x < width-1 && r.Included(current+1) { // East neighbor
 				east := current + 1
-				weight := getNewWeight(init)
+				weight := getNewWeight(init, config.rng)
+				if config.weights != nil {
+					weight *= config.weights(x, y, East)
+				}
 				err = r.Graph.Connect(current, east, weight)
 				if err != nil {
 					return nil, err
 				}
 			}
 
-			if y < height-1 { // South neighbor
+			if y < height-1 && r.Included(current+width) { // South neighbor
 				south := current + width
-				weight := getNewWeight(init)
+				weight := getNewWeight(init, config.rng)
+				if config.weights != nil {
+					weight *= config.weights(x, y, South)
+				}
 				err = r.Graph.Connect(current, south, weight)
 				if err != nil {
 					return nil, err
@@ -70,17 +130,99 @@ func NewRectangle(width, height int, init EdgeInit) (*Rectangle, error) {
 	return r, nil
 }
 
-func getNewWeight(method EdgeInit) float64 {
+func getNewWeight(method EdgeInit, rng *rand.Rand) float64 {
 	switch method {
 	case ConnectConst:
 		return DefaultWeight
 	case ConnectRandom:
-		return rand.Float64()
+		return rng.Float64()
 	}
 
 	return 0
 }
 
+// newRand returns a generator whose sequence is fully determined by seed.
+func newRand(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
 func (r *Rectangle) RectToGraph(i, j int) int {
 	return i*r.Width + j
 }
+
+// GraphToRect is the inverse of RectToGraph.
+func (r *Rectangle) GraphToRect(n int) (int, int) {
+	return n / r.Width, n % r.Width
+}
+
+// blockRow reports whether the row of walls above cell row y lies between the
+// sub-mazes of a hierarchical maze.
+func (r *Rectangle) blockRow(y int) bool {
+	return r.Block.Y > 0 && y > 0 && y < r.Height && y%r.Block.Y == 0
+}
+
+// blockColumn reports whether the column of walls left of cell column x lies
+// between the sub-mazes of a hierarchical maze.
+func (r *Rectangle) blockColumn(x int) bool {
+	return r.Block.X > 0 && x > 0 && x < r.Width && x%r.Block.X == 0
+}
+
+// Included reports whether cell n is part of the maze, rather than left out by the mask.
+func (r *Rectangle) Included(n int) bool {
+	if r.Mask == nil {
+		return true
+	}
+	i, j := r.GraphToRect(n)
+	return !r.Mask.Excluded(j, i)
+}
+
+// outside reports whether the cell at row i, column j is beyond the edge of the
+// maze: off the rectangle or left out by the mask.
+func (r *Rectangle) outside(i, j int) bool {
+	if i < 0 || j < 0 || i >= r.Height || j >= r.Width {
+		return true
+	}
+	return !r.Included(r.RectToGraph(i, j))
+}
+
+// IsBorder reports whether cell n lies on the outer edge of the maze, next to the
+// edge of the rectangle or a cell left out by the mask.
+func (r *Rectangle) IsBorder(n int) bool {
+	if !r.Included(n) {
+		return false
+	}
+	i, j := r.GraphToRect(n)
+	return r.outside(i-1, j) || r.outside(i, j+1) || r.outside(i+1, j) || r.outside(i, j-1)
+}
+
+// BorderCells lists the cells on the outer edge, clockwise from the top-left corner.
+// Masked mazes list them row by row.
+func (r *Rectangle) BorderCells() []int {
+	cells := []int{}
+	if r.Mask != nil {
+		for n := range r.Width * r.Height {
+			if r.IsBorder(n) {
+				cells = append(cells, n)
+			}
+		}
+		return cells
+	}
+
+	for j := range r.Width {
+		cells = append(cells, r.RectToGraph(0, j))
+	}
+	for i := 1; i < r.Height; i++ {
+		cells = append(cells, r.RectToGraph(i, r.Width-1))
+	}
+	if r.Height > 1 {
+		for j := r.Width - 2; j >= 0; j-- {
+			cells = append(cells, r.RectToGraph(r.Height-1, j))
+		}
+	}
+	if r.Width > 1 {
+		for i := r.Height - 2; i > 0; i-- {
+			cells = append(cells, r.RectToGraph(i, 0))
+		}
+	}
+	return cells
+}
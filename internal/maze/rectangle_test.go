@@ -1,8 +1,11 @@
 package maze_test
 
 import (
+	"errors"
 	"testing"
 
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+
 	. "tsumegolang/internal/maze"
 )
 
@@ -169,3 +172,26 @@ func TestRectToGraph(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateInvalidSize(t *testing.T) {
+	testCases := []struct {
+		name   string
+		width  int
+		height int
+	}{
+		{name: "zero width", width: 0, height: 20},
+		{name: "zero height", width: 20, height: 0},
+		{name: "negative", width: -1, height: -1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewRectangle(tc.width, tc.height, NoConnect); !errors.Is(err, sparsegraph.ErrInvalidConfiguration) {
+				t.Errorf("(NewRectangle) want %v, got %v", sparsegraph.ErrInvalidConfiguration, err)
+			}
+			if err := NewMazeGenerator(tc.width, tc.height).Generate(); !errors.Is(err, sparsegraph.ErrInvalidConfiguration) {
+				t.Errorf("(Generate) want %v, got %v", sparsegraph.ErrInvalidConfiguration, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+var (
+	ErrInvalidRender = errors.New("invalid render options")
+	ErrInvalidColor  = errors.New("invalid color")
+)
+
+// RenderOptions controls how mazes are drawn as PNG and SVG.
+type RenderOptions struct {
+	CellSize      int         // width of a square cell in pixels; other shapes scale to match
+	WallThickness int         // in pixels
+	Margin        int         // space around the maze in pixels
+	WallColor     color.Color //
+	PassageColor  color.Color // fill of the cells in the maze
+	Background    color.Color // fill of the margin and of cells left out by a mask
+	SolutionColor color.Color //
+	RoundCorners  bool        // round the ends and joins of walls and the solution
+	HeatMap       bool        // shade cells from HeatNear to HeatFar by their distance from the entrance
+	HeatNear      color.Color //
+	HeatFar       color.Color //
+	BlockColor    color.Color // walls between the sub-mazes of a hierarchical maze; nil draws them as other walls
+
+	overlay map[int]color.Color // fills replacing those of individual cells, for animation frames
+}
+
+// DefaultRenderOptions returns the options used by DrawRectangleMaze, EncodeSVG and
+// the other drawing functions that take none.
+func DefaultRenderOptions() RenderOptions {
+	return RenderOptions{
+		CellSize:      SquareSize,
+		WallThickness: 1,
+		WallColor:     color.Black,
+		PassageColor:  color.White,
+		Background:    color.White,
+		SolutionColor: SolutionColor,
+		HeatNear:      color.RGBA{R: 255, G: 236, B: 160, A: 255},
+		HeatFar:       color.RGBA{R: 70, G: 30, B: 130, A: 255},
+	}
+}
+
+// Validate checks that the sizes can be drawn and every color is set.
+func (o RenderOptions) Validate() error {
+	if o.CellSize < 2 {
+		return fmt.Errorf("%w: cell size %d is less than 2", ErrInvalidRender, o.CellSize)
+	}
+	if o.WallThickness < 1 || o.WallThickness >= o.CellSize {
+		return fmt.Errorf("%w: wall thickness %d must be at least 1 and less than the cell size", ErrInvalidRender, o.WallThickness)
+	}
+	if o.Margin < 0 {
+		return fmt.Errorf("%w: margin %d is negative", ErrInvalidRender, o.Margin)
+	}
+	for _, c := range []color.Color{o.WallColor, o.PassageColor, o.Background, o.SolutionColor, o.HeatNear, o.HeatFar} {
+		if c == nil {
+			return fmt.Errorf("%w: missing color", ErrInvalidRender)
+		}
+	}
+	return nil
+}
+
+// wallColor returns the color of a wall, which may lie between sub-mazes.
+func (o RenderOptions) wallColor(block bool) color.Color {
+	if block && o.BlockColor != nil {
+		return o.BlockColor
+	}
+	return o.WallColor
+}
+
+// solutionThickness is the width of the solution line in pixels.
+func (o RenderOptions) solutionThickness() int {
+	return max(o.CellSize/5, 1)
+}
+
+// cellColors returns the fill of every cell, shading them by distance from start
+// when the heat map is on. Cells left out by a mask are not drawn.
+func (o RenderOptions) cellColors(adj [][]int, start int) []color.Color {
+	colors := make([]color.Color, len(adj))
+	for cell := range colors {
+		colors[cell] = o.PassageColor
+	}
+	if o.HeatMap && len(adj) > 0 {
+		dist := distances(adj, start)
+		farthest := max(1, maxOf(dist))
+		for cell, d := range dist {
+			if d >= 0 {
+				colors[cell] = lerpColor(o.HeatNear, o.HeatFar, float64(d)/float64(farthest))
+			}
+		}
+	}
+	for cell, c := range o.overlay {
+		colors[cell] = c
+	}
+	return colors
+}
+
+func maxOf(values []int) int {
+	m := 0
+	for _, v := range values {
+		m = max(m, v)
+	}
+	return m
+}
+
+// lerpColor mixes a and b, giving a when t is 0 and b when t is 1.
+func lerpColor(a, b color.Color, t float64) color.RGBA {
+	ca := color.RGBAModel.Convert(a).(color.RGBA)
+	cb := color.RGBAModel.Convert(b).(color.RGBA)
+	mix := func(x, y uint8) uint8 {
+		return uint8(float64(x) + (float64(y)-float64(x))*t + 0.5)
+	}
+	return color.RGBA{R: mix(ca.R, cb.R), G: mix(ca.G, cb.G), B: mix(ca.B, cb.B), A: mix(ca.A, cb.A)}
+}
+
+func sameColor(a, b color.Color) bool {
+	return color.RGBAModel.Convert(a) == color.RGBAModel.Convert(b)
+}
+
+var namedColors = map[string]color.Color{
+	"black":       color.Black,
+	"white":       color.White,
+	"transparent": color.Transparent,
+	"red":         color.RGBA{R: 220, G: 40, B: 40, A: 255},
+	"green":       color.RGBA{R: 40, G: 160, B: 60, A: 255},
+	"blue":        color.RGBA{R: 40, G: 80, B: 220, A: 255},
+	"gray":        color.RGBA{R: 128, G: 128, B: 128, A: 255},
+	"navy":        color.RGBA{R: 20, G: 30, B: 80, A: 255},
+	"cream":       color.RGBA{R: 250, G: 245, B: 225, A: 255},
+}
+
+// ParseColor reads a color written as #rgb, #rrggbb or #rrggbbaa, or by one of
+// the names black, white, transparent, red, green, blue, gray, navy and cream.
+func ParseColor(s string) (color.Color, error) {
+	if c, ok := namedColors[strings.ToLower(s)]; ok {
+		return c, nil
+	}
+
+	hex, ok := strings.CutPrefix(s, "#")
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+	if len(hex) == 3 {
+		hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+
+	var c color.NRGBA
+	if len(hex) != 8 {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+	if _, err := fmt.Sscanf(hex, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A); err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+	return c, nil
+}
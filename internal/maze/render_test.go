@@ -0,0 +1,152 @@
+package maze_test
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"image"
+	"image/color"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestParseColor(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    color.Color
+		wantErr error
+	}{
+		{input: "black", want: color.Black},
+		{input: "White", want: color.White},
+		{input: "#f80", want: color.NRGBA{R: 0xff, G: 0x88, B: 0x00, A: 0xff}},
+		{input: "#1a2b3c", want: color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0xff}},
+		{input: "#1a2b3c80", want: color.NRGBA{R: 0x1a, G: 0x2b, B: 0x3c, A: 0x80}},
+		{input: "1a2b3c", wantErr: ErrInvalidColor},
+		{input: "#12345", wantErr: ErrInvalidColor},
+		{input: "#gggggg", wantErr: ErrInvalidColor},
+		{input: "mauve", wantErr: ErrInvalidColor},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseColor(tc.input)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(ParseColor) want %v, got %v", tc.wantErr, err)
+			}
+			if err == nil && color.RGBAModel.Convert(got) != color.RGBAModel.Convert(tc.want) {
+				t.Errorf("(ParseColor) want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderOptionsValidate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		change  func(*RenderOptions)
+		wantErr error
+	}{
+		{name: "defaults", change: func(*RenderOptions) {}},
+		{name: "tiny cells", change: func(o *RenderOptions) { o.CellSize = 1 }, wantErr: ErrInvalidRender},
+		{name: "walls fill the cell", change: func(o *RenderOptions) { o.WallThickness = o.CellSize }, wantErr: ErrInvalidRender},
+		{name: "negative margin", change: func(o *RenderOptions) { o.Margin = -1 }, wantErr: ErrInvalidRender},
+		{name: "missing color", change: func(o *RenderOptions) { o.WallColor = nil }, wantErr: ErrInvalidRender},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultRenderOptions()
+			tc.change(&o)
+			if err := o.Validate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Validate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestDrawRectangleWithOptions(t *testing.T) {
+	r := newTestMaze(t)
+	o := DefaultRenderOptions()
+	o.CellSize = 30
+	o.WallThickness = 4
+	o.Margin = 10
+	o.WallColor = color.RGBA{B: 128, A: 255}
+	o.Background = color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	o.HeatMap = true
+
+	m, err := o.DrawRectangle(r, []int{0, 3, 4, 5})
+	if err != nil {
+		t.Fatalf("(DrawRectangle) unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		name string
+		p    image.Point
+		want color.Color
+	}{
+		{name: "margin", p: image.Pt(3, 3), want: o.Background},
+		{name: "outer wall", p: image.Pt(10+45, 11), want: o.WallColor},
+		{name: "inner wall", p: image.Pt(10+60, 10+15), want: o.WallColor},
+		{name: "entrance shaded near", p: image.Pt(10+25, 10+25), want: o.HeatNear},
+		{name: "far cell shaded far", p: image.Pt(10+65, 10+5), want: o.HeatFar},
+		{name: "solution", p: image.Pt(10+15, 10+30), want: o.SolutionColor},
+	}
+	if got := m.Bounds().Size(); got != image.Pt(3*30+20, 2*30+20) {
+		t.Errorf("(DrawRectangle) want a 110x80 image, got %v", got)
+	}
+	for _, tc := range testCases {
+		if got := m.At(tc.p.X, tc.p.Y); color.RGBAModel.Convert(got) != color.RGBAModel.Convert(tc.want) {
+			t.Errorf("(DrawRectangle) %s: want %v at %v, got %v", tc.name, tc.want, tc.p, got)
+		}
+	}
+
+	o.WallThickness = 0
+	if _, err := o.DrawRectangle(r, nil); !errors.Is(err, ErrInvalidRender) {
+		t.Errorf("(DrawRectangle) want %v, got %v", ErrInvalidRender, err)
+	}
+}
+
+func TestEncodeSVGWithOptions(t *testing.T) {
+	o := DefaultRenderOptions()
+	o.CellSize = 10
+	o.WallThickness = 3
+	o.Margin = 5
+	o.RoundCorners = true
+	o.HeatMap = true
+
+	grid, _ := NewGrid(Hex, 3, 2, ConnectConst)
+	for name, encode := range map[string]func(*bytes.Buffer) error{
+		"rectangle": func(buf *bytes.Buffer) error { return o.EncodeSVG(buf, newTestMaze(t), nil, nil) },
+		"grid":      func(buf *bytes.Buffer) error { return o.EncodeGridSVG(buf, grid, nil, nil) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encode(&buf); err != nil {
+				t.Fatalf("(EncodeSVG) unexpected error: %v", err)
+			}
+			var doc struct {
+				ViewBox string `xml:"viewBox,attr"`
+				Rects   []struct {
+					Fill string `xml:"fill,attr"`
+				} `xml:"rect"`
+				Paths []struct {
+					Fill   string `xml:"fill,attr"`
+					Stroke string `xml:"stroke-width,attr"`
+				} `xml:"path"`
+			}
+			if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+				t.Fatalf("(EncodeSVG) invalid XML: %v", err)
+			}
+
+			// Six cells filled by distance from the entrance, plus the background.
+			if fills := len(doc.Rects) + len(doc.Paths) - 1; fills != 1+6 {
+				t.Errorf("(EncodeSVG) want the background and 6 shaded cells, got %d shapes", fills)
+			}
+			walls := doc.Paths[len(doc.Paths)-1]
+			if walls.Fill != "none" || walls.Stroke != "3" {
+				t.Errorf("(EncodeSVG) want 3 unit walls last, got %+v", walls)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package maze
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+var (
+	ErrInvalidRequest = errors.New("invalid maze request")
+)
+
+const (
+	DefaultMaxCells       = 250_000 // largest maze a Server generates, in cells
+	DefaultRequestTimeout = 10 * time.Second
+	DefaultCacheSize      = 256 // responses kept by a Server
+)
+
+var contentTypes = map[Format]string{
+	PNG:  "image/png",
+	SVG:  "image/svg+xml",
+	Text: "text/plain; charset=utf-8",
+	JSON: "application/json",
+}
+
+// Server serves mazes over HTTP at /maze.png, /maze.svg, /maze.txt and
+// /maze.json, generated with MazeGenerator from the query parameters:
+//
+//	w, h      width and height in cells, 20 by default
+//	seed      seed of the maze; without one every response is a new maze
+//	algo      generation algorithm, kruskal by default
+//	shape     maze shape, square by default
+//	braid     fraction of dead ends opened into loops
+//	solution  true to draw the solution
+//
+// The seed is returned in the X-Maze-Seed header. Responses to requests with a
+// seed are cached by their parameters.
+type Server struct {
+	maxCells      int
+	timeout       time.Duration
+	cache         *responseCache
+	maxGenerating int
+	generating    chan struct{} // holds a token for each maze being generated
+}
+
+type ServerOptions func(*Server)
+
+func NewServer(opts ...ServerOptions) *Server {
+	s := &Server{
+		maxCells:      DefaultMaxCells,
+		timeout:       DefaultRequestTimeout,
+		cache:         newResponseCache(DefaultCacheSize),
+		maxGenerating: runtime.GOMAXPROCS(0),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.generating = make(chan struct{}, max(s.maxGenerating, 1))
+
+	return s
+}
+
+// WithMaxCells refuses requests for mazes of more than n cells, counted for the
+// shape asked for.
+func WithMaxCells(n int) ServerOptions {
+	return func(s *Server) {
+		s.maxCells = n
+	}
+}
+
+// WithRequestTimeout answers requests whose maze is not ready after timeout
+// with 503 Service Unavailable, and stops generating it.
+func WithRequestTimeout(timeout time.Duration) ServerOptions {
+	return func(s *Server) {
+		s.timeout = timeout
+	}
+}
+
+// WithMaxGenerating generates at most n mazes at once, by default one per CPU.
+// Other requests wait for their turn until they time out.
+func WithMaxGenerating(n int) ServerOptions {
+	return func(s *Server) {
+		s.maxGenerating = n
+	}
+}
+
+// WithCacheSize keeps the n most recently used responses; 0 caches none.
+func WithCacheSize(n int) ServerOptions {
+	return func(s *Server) {
+		s.cache = newResponseCache(n)
+	}
+}
+
+// mazeRequest is a request for a maze, parsed from its URL.
+type mazeRequest struct {
+	format    Format
+	width     int
+	height    int
+	seed      uint64
+	seeded    bool
+	algorithm Algorithm
+	shape     Shape
+	braid     float64
+	solution  bool
+}
+
+// key identifies the response to r in the cache.
+func (r mazeRequest) key() string {
+	return fmt.Sprintf("%v %dx%d %d %v %v %g %t", r.format, r.width, r.height, r.seed, r.algorithm, r.shape, r.braid, r.solution)
+}
+
+type mazeResponse struct {
+	body []byte
+	seed uint64
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	ext, ok := strings.CutPrefix(req.URL.Path, "/maze.")
+	format, err := ParseFormat(ext)
+	if !ok || err != nil {
+		http.NotFound(w, req)
+		return
+	}
+
+	r, err := s.parseRequest(req, format)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var resp mazeResponse
+	cached := false
+	if r.seeded {
+		resp, cached = s.cache.get(r.key())
+	}
+	if !cached {
+		ctx, cancel := context.WithTimeout(req.Context(), s.timeout)
+		defer cancel()
+		if resp, err = s.generate(ctx, r); err != nil {
+			http.Error(w, err.Error(), errorStatus(err))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", contentTypes[format])
+	w.Header().Set("X-Maze-Seed", strconv.FormatUint(resp.seed, 10))
+	if r.seeded {
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+	}
+	w.Write(resp.body)
+}
+
+// parseRequest reads the query parameters of req, checking them against the
+// limits of the server.
+func (s *Server) parseRequest(req *http.Request, format Format) (mazeRequest, error) {
+	query := req.URL.Query()
+	r := mazeRequest{format: format, width: 20, height: 20}
+
+	var err error
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"w", &r.width},
+		{"h", &r.height},
+	}
+	for _, param := range ints {
+		if v := query.Get(param.name); v != "" {
+			if *param.value, err = strconv.Atoi(v); err != nil || *param.value < 1 {
+				return r, fmt.Errorf("%w: %s=%q is not a positive integer", ErrInvalidRequest, param.name, v)
+			}
+		}
+	}
+	if v := query.Get("seed"); v != "" {
+		if r.seed, err = strconv.ParseUint(v, 10, 64); err != nil {
+			return r, fmt.Errorf("%w: seed=%q is not a number", ErrInvalidRequest, v)
+		}
+		r.seeded = true
+	}
+	if v := query.Get("algo"); v != "" {
+		if r.algorithm, err = ParseAlgorithm(v); err != nil {
+			return r, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+	}
+	if v := query.Get("shape"); v != "" {
+		if r.shape, err = ParseShape(v); err != nil {
+			return r, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+		}
+	}
+	if cellCount(r.shape, r.width, r.height, s.maxCells) > s.maxCells {
+		return r, fmt.Errorf("%w: a %v maze of %dx%d is more than %d cells", ErrInvalidRequest, r.shape, r.width, r.height, s.maxCells)
+	}
+	if v := query.Get("braid"); v != "" {
+		if r.braid, err = strconv.ParseFloat(v, 64); err != nil || r.braid < 0 || r.braid > 1 {
+			return r, fmt.Errorf("%w: braid=%q is not between 0 and 1", ErrInvalidRequest, v)
+		}
+	}
+	if v := query.Get("solution"); v != "" {
+		if r.solution, err = strconv.ParseBool(v); err != nil {
+			return r, fmt.Errorf("%w: solution=%q is not true or false", ErrInvalidRequest, v)
+		}
+	}
+	return r, nil
+}
+
+// generate generates and encodes the maze r asks for, caching it if it has a
+// seed. It gives up waiting for a turn or for the maze when ctx is done, which
+// also stops the generation.
+func (s *Server) generate(ctx context.Context, r mazeRequest) (mazeResponse, error) {
+	select {
+	case s.generating <- struct{}{}:
+	case <-ctx.Done():
+		return mazeResponse{}, ctx.Err()
+	}
+
+	type result struct {
+		resp mazeResponse
+		err  error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-s.generating }()
+		opts := []GeneratorOptions{WithAlgorithm(r.algorithm), WithShape(r.shape), WithBraid(r.braid), WithContext(ctx)}
+		if r.seeded {
+			opts = append(opts, WithSeed(r.seed))
+		}
+		mg := NewMazeGenerator(r.width, r.height, opts...)
+		var buf bytes.Buffer
+		err := mg.Generate()
+		if err == nil {
+			err = mg.Encode(&buf, r.format, r.solution)
+		}
+
+		resp := mazeResponse{body: buf.Bytes(), seed: mg.Seed()}
+		if err == nil && r.seeded {
+			s.cache.put(r.key(), resp)
+		}
+		done <- result{resp, err}
+	}()
+
+	select {
+	case res := <-done:
+		return res.resp, res.err
+	case <-ctx.Done():
+		return mazeResponse{}, ctx.Err()
+	}
+}
+
+// errorStatus is the HTTP status of a failed request for a maze.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrUnsupportedAlgorithm), errors.Is(err, ErrUnsupportedShape),
+		errors.Is(err, sparsegraph.ErrInvalidConfiguration):
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+// responseCache keeps the most recently used responses.
+type responseCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // of *cacheEntry, most recently used first
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key  string
+	resp mazeResponse
+}
+
+func newResponseCache(size int) *responseCache {
+	return &responseCache{size: size, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+func (c *responseCache) get(key string) (mazeResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return mazeResponse{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*cacheEntry).resp, true
+}
+
+func (c *responseCache) put(key string, resp mazeResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*cacheEntry).resp = resp
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key, resp})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
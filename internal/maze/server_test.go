@@ -0,0 +1,178 @@
+package maze_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	. "tsumegolang/internal/maze"
+)
+
+func get(t *testing.T, s *Server, url string) *httptest.ResponseRecorder {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	return rec
+}
+
+func TestServerFormats(t *testing.T) {
+	s := NewServer()
+
+	testCases := []struct {
+		url         string
+		contentType string
+		check       func(body []byte) error
+	}{
+		{url: "/maze.png?w=8&h=6&seed=42&algo=prim&solution=true", contentType: "image/png", check: func(body []byte) error {
+			_, err := png.Decode(bytes.NewReader(body))
+			return err
+		}},
+		{url: "/maze.svg?w=8&h=6&seed=42", contentType: "image/svg+xml"},
+		{url: "/maze.json?w=8&h=6&seed=42&shape=hex&solution=1", contentType: "application/json", check: func(body []byte) error {
+			var doc map[string]any
+			return json.Unmarshal(body, &doc)
+		}},
+		{url: "/maze.txt?w=8&h=6&seed=42&braid=0.5", contentType: "text/plain; charset=utf-8"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			rec := get(t, s, tc.url)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("(ServeHTTP) want status 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			if got := rec.Header().Get("Content-Type"); got != tc.contentType {
+				t.Errorf("(ServeHTTP) want content type %q, got %q", tc.contentType, got)
+			}
+			if got := rec.Header().Get("X-Maze-Seed"); got != "42" {
+				t.Errorf("(ServeHTTP) want seed 42, got %q", got)
+			}
+			if tc.check != nil {
+				if err := tc.check(rec.Body.Bytes()); err != nil {
+					t.Errorf("(ServeHTTP) invalid body: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestServerMatchesGenerator(t *testing.T) {
+	mg := NewMazeGenerator(9, 7, WithSeed(5), WithAlgorithm(Wilson))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	var want bytes.Buffer
+	if err := mg.Encode(&want, SVG, true); err != nil {
+		t.Fatalf("(Encode) unexpected error: %v", err)
+	}
+
+	rec := get(t, NewServer(), "/maze.svg?w=9&h=7&seed=5&algo=wilson&solution=true")
+	if rec.Body.String() != want.String() {
+		t.Errorf("(ServeHTTP) want the maze of MazeGenerator:\n%s\ngot:\n%s", want.String(), rec.Body.String())
+	}
+}
+
+func TestServerCache(t *testing.T) {
+	s := NewServer(WithCacheSize(1))
+
+	first := get(t, s, "/maze.txt?w=30&h=30&seed=3")
+	if again := get(t, s, "/maze.txt?w=30&h=30&seed=3"); again.Body.String() != first.Body.String() {
+		t.Errorf("(ServeHTTP) want the same maze for the same seed")
+	}
+	if first.Header().Get("Cache-Control") == "no-store" {
+		t.Errorf("(ServeHTTP) want mazes with a seed cacheable")
+	}
+
+	// Without a seed every request gets a new maze.
+	a, b := get(t, s, "/maze.txt?w=30&h=30"), get(t, s, "/maze.txt?w=30&h=30")
+	if a.Header().Get("X-Maze-Seed") == b.Header().Get("X-Maze-Seed") || a.Body.String() == b.Body.String() {
+		t.Errorf("(ServeHTTP) want a new maze without a seed, got seed %s twice", a.Header().Get("X-Maze-Seed"))
+	}
+	if a.Header().Get("Cache-Control") != "no-store" {
+		t.Errorf("(ServeHTTP) want mazes without a seed not cached, got %q", a.Header().Get("Cache-Control"))
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	testCases := []struct {
+		name       string
+		server     *Server
+		method     string
+		url        string
+		wantStatus int
+	}{
+		{name: "unknown path", url: "/maze.gif", wantStatus: http.StatusNotFound},
+		{name: "not a maze", url: "/index.html", wantStatus: http.StatusNotFound},
+		{name: "post", method: http.MethodPost, url: "/maze.png", wantStatus: http.StatusMethodNotAllowed},
+		{name: "bad width", url: "/maze.png?w=abc", wantStatus: http.StatusBadRequest},
+		{name: "negative height", url: "/maze.png?h=-3", wantStatus: http.StatusBadRequest},
+		{name: "too large", url: "/maze.png?w=1000&h=1000", wantStatus: http.StatusBadRequest},
+		{name: "too many rings", url: "/maze.png?shape=polar&w=1&h=300", wantStatus: http.StatusBadRequest},
+		{name: "polar ignores width", url: "/maze.png?shape=polar&w=100000&h=10", wantStatus: http.StatusOK},
+		{name: "too large hex", url: "/maze.png?shape=hex&w=600&h=600", wantStatus: http.StatusBadRequest},
+		{name: "smaller limit", server: NewServer(WithMaxCells(50)), url: "/maze.png?w=10&h=6", wantStatus: http.StatusBadRequest},
+		{name: "unknown algorithm", url: "/maze.png?algo=magic", wantStatus: http.StatusBadRequest},
+		{name: "bad braid", url: "/maze.png?braid=2", wantStatus: http.StatusBadRequest},
+		{name: "bad solution", url: "/maze.png?solution=maybe", wantStatus: http.StatusBadRequest},
+		{name: "disconnected triangle", url: "/maze.png?shape=triangle&w=1&h=3&algo=wilson", wantStatus: http.StatusBadRequest},
+		{name: "unsupported combination", url: "/maze.png?shape=hex&algo=eller", wantStatus: http.StatusBadRequest},
+		{name: "timeout", server: NewServer(WithRequestTimeout(time.Nanosecond)), url: "/maze.png?w=400&h=400", wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.server
+			if s == nil {
+				s = NewServer()
+			}
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(method, tc.url, nil))
+			if rec.Code != tc.wantStatus {
+				t.Errorf("(ServeHTTP) want status %d, got %d: %s", tc.wantStatus, rec.Code, strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+}
+
+func TestServerMaxGenerating(t *testing.T) {
+	s := NewServer(WithMaxGenerating(2))
+	var wg sync.WaitGroup
+	codes := make([]int, 8)
+	for i := range codes {
+		wg.Go(func() {
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, fmt.Sprintf("/maze.txt?w=30&h=30&seed=%d", i), nil))
+			codes[i] = rec.Code
+		})
+	}
+	wg.Wait()
+	for i, code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("(ServeHTTP) want status %d for request %d, got %d", http.StatusOK, i, code)
+		}
+	}
+}
+
+func TestGenerateCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, shape := range []Shape{Square, Hex} {
+		err := NewMazeGenerator(30, 30, WithShape(shape), WithContext(ctx)).Generate()
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("(Generate) want %v for a %v maze, got %v", context.Canceled, shape, err)
+		}
+	}
+}
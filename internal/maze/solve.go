@@ -0,0 +1,266 @@
+package maze
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"tsumegolang/pkg/algo/graph/bfs"
+	"tsumegolang/pkg/algo/graph/common"
+	"tsumegolang/pkg/ds/basic"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+// Solver selects how the shortest path through a maze is found. Both give a path
+// with the fewest steps; A* explores fewer cells on large, open mazes.
+type Solver int
+
+const (
+	BFS   Solver = iota // breadth-first search
+	AStar               // A* guided by the distance to the goal
+)
+
+var (
+	ErrUnknownSolver = errors.New("unknown maze solver")
+	ErrNoSolution    = errors.New("maze has no path between the openings")
+)
+
+var solverNames = []string{
+	BFS:   "bfs",
+	AStar: "astar",
+}
+
+func (s Solver) String() string {
+	if s < 0 || int(s) >= len(solverNames) {
+		return fmt.Sprintf("Solver(%d)", int(s))
+	}
+	return solverNames[s]
+}
+
+// ParseSolver returns the Solver with the given name, as listed by SolverNames.
+func ParseSolver(name string) (Solver, error) {
+	i := slices.Index(solverNames, strings.ToLower(name))
+	if i < 0 {
+		return 0, fmt.Errorf("%w: %q", ErrUnknownSolver, name)
+	}
+	return Solver(i), nil
+}
+
+// SolverNames lists the names accepted by ParseSolver.
+func SolverNames() []string {
+	return slices.Clone(solverNames)
+}
+
+// Solve returns the cells on a shortest path from start to goal through the
+// passages of r, including both ends.
+func (s Solver) Solve(r *Rectangle, start, goal int) ([]int, error) {
+	var path []int
+	var err error
+	switch s {
+	case BFS:
+		path, err = bfs.ShortestPath(r.Graph, start, goal)
+	case AStar:
+		path, err = aStar(r.Graph, start, goal, r.manhattan(goal), nil)
+	default:
+		err = fmt.Errorf("%w: %v", ErrUnknownSolver, s)
+	}
+	if errors.Is(err, common.ErrNoPath) {
+		return nil, ErrNoSolution
+	}
+
+	return path, err
+}
+
+// SolveGrid is Solve for hex, triangle and polar mazes. A* is guided by the
+// straight-line distance to the goal in steps between neighboring cells.
+func (s Solver) SolveGrid(g *Grid, start, goal int) ([]int, error) {
+	var path []int
+	var err error
+	switch s {
+	case BFS:
+		path, err = bfs.ShortestPath(g.Graph, start, goal)
+	case AStar:
+		path, err = aStar(g.Graph, start, goal, g.stepsTo(goal), nil)
+	default:
+		err = fmt.Errorf("%w: %v", ErrUnknownSolver, s)
+	}
+	if errors.Is(err, common.ErrNoPath) {
+		return nil, ErrNoSolution
+	}
+
+	return path, err
+}
+
+// manhattan returns the A* heuristic for reaching goal in a rectangle. A step
+// through a tunnel of a woven maze covers two cells, so the distance is halved.
+func (r *Rectangle) manhattan(goal int) func(int) int {
+	goalI, goalJ := r.GraphToRect(goal)
+	woven := r.woven()
+	return func(cell int) int {
+		i, j := r.GraphToRect(cell)
+		d := abs(i-goalI) + abs(j-goalJ)
+		if woven {
+			return (d + 1) / 2
+		}
+		return d
+	}
+}
+
+// stepsTo returns an A* heuristic for reaching goal in g. No step between
+// neighbors is longer than maxStep, so it never overestimates.
+func (g *Grid) stepsTo(goal int) func(int) int {
+	step := g.maxStep()
+	target := g.Center(goal)
+	return func(cell int) int {
+		if step == 0 {
+			return 0
+		}
+		return int(distance(g.Center(cell), target) / step)
+	}
+}
+
+type aStarItem struct {
+	cell     int
+	estimate int // steps so far plus the heuristic
+}
+
+type aStarQueue []aStarItem
+
+func (q aStarQueue) Len() int { return len(q) }
+func (q aStarQueue) Less(i, j int) bool {
+	if q[i].estimate != q[j].estimate {
+		return q[i].estimate < q[j].estimate
+	}
+	return q[i].cell < q[j].cell
+}
+func (q aStarQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *aStarQueue) Push(x any)   { *q = append(*q, x.(aStarItem)) }
+func (q *aStarQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// explore returns the cells in the order the solver visits them while looking
+// for goal, ending with goal if it can be reached.
+func (s Solver) explore(g *sparsegraph.Graph, start, goal int, heuristic func(cell int) int) []int {
+	visited := []int{}
+	seen := make([]bool, g.GetSize())
+	visit := func(cell int) {
+		// A* may take a cell from the queue again after finding a shorter way to it.
+		if !seen[cell] {
+			seen[cell] = true
+			visited = append(visited, cell)
+		}
+	}
+	if s == AStar {
+		aStar(g, start, goal, heuristic, visit)
+	} else {
+		breadthFirst(adjacency(g), start, goal, visit)
+	}
+	return visited
+}
+
+// breadthFirst visits the cells reachable from start in order of distance,
+// stopping at goal.
+func breadthFirst(adj [][]int, start, goal int, visit func(cell int)) {
+	seen := make([]bool, len(adj))
+	seen[start] = true
+
+	queue := basic.NewQueue[int]()
+	queue.Enqueue(start)
+	for current, ok := queue.Dequeue(); ok; current, ok = queue.Dequeue() {
+		visit(current)
+		if current == goal {
+			return
+		}
+		for _, next := range adj[current] {
+			if !seen[next] {
+				seen[next] = true
+				queue.Enqueue(next)
+			}
+		}
+	}
+}
+
+// aStar returns a shortest path from start to goal, calling visit, if set, on
+// each cell as it is taken from the queue.
+func aStar(g *sparsegraph.Graph, start, goal int, heuristic func(cell int) int, visit func(cell int)) ([]int, error) {
+	n := g.GetSize()
+	if start < 0 || start >= n || goal < 0 || goal >= n {
+		return nil, common.ErrNoSuchRoot
+	}
+
+	adj := adjacency(g)
+	steps := make([]int, n)
+	previous := make([]int, n)
+	for i := range previous {
+		previous[i] = -1
+	}
+	previous[start] = start
+
+	queue := &aStarQueue{{cell: start, estimate: heuristic(start)}}
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(aStarItem).cell
+		if visit != nil {
+			visit(current)
+		}
+		if current == goal {
+			break
+		}
+		for _, next := range adj[current] {
+			if previous[next] >= 0 && steps[next] <= steps[current]+1 {
+				continue
+			}
+			previous[next] = current
+			steps[next] = steps[current] + 1
+			heap.Push(queue, aStarItem{cell: next, estimate: steps[next] + heuristic(next)})
+		}
+	}
+
+	if previous[goal] < 0 {
+		return nil, common.ErrNoPath
+	}
+
+	path := []int{goal}
+	for current := goal; current != start; {
+		current = previous[current]
+		path = append(path, current)
+	}
+	slices.Reverse(path)
+
+	return path, nil
+}
+
+// distances returns the number of steps from start to every cell through the
+// passages in adj, or -1 for cells that cannot be reached.
+func distances(adj [][]int, start int) []int {
+	dist := make([]int, len(adj))
+	for i := range dist {
+		dist[i] = -1
+	}
+	dist[start] = 0
+
+	queue := basic.NewQueue[int]()
+	queue.Enqueue(start)
+	for current, ok := queue.Dequeue(); ok; current, ok = queue.Dequeue() {
+		for _, next := range adj[current] {
+			if dist[next] < 0 {
+				dist[next] = dist[current] + 1
+				queue.Enqueue(next)
+			}
+		}
+	}
+
+	return dist
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,173 @@
+package maze_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestSolve(t *testing.T) {
+	for _, name := range SolverNames() {
+		solver, _ := ParseSolver(name)
+		for _, algorithm := range []Algorithm{Kruskal, Backtracker, Sidewinder} {
+			t.Run(name+" "+algorithm.String(), func(t *testing.T) {
+				rng := rand.New(rand.NewPCG(3, 5))
+				r, _ := NewRectangle(15, 10, ConnectRandom, WithRand(rng))
+				paths, err := algorithm.Carve(r, rng)
+				if err != nil {
+					t.Fatalf("(Carve) unexpected error: %v", err)
+				}
+				r.Graph = paths
+
+				start, goal := 0, r.RectToGraph(9, 14)
+				path, err := solver.Solve(r, start, goal)
+				if err != nil {
+					t.Fatalf("(Solve) unexpected error: %v", err)
+				}
+				if path[0] != start || path[len(path)-1] != goal {
+					t.Errorf("(Solve) path runs from %d to %d, want %d to %d", path[0], path[len(path)-1], start, goal)
+				}
+				for k := 1; k < len(path); k++ {
+					if _, ok := r.Graph.GetEdge(path[k-1], path[k]); !ok {
+						t.Errorf("(Solve) path steps through a wall between %d and %d", path[k-1], path[k])
+					}
+				}
+
+				// A perfect maze has exactly one path, so every solver must agree with BFS.
+				want, _ := BFS.Solve(r, start, goal)
+				if len(path) != len(want) {
+					t.Errorf("(Solve) want path of %d cells, got %d", len(want), len(path))
+				}
+			})
+		}
+	}
+}
+
+func TestSolveNoSolution(t *testing.T) {
+	r, _ := NewRectangle(3, 3, NoConnect)
+	for _, name := range SolverNames() {
+		solver, _ := ParseSolver(name)
+		if _, err := solver.Solve(r, 0, 8); !errors.Is(err, ErrNoSolution) {
+			t.Errorf("(Solve) %s: want %v, got %v", name, ErrNoSolution, err)
+		}
+	}
+}
+
+func TestOpenings(t *testing.T) {
+	testCases := []struct {
+		name         string
+		opts         []GeneratorOptions
+		wantEntrance image.Point
+		wantExit     image.Point
+		wantErr      error
+	}{
+		{
+			name:         "default corners",
+			wantEntrance: image.Pt(0, 0),
+			wantExit:     image.Pt(7, 5),
+		},
+		{
+			name:         "chosen points",
+			opts:         []GeneratorOptions{WithOpenings(image.Pt(0, 3), image.Pt(7, 2))},
+			wantEntrance: image.Pt(0, 3),
+			wantExit:     image.Pt(7, 2),
+		},
+		{
+			name:         "entrance only",
+			opts:         []GeneratorOptions{WithEntrance(image.Pt(4, 0))},
+			wantEntrance: image.Pt(4, 0),
+			wantExit:     image.Pt(7, 5),
+		},
+		{
+			name:         "exit only",
+			opts:         []GeneratorOptions{WithExit(image.Pt(0, 5))},
+			wantEntrance: image.Pt(0, 0),
+			wantExit:     image.Pt(0, 5),
+		},
+		{
+			name:    "interior point",
+			opts:    []GeneratorOptions{WithOpenings(image.Pt(3, 3), image.Pt(7, 2))},
+			wantErr: ErrNotOnBorder,
+		},
+		{
+			name:    "outside point",
+			opts:    []GeneratorOptions{WithOpenings(image.Pt(0, 0), image.Pt(8, 2))},
+			wantErr: ErrNotOnBorder,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			filename := filepath.Join(t.TempDir(), "maze.png")
+			opts := append([]GeneratorOptions{WithSeed(11), WithFilename(filename)}, tc.opts...)
+			mg := NewMazeGenerator(8, 6, opts...)
+
+			err := mg.Generate()
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if mg.Entrance() != tc.wantEntrance || mg.Exit() != tc.wantExit {
+				t.Errorf("(Generate) want openings %v and %v, got %v and %v", tc.wantEntrance, tc.wantExit, mg.Entrance(), mg.Exit())
+			}
+			solution := mg.Solution()
+			r := mg.Maze()
+			if solution[0] != r.RectToGraph(tc.wantEntrance.Y, tc.wantEntrance.X) ||
+				solution[len(solution)-1] != r.RectToGraph(tc.wantExit.Y, tc.wantExit.X) {
+				t.Errorf("(Generate) solution does not join the openings: %v", solution)
+			}
+		})
+	}
+}
+
+func TestFarthestOpenings(t *testing.T) {
+	dir := t.TempDir()
+	corners := NewMazeGenerator(12, 12, WithSeed(5), WithFilename(filepath.Join(dir, "corners.png")))
+	farthest := NewMazeGenerator(12, 12, WithSeed(5), WithFilename(filepath.Join(dir, "farthest.png")), WithFarthestOpenings())
+	for _, mg := range []*MazeGenerator{corners, farthest} {
+		if err := mg.Generate(); err != nil {
+			t.Fatalf("(Generate) unexpected error: %v", err)
+		}
+	}
+
+	if len(farthest.Solution()) < len(corners.Solution()) {
+		t.Errorf("farthest openings give a %d cell path, shorter than the corners' %d", len(farthest.Solution()), len(corners.Solution()))
+	}
+	for _, p := range []image.Point{farthest.Entrance(), farthest.Exit()} {
+		if !farthest.Maze().IsBorder(farthest.Maze().RectToGraph(p.Y, p.X)) {
+			t.Errorf("opening %v is not on the border", p)
+		}
+	}
+}
+
+func TestDrawSolution(t *testing.T) {
+	r, _ := NewRectangle(3, 1, ConnectConst)
+	r.Openings = []int{0, 2}
+
+	m, err := DrawRectangleMaze(r)
+	if err != nil {
+		t.Fatalf("(DrawRectangleMaze) unexpected error: %v", err)
+	}
+	if got := m.At(SquareSize/2, 0); got != color.RGBAModel.Convert(color.White) {
+		t.Errorf("(DrawRectangleMaze) want an opening above the entrance, got %v", got)
+	}
+
+	solved := DrawSolution(m, r, []int{0, 1, 2})
+	for _, p := range []image.Point{
+		{SquareSize / 2, 0},                  // through the entrance
+		{SquareSize * 3 / 2, SquareSize / 2}, // middle cell
+		{SquareSize*5/2 - 1, SquareSize / 2}, // last cell
+	} {
+		if got := solved.At(p.X, p.Y); got != color.Color(SolutionColor) {
+			t.Errorf("(DrawSolution) want %v at %v, got %v", SolutionColor, p, got)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+package maze
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+// turnAngle is the smallest change of direction counted as a turn. It sees every
+// corner of a square maze, and the bends of hex and triangle mazes.
+const turnAngle = math.Pi / 6
+
+// Stats describes the structure of a maze and how hard its solution is to find.
+type Stats struct {
+	Cells          int     `json:"cells"`
+	Passages       int     `json:"passages"`
+	DeadEnds       int     `json:"dead_ends"` // cells with one passage
+	Corridors      int     `json:"corridors"` // cells with two passages
+	Junctions      int     `json:"junctions"` // cells with three or more passages
+	Loops          int     `json:"loops"`     // passages beyond a spanning tree; 0 for a perfect maze
+	Diameter       int     `json:"diameter"`  // steps on the longest shortest path between two cells
+	SolutionLength int     `json:"solution_length"`
+	SolutionTurns  int     `json:"solution_turns"`
+	Decisions      int     `json:"decisions"`             // junctions passed on the way to the exit
+	AverageBranch  float64 `json:"average_branch_length"` // steps between cells that are not corridors
+	Difficulty     float64 `json:"difficulty"`            // from 0 to 100; see Analyze
+}
+
+// Analyze reports on the passages of r and a solution through it, such as the
+// one found by a Solver.
+//
+// Difficulty combines how much of the maze the solution covers, how often it
+// turns and how many junctions it passes: 100 * (0.5*min(1, 2*length/cells) +
+// 0.25*turns/steps + 0.25*decisions/length).
+func Analyze(r *Rectangle, solution []int) Stats {
+	center := func(cell int) Point {
+		i, j := r.GraphToRect(cell)
+		return Point{float64(j), float64(i)}
+	}
+	return analyze(r.Graph, r.Included, center, solution)
+}
+
+// AnalyzeGrid is Analyze for hex, triangle and polar mazes.
+func AnalyzeGrid(g *Grid, solution []int) Stats {
+	included := func(int) bool { return true }
+	return analyze(g.Graph, included, g.Center, solution)
+}
+
+// Stats analyzes the generated maze and its solution.
+func (mg *MazeGenerator) Stats() Stats {
+	if mg.grid != nil {
+		return AnalyzeGrid(mg.grid, mg.solution)
+	}
+	if mg.compactMaze != nil {
+		return mg.compactMaze.Analyze(mg.solution)
+	}
+	if mg.box != nil {
+		return AnalyzeBox(mg.box, mg.solution)
+	}
+	return Analyze(mg.rect, mg.solution)
+}
+
+func analyze(paths *sparsegraph.Graph, included func(int) bool, center func(int) Point, solution []int) Stats {
+	adj := adjacency(paths)
+	s := Stats{
+		Passages:       len(paths.GetAllEdges()),
+		SolutionLength: len(solution),
+	}
+
+	cells := []int{}
+	for cell, next := range adj {
+		if !included(cell) {
+			continue
+		}
+		cells = append(cells, cell)
+		switch len(next) {
+		case 1:
+			s.DeadEnds++
+		case 2:
+			s.Corridors++
+		case 0:
+		default:
+			s.Junctions++
+		}
+	}
+	s.Cells = len(cells)
+	if s.Cells == 0 {
+		return s
+	}
+
+	components := 0
+	seen := make([]bool, len(adj))
+	for _, cell := range cells {
+		if seen[cell] {
+			continue
+		}
+		components++
+		for other, d := range distances(adj, cell) {
+			if d >= 0 {
+				seen[other] = true
+			}
+		}
+	}
+	s.Loops = s.Passages - s.Cells + components
+	s.Diameter = diameter(adj, cells)
+	s.AverageBranch = averageBranch(adj)
+
+	s.scoreSolution(solution, func(cell int) int { return len(adj[cell]) }, center)
+	return s
+}
+
+// scoreSolution counts the turns and decisions along solution and sets the
+// Difficulty, given the passages leaving each cell and its center.
+func (s *Stats) scoreSolution(solution []int, degree func(cell int) int, center func(cell int) Point) {
+	for k := 1; k+1 < len(solution); k++ {
+		if degree(solution[k]) >= 3 {
+			s.Decisions++
+		}
+		a, b, c := center(solution[k-1]), center(solution[k]), center(solution[k+1])
+		turn := math.Atan2(c.Y-b.Y, c.X-b.X) - math.Atan2(b.Y-a.Y, b.X-a.X)
+		turn = math.Abs(math.Remainder(turn, 2*math.Pi))
+		if turn > turnAngle {
+			s.SolutionTurns++
+		}
+	}
+
+	if len(solution) > 1 {
+		steps := float64(len(solution) - 1)
+		length := float64(len(solution))
+		s.Difficulty = 100 * (0.5*min(1, 2*length/float64(s.Cells)) +
+			0.25*float64(s.SolutionTurns)/steps +
+			0.25*float64(s.Decisions)/length)
+		s.Difficulty = math.Round(s.Difficulty*10) / 10
+	}
+}
+
+// diameter returns the most steps between two connected cells. Each component
+// is searched with iFUB: cells are taken from the farthest outward from a
+// central cell, and once the eccentricities found exceed twice the distance of
+// the cells left, no pair of those can lie farther apart. A tree, or a maze with
+// few loops, needs only a handful of searches.
+func diameter(adj [][]int, cells []int) int {
+	longest := 0
+	seen := make([]bool, len(adj))
+	for _, cell := range cells {
+		if !seen[cell] {
+			longest = max(longest, componentDiameter(adj, cell, seen))
+		}
+	}
+	return longest
+}
+
+// componentDiameter returns the diameter of the component holding start,
+// marking its cells seen.
+func componentDiameter(adj [][]int, start int, seen []bool) int {
+	farthest := func(dist []int) int {
+		best := start
+		for cell, d := range dist {
+			if d > dist[best] {
+				best = cell
+			}
+		}
+		return best
+	}
+
+	fromStart := distances(adj, start)
+	for cell, d := range fromStart {
+		if d >= 0 {
+			seen[cell] = true
+		}
+	}
+
+	// A double sweep gives a lower bound and a long path, whose middle is
+	// central enough to start from.
+	a := farthest(fromStart)
+	fromA := distances(adj, a)
+	b := farthest(fromA)
+	lower := fromA[b]
+	fromB := distances(adj, b)
+	center := a
+	for cell, d := range fromA {
+		if d == lower/2 && d+fromB[cell] == lower {
+			center = cell
+			break
+		}
+	}
+
+	fromCenter := distances(adj, center)
+	var fringes [][]int
+	for cell, d := range fromCenter {
+		if d < 0 {
+			continue
+		}
+		for len(fringes) <= d {
+			fringes = append(fringes, nil)
+		}
+		fringes[d] = append(fringes[d], cell)
+	}
+
+	upper := 2 * (len(fringes) - 1)
+	for i := len(fringes) - 1; upper > lower && i > 0; i-- {
+		for _, cell := range fringes[i] {
+			dist := distances(adj, cell)
+			lower = max(lower, dist[farthest(dist)])
+		}
+		// Cells nearer the center than i are at most 2(i-1) steps apart.
+		upper = 2 * (i - 1)
+	}
+	return lower
+}
+
+// averageBranch returns the average number of steps between cells that are not
+// corridors, following the corridors between them.
+func averageBranch(adj [][]int) float64 {
+	walked := make(map[[2]int]bool)
+	walk := func(a, b int) {
+		walked[[2]int{a, b}] = true
+		walked[[2]int{b, a}] = true
+	}
+
+	total, branches := 0, 0
+	for start, next := range adj {
+		if len(next) == 2 {
+			continue
+		}
+		for _, cell := range next {
+			if walked[[2]int{start, cell}] {
+				continue
+			}
+			walk(start, cell)
+			steps, previous := 1, start
+			for len(adj[cell]) == 2 {
+				following := adj[cell][0]
+				if following == previous {
+					following = adj[cell][1]
+				}
+				walk(cell, following)
+				previous, cell = cell, following
+				steps++
+			}
+			total += steps
+			branches++
+		}
+	}
+
+	if branches == 0 {
+		return 0
+	}
+	return math.Round(float64(total)/float64(branches)*100) / 100
+}
+
+// Rating labels how hard a maze is to solve, for printing beside it.
+type Rating int
+
+const (
+	Easy Rating = iota
+	Medium
+	Hard
+	Expert
+)
+
+var ratingNames = []string{
+	Easy:   "easy",
+	Medium: "medium",
+	Hard:   "hard",
+	Expert: "expert",
+}
+
+// ratingLimits are the efforts, in steps, below which a maze has each rating.
+var ratingLimits = []float64{
+	Easy:   15,
+	Medium: 35,
+	Hard:   80,
+}
+
+func (r Rating) String() string {
+	if r < 0 || int(r) >= len(ratingNames) {
+		return fmt.Sprintf("Rating(%d)", int(r))
+	}
+	return ratingNames[r]
+}
+
+// Rating rates the maze by the steps of its solution weighted by its
+// Difficulty, so a large maze rates harder than a small one with the same score.
+// A 10x10 Kruskal maze is usually easy, 20x20 medium and 40x40 hard.
+func (s Stats) Rating() Rating {
+	effort := float64(s.SolutionLength) * s.Difficulty / 100
+	for r, limit := range ratingLimits {
+		if effort < limit {
+			return Rating(r)
+		}
+	}
+	return Expert
+}
+
+// EncodeStats writes s as aligned text or as JSON.
+func EncodeStats(w io.Writer, s Stats, format Format) error {
+	switch format {
+	case Text:
+		var sb strings.Builder
+		for _, row := range []struct {
+			label string
+			value any
+		}{
+			{"Cells", s.Cells},
+			{"Passages", s.Passages},
+			{"Dead ends", s.DeadEnds},
+			{"Corridors", s.Corridors},
+			{"Junctions", s.Junctions},
+			{"Loops", s.Loops},
+			{"Diameter", s.Diameter},
+			{"Solution length", s.SolutionLength},
+			{"Solution turns", s.SolutionTurns},
+			{"Decisions", s.Decisions},
+			{"Average branch", s.AverageBranch},
+			{"Difficulty", s.Difficulty},
+		} {
+			fmt.Fprintf(&sb, "%-16s %v\n", row.label+":", row.value)
+		}
+		_, err := io.WriteString(w, sb.String())
+		return err
+	case JSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(s); err != nil {
+			return fmt.Errorf("failed to encode stats: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: stats cannot be written as %v", ErrUnknownFormat, format)
+}
@@ -0,0 +1,181 @@
+package maze_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestAnalyze(t *testing.T) {
+	loop, _ := NewRectangle(2, 2, ConnectConst)
+
+	testCases := []struct {
+		name     string
+		r        *Rectangle
+		solution []int
+		want     Stats
+	}{
+		{
+			name:     "single corridor",
+			r:        newTestMaze(t),
+			solution: []int{0, 3, 4, 5},
+			want: Stats{
+				Cells:          6,
+				Passages:       5,
+				DeadEnds:       2,
+				Corridors:      4,
+				Diameter:       5,
+				SolutionLength: 4,
+				SolutionTurns:  1,
+				AverageBranch:  5,
+				Difficulty:     58.3, // 100 * (0.5*1 + 0.25*1/3 + 0)
+			},
+		},
+		{
+			name:     "loop",
+			r:        loop,
+			solution: []int{0, 1, 3},
+			want: Stats{
+				Cells:          4,
+				Passages:       4,
+				Corridors:      4,
+				Loops:          1,
+				Diameter:       2,
+				SolutionLength: 3,
+				SolutionTurns:  1,
+				Difficulty:     62.5, // 100 * (0.5*1 + 0.25*1/2 + 0)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Analyze(tc.r, tc.solution); got != tc.want {
+				t.Errorf("(Analyze) want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestGeneratorStats(t *testing.T) {
+	mask, _ := ParseMaskText(strings.NewReader("X.....\n......\n......\n.....X\n"))
+	testCases := []struct {
+		name      string
+		opts      []GeneratorOptions
+		wantCells int
+		wantLoops bool
+	}{
+		{name: "square", wantCells: 80},
+		{name: "hex", opts: []GeneratorOptions{WithShape(Hex)}, wantCells: 80},
+		{name: "masked", opts: []GeneratorOptions{WithMask(mask)}, wantCells: 22},
+		{name: "braided", opts: []GeneratorOptions{WithBraid(1)}, wantCells: 80, wantLoops: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]GeneratorOptions{WithSeed(13), WithFilename(filepath.Join(t.TempDir(), "maze.png"))}, tc.opts...)
+			mg := NewMazeGenerator(10, 8, opts...)
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+
+			s := mg.Stats()
+			if s.Cells != tc.wantCells {
+				t.Errorf("want %d cells, got %d", tc.wantCells, s.Cells)
+			}
+			if s.DeadEnds+s.Corridors+s.Junctions != s.Cells {
+				t.Errorf("want every cell to be a dead end, corridor or junction, got %+v", s)
+			}
+			if (s.Loops > 0) != tc.wantLoops {
+				t.Errorf("want loops %v, got %d", tc.wantLoops, s.Loops)
+			}
+			if s.SolutionLength != len(mg.Solution()) || s.Diameter < s.SolutionLength-1 {
+				t.Errorf("want a solution of %d cells no longer than the diameter, got %+v", len(mg.Solution()), s)
+			}
+			if s.Difficulty <= 0 || s.Difficulty > 100 {
+				t.Errorf("want a difficulty between 0 and 100, got %v", s.Difficulty)
+			}
+		})
+	}
+}
+
+func TestAnalyzeDiameterBraided(t *testing.T) {
+	for _, seed := range []uint64{1, 2, 3} {
+		mg := NewMazeGenerator(30, 20, WithSeed(seed), WithBraid(0.5))
+		if err := mg.Generate(); err != nil {
+			t.Fatalf("(Generate) unexpected error: %v", err)
+		}
+		r := mg.Maze()
+
+		// Every cell's farthest cell, found the slow way.
+		n := r.Width * r.Height
+		adj := make([][]int, n)
+		for _, e := range r.Graph.GetAllEdges() {
+			from, to := e.From.(int), e.To.(int)
+			adj[from] = append(adj[from], to)
+			adj[to] = append(adj[to], from)
+		}
+		want := 0
+		for start := range n {
+			dist := map[int]int{start: 0}
+			for queue := []int{start}; len(queue) > 0; queue = queue[1:] {
+				for _, next := range adj[queue[0]] {
+					if _, ok := dist[next]; !ok {
+						dist[next] = dist[queue[0]] + 1
+						want = max(want, dist[next])
+						queue = append(queue, next)
+					}
+				}
+			}
+		}
+
+		if got := mg.Stats().Diameter; got != want {
+			t.Errorf("(Stats) want diameter %d with seed %d, got %d", want, seed, got)
+		}
+	}
+}
+
+func BenchmarkAnalyzeBraided(b *testing.B) {
+	for _, size := range []int{100, 300} {
+		mg := NewMazeGenerator(size, size, WithSeed(1), WithBraid(0.5))
+		if err := mg.Generate(); err != nil {
+			b.Fatalf("(Generate) unexpected error: %v", err)
+		}
+		b.Run(fmt.Sprintf("%dx%d", size, size), func(b *testing.B) {
+			for range b.N {
+				Analyze(mg.Maze(), mg.Solution())
+			}
+		})
+	}
+}
+
+func TestEncodeStats(t *testing.T) {
+	s := Analyze(newTestMaze(t), []int{0, 3, 4, 5})
+
+	var buf bytes.Buffer
+	if err := EncodeStats(&buf, s, Text); err != nil {
+		t.Fatalf("(EncodeStats) unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Dead ends:       2\n") || !strings.Contains(buf.String(), "Difficulty:      58.3\n") {
+		t.Errorf("(EncodeStats) unexpected text:\n%s", buf.String())
+	}
+
+	buf.Reset()
+	if err := EncodeStats(&buf, s, JSON); err != nil {
+		t.Fatalf("(EncodeStats) unexpected error: %v", err)
+	}
+	var got Stats
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil || got != s {
+		t.Errorf("(EncodeStats) want %+v, got %+v and error %v", s, got, err)
+	}
+
+	if err := EncodeStats(&buf, s, SVG); !errors.Is(err, ErrUnknownFormat) {
+		t.Errorf("(EncodeStats) want %v, got %v", ErrUnknownFormat, err)
+	}
+}
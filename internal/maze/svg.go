@@ -0,0 +1,315 @@
+package maze
+
+import (
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+)
+
+// EncodeSVG writes the maze as an SVG drawing with one unit per pixel of the PNG
+// drawing, so both have the same proportions. path, if not empty, is drawn as the
+// solution. metadata entries are listed in the document's description.
+func EncodeSVG(w io.Writer, r *Rectangle, path []int, metadata map[string]string) error {
+	return DefaultRenderOptions().EncodeSVG(w, r, path, metadata)
+}
+
+// EncodeSVG is the function EncodeSVG drawn with the given options.
+func (o RenderOptions) EncodeSVG(w io.Writer, r *Rectangle, path []int, metadata map[string]string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	size := o.CellSize
+	var sb strings.Builder
+	o.writeSVGStart(&sb, float64(r.Width*size), float64(r.Height*size), metadata)
+
+	var colors []color.Color
+	if o.HeatMap || !sameColor(o.PassageColor, o.Background) {
+		start := 0
+		if len(r.Openings) > 0 {
+			start = r.Openings[0]
+		}
+		colors = o.cellColors(adjacency(r.Graph), start)
+	}
+	var paths [][]int
+	if len(path) > 0 {
+		paths = [][]int{path}
+	}
+	o.writeSVGRectangle(&sb, r, colors, paths)
+
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeSVGRectangle draws the cells of r in colors, if any, then its walls and the
+// solution along each of paths.
+func (o RenderOptions) writeSVGRectangle(w io.Writer, r *Rectangle, colors []color.Color, paths [][]int) {
+	openings := r.openSides()
+	size := o.CellSize
+
+	for cell, c := range colors {
+		if r.Included(cell) {
+			i, j := r.GraphToRect(cell)
+			fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n", j*size, i*size, size, size, svgColor(c))
+		}
+	}
+
+	// Walls are centered on cell edges; the square caps close the corners.
+	cap := "square"
+	if o.RoundCorners {
+		cap = "round"
+	}
+	// Walls between sub-mazes get a path of their own when they have a color of
+	// their own.
+	separate := o.BlockColor != nil
+	walls := func(c color.Color, block bool) {
+		fmt.Fprintf(w, `<path fill="none" stroke="%s" stroke-width="%d" stroke-linecap="%s" d="`, svgColor(c), o.WallThickness, cap)
+		for y := 0; y <= r.Height; y++ {
+			for x := 0; x <= r.Width; x++ {
+				if x < r.Width && r.horizontalWall(x, y, openings) && (!separate || r.blockRow(y) == block) {
+					fmt.Fprintf(w, "M%d %dh%d", x*size, y*size, size)
+				}
+				if y < r.Height && r.verticalWall(x, y, openings) && (!separate || r.blockColumn(x) == block) {
+					fmt.Fprintf(w, "M%d %dv%d", x*size, y*size, size)
+				}
+			}
+		}
+		io.WriteString(w, `"/>`+"\n")
+	}
+	walls(o.WallColor, false)
+	if separate && r.Block != (image.Point{}) {
+		walls(o.BlockColor, true)
+	}
+	if crossings := r.Crossings(); len(crossings) > 0 {
+		fmt.Fprintf(w, `<path fill="none" stroke="%s" stroke-width="%d" stroke-linecap="%s" d="`, svgColor(o.WallColor), o.WallThickness, cap)
+		for _, cell := range crossings {
+			i, j := r.GraphToRect(cell)
+			horizontal, _ := r.tunnels(cell)
+			for _, wall := range crossingWalls(j, i, size, horizontal) {
+				fmt.Fprintf(w, "M%d %dL%d %d", wall[0].X, wall[0].Y, wall[1].X, wall[1].Y)
+			}
+		}
+		io.WriteString(w, `"/>`+"\n")
+	}
+
+	for _, path := range paths {
+		for _, points := range splitTunnels(svgSolutionPoints(r, path, openings, float64(size)), float64(size)) {
+			o.writeSVGSolution(w, points)
+		}
+	}
+}
+
+// EncodeBoxSVG is EncodeSVG for boxes, drawing the floors side by side as
+// DrawBox does.
+func (o RenderOptions) EncodeBoxSVG(w io.Writer, b *Box, path []int, metadata map[string]string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	size := o.CellSize
+	step := (b.Width+1)*size + 2*o.Margin // from one floor to the next
+	var sb strings.Builder
+	o.writeSVGStart(&sb, float64(b.Floors*step-size-2*o.Margin), float64(b.Height*size), metadata)
+
+	var colors []color.Color
+	if o.HeatMap || !sameColor(o.PassageColor, o.Background) {
+		start := 0
+		if len(b.Openings) > 0 {
+			start = b.Openings[0]
+		}
+		colors = o.cellColors(adjacency(b.Graph), start)
+	}
+	runs := b.floorRuns(path)
+	for floor := range b.Floors {
+		r, err := b.Floor(floor)
+		if err != nil {
+			return err
+		}
+		var floorColors []color.Color
+		if colors != nil {
+			floorColors = colors[floor*b.area() : (floor+1)*b.area()]
+		}
+
+		fmt.Fprintf(&sb, `<g transform="translate(%d 0)">`+"\n", floor*step)
+		o.writeSVGRectangle(&sb, r, floorColors, runs[floor])
+		if markers := b.stairMarkers(floor, float64(size)); len(markers) > 0 {
+			fmt.Fprintf(&sb, `<path fill="%s" d="`, svgColor(o.WallColor))
+			for _, marker := range markers {
+				for k, p := range marker {
+					command := "L"
+					if k == 0 {
+						command = "M"
+					}
+					fmt.Fprintf(&sb, "%s%g %g", command, svgRound(p.X), svgRound(p.Y))
+				}
+				sb.WriteString("z")
+			}
+			sb.WriteString(`"/>` + "\n")
+		}
+		sb.WriteString("</g>\n")
+	}
+
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// EncodeGridSVG is EncodeSVG for hex, triangle and polar mazes.
+func EncodeGridSVG(w io.Writer, g *Grid, path []int, metadata map[string]string) error {
+	return DefaultRenderOptions().EncodeGridSVG(w, g, path, metadata)
+}
+
+// EncodeGridSVG is the function EncodeGridSVG drawn with the given options. Walls
+// always have round joins, which suit the arcs of polar mazes.
+func (o RenderOptions) EncodeGridSVG(w io.Writer, g *Grid, path []int, metadata map[string]string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	size := o.gridPixels(g.Size())
+	scale := func(p Point) Point { return o.gridPixels(p) }
+
+	var sb strings.Builder
+	o.writeSVGStart(&sb, size.X, size.Y, metadata)
+
+	if o.HeatMap || !sameColor(o.PassageColor, o.Background) {
+		start := 0
+		if len(g.Openings) > 0 {
+			start = g.Openings[0]
+		}
+		for cell, c := range o.cellColors(adjacency(g.Graph), start) {
+			sb.WriteString(`<path d="`)
+			command := "M"
+			for _, e := range g.cells[cell].edges {
+				for _, p := range e.points[:len(e.points)-1] {
+					p = scale(p)
+					fmt.Fprintf(&sb, "%s%g %g", command, svgRound(p.X), svgRound(p.Y))
+					command = "L"
+				}
+			}
+			fmt.Fprintf(&sb, `z" fill="%s"/>`+"\n", svgColor(c))
+		}
+	}
+
+	fmt.Fprintf(&sb, `<path fill="none" stroke="%s" stroke-width="%d" stroke-linecap="round" stroke-linejoin="round" d="`, svgColor(o.WallColor), o.WallThickness)
+	for _, wall := range g.walls() {
+		for k, p := range wall {
+			command := "L"
+			if k == 0 {
+				command = "M"
+			}
+			p = scale(p)
+			fmt.Fprintf(&sb, "%s%g %g", command, svgRound(p.X), svgRound(p.Y))
+		}
+	}
+	sb.WriteString(`"/>` + "\n")
+
+	if len(path) > 0 {
+		points := g.solutionPoints(path)
+		for k := range points {
+			points[k] = scale(points[k])
+		}
+		o.writeSVGSolution(&sb, points)
+	}
+
+	sb.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// writeSVGStart opens an SVG document for a drawing of the given size, listing
+// metadata in its description and filling the background.
+func (o RenderOptions) writeSVGStart(w io.Writer, width, height float64, metadata map[string]string) {
+	width, height, pad := o.svgSize(width, height)
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%g" height="%g" viewBox="%g %g %g %g">`+"\n", width, height, -pad, -pad, width, height)
+	if len(metadata) > 0 {
+		io.WriteString(w, "<desc>")
+		for _, key := range slices.Sorted(maps.Keys(metadata)) {
+			fmt.Fprintf(w, "%s: %s\n", html.EscapeString(key), html.EscapeString(metadata[key]))
+		}
+		io.WriteString(w, "</desc>\n")
+	}
+	fmt.Fprintf(w, `<rect x="%g" y="%g" width="%g" height="%g" fill="%s"/>`+"\n", -pad, -pad, width, height, svgColor(o.Background))
+}
+
+// svgSize returns the size of the SVG document for a drawing of the given size
+// and the padding around the drawing. Padding of half a wall keeps the outer
+// walls as thick as the inner ones.
+func (o RenderOptions) svgSize(width, height float64) (float64, float64, float64) {
+	pad := float64(o.Margin + (o.WallThickness+1)/2)
+	return math.Ceil(width) + 2*pad, math.Ceil(height) + 2*pad, pad
+}
+
+func (o RenderOptions) writeSVGSolution(w io.Writer, points []Point) {
+	fmt.Fprintf(w, `<polyline fill="none" stroke="%s" stroke-width="%d" stroke-linecap="round" stroke-linejoin="round" points="`, svgColor(o.SolutionColor), o.solutionThickness())
+	for k, p := range points {
+		if k > 0 {
+			io.WriteString(w, " ")
+		}
+		fmt.Fprintf(w, "%g,%g", svgRound(p.X), svgRound(p.Y))
+	}
+	io.WriteString(w, `"/>`+"\n")
+}
+
+// svgSolutionPoints returns the centers of the cells on path, extended out through
+// the openings at either end.
+func svgSolutionPoints(r *Rectangle, path []int, openings map[int]wall, size float64) []Point {
+	center := func(cell int) Point {
+		i, j := r.GraphToRect(cell)
+		return Point{(float64(j) + 0.5) * size, (float64(i) + 0.5) * size}
+	}
+	outside := func(cell int) (Point, bool) {
+		open, ok := openings[cell]
+		if !ok {
+			return Point{}, false
+		}
+		c := center(cell)
+		half := size / 2
+		switch open {
+		case wallNorth:
+			c.Y -= half
+		case wallEast:
+			c.X += half
+		case wallSouth:
+			c.Y += half
+		case wallWest:
+			c.X -= half
+		}
+		return c, true
+	}
+
+	points := []Point{}
+	if p, ok := outside(path[0]); ok {
+		points = append(points, p)
+	}
+	for _, cell := range path {
+		points = append(points, center(cell))
+	}
+	if p, ok := outside(path[len(path)-1]); ok {
+		points = append(points, p)
+	}
+	return points
+}
+
+// svgRound keeps coordinates to two decimal places, well below a pixel.
+func svgRound(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+func svgColor(c color.Color) string {
+	nrgba := color.NRGBAModel.Convert(c).(color.NRGBA)
+	if nrgba.A < 0xff {
+		return fmt.Sprintf("#%02x%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B, nrgba.A)
+	}
+	return fmt.Sprintf("#%02x%02x%02x", nrgba.R, nrgba.G, nrgba.B)
+}
@@ -0,0 +1,47 @@
+package maze_test
+
+import (
+	"testing"
+
+	"tsumegolang/pkg/ds/disjointset"
+	"tsumegolang/pkg/ds/graph"
+
+	. "tsumegolang/internal/maze"
+)
+
+// assertSpanningTree checks that edges join only grid neighbors of r and form a
+// tree covering every cell.
+func assertSpanningTree(t *testing.T, r *Rectangle, edges []graph.Edge) {
+	t.Helper()
+
+	n := r.Width * r.Height
+	if len(edges) != n-1 {
+		t.Fatalf("want %d edges in a spanning tree of %d cells, got %d", n-1, n, len(edges))
+	}
+
+	ds, err := disjointset.NewDisjointSet(disjointset.WithCapacity(n))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ds.AddMany(n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for _, e := range edges {
+		from, to := e.From.(int), e.To.(int)
+		dx := from%r.Width - to%r.Width
+		dy := from/r.Width - to/r.Width
+		if dx*dx+dy*dy != 1 {
+			t.Fatalf("edge %d-%d does not join neighboring cells", from, to)
+		}
+
+		a, _ := ds.Find(from)
+		b, _ := ds.Find(to)
+		if a == b {
+			t.Fatalf("edge %d-%d closes a loop", from, to)
+		}
+		if err := ds.Union(a, b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package maze
+
+import (
+	"io"
+	"strings"
+)
+
+const (
+	textSolutionMark = "•"
+)
+
+// boxCorners holds the box-drawing character joining walls that leave a corner
+// upward (1), rightward (2), downward (4) and leftward (8).
+var boxCorners = []rune(" ╵╶└╷│┌├╴┘─┴┐┤┬┼")
+
+// EncodeText writes the maze with Unicode box-drawing characters, marking the
+// cells of path if it is not empty. Each cell is three characters wide.
+func EncodeText(w io.Writer, r *Rectangle, path []int) error {
+	onPath := make(map[int]bool, len(path))
+	for _, cell := range path {
+		onPath[cell] = true
+	}
+	return encodeText(w, r, func(cell int) string {
+		if onPath[cell] {
+			return textSolutionMark
+		}
+		return " "
+	})
+}
+
+// encodeText is EncodeText marking each cell with the single character mark
+// returns for it.
+func encodeText(w io.Writer, r *Rectangle, mark func(cell int) string) error {
+	openings := r.openSides()
+
+	var sb, line strings.Builder
+	endLine := func() {
+		// Open walls on the right edge would otherwise leave trailing spaces.
+		sb.WriteString(strings.TrimRight(line.String(), " "))
+		sb.WriteString("\n")
+		line.Reset()
+	}
+
+	for y := 0; y <= r.Height; y++ {
+		// The wall row above cell row y.
+		for x := 0; x <= r.Width; x++ {
+			corner := 0
+			if y > 0 && r.verticalWall(x, y-1, openings) {
+				corner |= 1
+			}
+			if x < r.Width && r.horizontalWall(x, y, openings) {
+				corner |= 2
+			}
+			if y < r.Height && r.verticalWall(x, y, openings) {
+				corner |= 4
+			}
+			if x > 0 && r.horizontalWall(x-1, y, openings) {
+				corner |= 8
+			}
+			line.WriteRune(boxCorners[corner])
+
+			if x < r.Width {
+				if r.horizontalWall(x, y, openings) {
+					line.WriteString("───")
+				} else {
+					line.WriteString("   ")
+				}
+			}
+		}
+		endLine()
+
+		if y == r.Height {
+			break
+		}
+
+		// The cell row itself.
+		for x := 0; x <= r.Width; x++ {
+			if r.verticalWall(x, y, openings) {
+				line.WriteString("│")
+			} else {
+				line.WriteString(" ")
+			}
+			if x < r.Width {
+				cell := r.RectToGraph(y, x)
+				m := mark(cell)
+				if horizontal, vertical := r.tunnels(cell); m == " " && (horizontal || vertical) {
+					m = "┼"
+				}
+				line.WriteString(" " + m + " ")
+			}
+		}
+		endLine()
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
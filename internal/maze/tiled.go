@@ -0,0 +1,240 @@
+package maze
+
+import (
+	"bufio"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"maps"
+	"os"
+	"slices"
+)
+
+const (
+	bandPixels = 1 << 20 // most pixels drawn at once by EncodeCompactPNG
+	idatSize   = 1 << 16 // most image data in a single PNG chunk
+)
+
+// EncodeCompactPNG writes c as a PNG the same as DrawRectangle draws it, with path
+// as the solution if it is not empty. The image is drawn and compressed a band of
+// rows at a time, so only a band is ever held in memory; the file is a paletted
+// PNG of the wall, passage, background and solution colors. metadata entries are
+// stored in tEXt chunks. The heat map is not supported.
+func (o RenderOptions) EncodeCompactPNG(w io.Writer, c *Compact, path []int, metadata map[string]string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if o.HeatMap {
+		return fmt.Errorf("%w: heat map", ErrUnsupportedCompact)
+	}
+
+	size, margin := o.CellSize, o.Margin
+	width, height := c.Width*size+2*margin, c.Height*size+2*margin
+
+	palette := color.Palette{}
+	index := map[color.RGBA]uint8{}
+	for _, col := range []color.Color{o.Background, o.PassageColor, o.WallColor, o.SolutionColor} {
+		rgba := color.RGBAModel.Convert(col).(color.RGBA)
+		if _, ok := index[rgba]; !ok {
+			index[rgba] = uint8(len(palette))
+			palette = append(palette, col)
+		}
+	}
+
+	if _, err := w.Write(pngSignature); err != nil {
+		return err
+	}
+	header := binary.BigEndian.AppendUint32(nil, uint32(width))
+	header = binary.BigEndian.AppendUint32(header, uint32(height))
+	header = append(header, 8, 3, 0, 0, 0) // 8 bit palette indices, no interlacing
+	if err := writePNGChunk(w, "IHDR", header); err != nil {
+		return err
+	}
+	for _, key := range slices.Sorted(maps.Keys(metadata)) {
+		data := append([]byte(key), 0)
+		data = append(data, metadata[key]...)
+		if err := writePNGChunk(w, "tEXt", data); err != nil {
+			return err
+		}
+	}
+	var plte, trns []byte
+	opaque := true
+	for _, col := range palette {
+		nrgba := color.NRGBAModel.Convert(col).(color.NRGBA)
+		plte = append(plte, nrgba.R, nrgba.G, nrgba.B)
+		trns = append(trns, nrgba.A)
+		opaque = opaque && nrgba.A == 0xff
+	}
+	if err := writePNGChunk(w, "PLTE", plte); err != nil {
+		return err
+	}
+	if !opaque {
+		if err := writePNGChunk(w, "tRNS", trns); err != nil {
+			return err
+		}
+	}
+
+	idat := bufio.NewWriterSize(chunkWriter{w, "IDAT"}, idatSize)
+	z := zlib.NewWriter(idat)
+
+	// The cells on each row of the maze, by their place on path, to find the parts
+	// of the solution crossing each band.
+	onRow := make([][]int, c.Height)
+	for k, cell := range path {
+		onRow[cell/c.Width] = append(onRow[cell/c.Width], k)
+	}
+	frame := c.frame()
+	openings := frame.openSides()
+	cellRow := func(y int) int {
+		// Floor division, for the rows of the top margin.
+		if y < margin {
+			return -1 - (margin-y-1)/size
+		}
+		return (y - margin) / size
+	}
+
+	line := make([]byte, 1+width) // filter type 0, then a palette index per pixel
+	rows := max(1, bandPixels/width)
+	pix := make([]byte, 4*width*min(rows, height)) // shared by every band
+	for top := 0; top < height; top += rows {
+		bottom := min(top+rows, height)
+		band := &image.RGBA{Pix: pix, Stride: 4 * width, Rect: image.Rect(0, top, width, bottom)}
+		draw.Draw(band, band.Bounds(), &image.Uniform{o.Background}, image.Point{}, draw.Src)
+		draw.Draw(band, image.Rect(margin, margin, width-margin, height-margin), &image.Uniform{o.PassageColor}, image.Point{}, draw.Src)
+
+		// Walls and the solution reach at most one row of cells past those the
+		// band covers.
+		first, last := cellRow(top)-1, cellRow(bottom-1)+1
+		o.drawWalls(band, c, c.Width, c.Height, min(max(first, 0), c.Height), min(max(last, 0), c.Height), openings)
+
+		steps := []int{}
+		for y := max(first, 0); y <= min(last, c.Height-1); y++ {
+			steps = append(steps, onRow[y]...)
+		}
+		slices.Sort(steps)
+		for start := 0; start < len(steps); {
+			end := start + 1
+			for end < len(steps) && steps[end] == steps[end-1]+1 {
+				end++
+			}
+			o.drawRectangleSolution(band, frame, path[steps[start]:steps[end-1]+1])
+			start = end
+		}
+
+		for y := top; y < bottom; y++ {
+			pixels := band.Pix[(y-top)*band.Stride:]
+			for x := range width {
+				p := pixels[4*x : 4*x+4]
+				line[1+x] = index[color.RGBA{R: p[0], G: p[1], B: p[2], A: p[3]}]
+			}
+			if _, err := z.Write(line); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := z.Close(); err != nil {
+		return err
+	}
+	if err := idat.Flush(); err != nil {
+		return err
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
+
+// chunkWriter writes each Write as a PNG chunk of its type.
+type chunkWriter struct {
+	w         io.Writer
+	chunkType string
+}
+
+func (cw chunkWriter) Write(data []byte) (int, error) {
+	if err := writePNGChunk(cw.w, cw.chunkType, data); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// EncodeCompactSVG writes c as EncodeSVG writes a Rectangle, a row of walls at a
+// time. Passages are filled with a single rectangle, and the heat map is not
+// supported.
+func (o RenderOptions) EncodeCompactSVG(w io.Writer, c *Compact, path []int, metadata map[string]string) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	if o.HeatMap {
+		return fmt.Errorf("%w: heat map", ErrUnsupportedCompact)
+	}
+
+	frame := c.frame()
+	openings := frame.openSides()
+	size := o.CellSize
+	bw := bufio.NewWriter(w)
+	o.writeSVGStart(bw, float64(c.Width*size), float64(c.Height*size), metadata)
+
+	if !sameColor(o.PassageColor, o.Background) {
+		fmt.Fprintf(bw, `<rect x="0" y="0" width="%d" height="%d" fill="%s"/>`+"\n", c.Width*size, c.Height*size, svgColor(o.PassageColor))
+	}
+
+	cap := "square"
+	if o.RoundCorners {
+		cap = "round"
+	}
+	fmt.Fprintf(bw, `<path fill="none" stroke="%s" stroke-width="%d" stroke-linecap="%s" d="`, svgColor(o.WallColor), o.WallThickness, cap)
+	for y := 0; y <= c.Height; y++ {
+		for x := 0; x <= c.Width; x++ {
+			if x < c.Width && c.horizontalWall(x, y, openings) {
+				fmt.Fprintf(bw, "M%d %dh%d", x*size, y*size, size)
+			}
+			if y < c.Height && c.verticalWall(x, y, openings) {
+				fmt.Fprintf(bw, "M%d %dv%d", x*size, y*size, size)
+			}
+		}
+	}
+	io.WriteString(bw, `"/>`+"\n")
+
+	if len(path) > 0 {
+		o.writeSVGSolution(bw, svgSolutionPoints(frame, path, openings, float64(size)))
+	}
+
+	io.WriteString(bw, "</svg>\n")
+	return bw.Flush()
+}
+
+// WriteCompact is WriteMaze for compact mazes, which can only be written as PNG
+// or SVG. The file is written as it is drawn.
+func (o RenderOptions) WriteCompact(c *Compact, filename string, format Format, path []int, metadata map[string]string) error {
+	if format != PNG && format != SVG {
+		return fmt.Errorf("%w: %v files", ErrUnsupportedCompact, format)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	bw := bufio.NewWriter(file)
+	if err := o.EncodeCompact(bw, c, format, path, metadata); err != nil {
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	return file.Close()
+}
+
+// EncodeCompact is WriteCompact writing to w instead of a file.
+func (o RenderOptions) EncodeCompact(w io.Writer, c *Compact, format Format, path []int, metadata map[string]string) error {
+	switch format {
+	case PNG:
+		return o.EncodeCompactPNG(w, c, path, metadata)
+	case SVG:
+		return o.EncodeCompactSVG(w, c, path, metadata)
+	}
+	return fmt.Errorf("%w: %v files", ErrUnsupportedCompact, format)
+}
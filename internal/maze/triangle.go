@@ -0,0 +1,45 @@
+package maze
+
+import (
+	"image"
+	"math"
+)
+
+// triangleCells lays out equilateral triangles with sides of SquareSize, pointing
+// up and down in turn along each row. The top-left triangle points up. Sides run
+// clockwise: right, bottom and left for upward triangles; top, right and left for
+// downward ones.
+func triangleCells(width, height int) ([]gridCell, Point) {
+	side := float64(SquareSize)
+	h := side * math.Sqrt(3) / 2
+
+	index := func(col, row int) int {
+		if col < 0 || row < 0 || col >= width || row >= height {
+			return -1
+		}
+		return row*width + col
+	}
+
+	cells := make([]gridCell, width*height)
+	for row := range height {
+		for col := range width {
+			left, top := float64(col)*side/2, float64(row)*h
+			var corners []Point
+			var neighbors []int
+			if (col+row)%2 == 0 {
+				corners = []Point{{left + side/2, top}, {left + side, top + h}, {left, top + h}}
+				neighbors = []int{index(col+1, row), index(col, row+1), index(col-1, row)}
+			} else {
+				corners = []Point{{left, top}, {left + side, top}, {left + side/2, top + h}}
+				neighbors = []int{index(col, row-1), index(col+1, row), index(col-1, row)}
+			}
+			cells[index(col, row)] = gridCell{
+				position: image.Pt(col, row),
+				center:   centroid(corners),
+				edges:    polygonEdges(corners, neighbors),
+			}
+		}
+	}
+
+	return cells, Point{X: side * float64(width+1) / 2, Y: h * float64(height)}
+}
@@ -0,0 +1,227 @@
+package maze
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"math/rand/v2"
+
+	"tsumegolang/pkg/ds/disjointset"
+)
+
+// forcedWeight is lighter than any random weight, so Kruskal and Prim carve the
+// passages of every crossing before all others.
+const forcedWeight = -1
+
+// WithWeave lets passages tunnel under perpendicular corridors. Each interior cell
+// is tried as a crossing with probability density, from 0 to 1; a crossing
+// carries a passage straight over it while another passes underneath, joining
+// the cells on either side. Only square mazes carved by Kruskal or Prim, with or
+// without a mask, can be woven, and not with loops or animation.
+func WithWeave(density float64) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.weave = density
+	}
+}
+
+// checkWeave reports whether the maze can be woven as asked.
+func (mg *MazeGenerator) checkWeave() error {
+	if mg.weave == 0 {
+		return nil
+	}
+	if mg.weave < 0 || mg.weave > 1 {
+		return fmt.Errorf("%w: weave density %g is not between 0 and 1", ErrUnsupportedShape, mg.weave)
+	}
+	if mg.shape != Square || mg.recursionLevel > 0 || mg.levels != nil || mg.braid > 0 || mg.extraPassages > 0 || mg.animationFile != "" {
+		return fmt.Errorf("%w: only square mazes without recursion, levels, loops or animation can be woven", ErrUnsupportedShape)
+	}
+	if mg.algorithm != Kruskal && mg.algorithm != Prim {
+		return fmt.Errorf("%w: %v cannot weave; use kruskal or prim", ErrUnsupportedAlgorithm, mg.algorithm)
+	}
+	return nil
+}
+
+// layCrossings turns interior cells of r, tried in random order with probability
+// density, into crossings among its candidate edges. A crossing keeps the edges to
+// the cells at the ends of its passage and trades the other two for an edge
+// between the cells at the ends of the tunnel under it, all with forcedWeight.
+// Cells next to a crossing cannot be crossings, and no crossing joins cells
+// already joined through another, so the forced edges form a forest that every
+// spanning tree of the lightest edges contains.
+func layCrossings(r *Rectangle, density float64, rng *rand.Rand) error {
+	if density <= 0 {
+		return nil
+	}
+	n := r.Width * r.Height
+	sets, err := disjointset.NewDisjointSet(disjointset.WithCapacity(n))
+	if err != nil {
+		return err
+	}
+	if _, err := sets.AddMany(n); err != nil {
+		return err
+	}
+	blocked := make([]bool, n) // crossings, their neighbors and the cells they join
+
+	for _, cell := range rng.Perm(n) {
+		if rng.Float64() >= density || blocked[cell] {
+			continue
+		}
+		i, j := r.GraphToRect(cell)
+		if r.outside(i-1, j) || r.outside(i+1, j) || r.outside(i, j-1) || r.outside(i, j+1) {
+			continue
+		}
+		north, south, west, east := cell-r.Width, cell+r.Width, cell-1, cell+1
+		ends := []int{cell, north, south, west, east}
+		roots := make(map[int]bool, len(ends))
+		for _, c := range ends {
+			root, err := sets.Find(c)
+			if err != nil {
+				return err
+			}
+			roots[root] = true
+		}
+		if len(roots) < len(ends) {
+			continue
+		}
+
+		// The passage over the crossing runs north-south or east-west.
+		over, under := [2]int{north, south}, [2]int{west, east}
+		if rng.IntN(2) == 0 {
+			over, under = under, over
+		}
+		for _, c := range ends[1:] {
+			if err := r.Graph.Disconnect(cell, c); err != nil {
+				return err
+			}
+		}
+		edges := [][2]int{{cell, over[0]}, {cell, over[1]}, under}
+		for _, e := range edges {
+			if err := r.Graph.Connect(e[0], e[1], forcedWeight); err != nil {
+				return err
+			}
+			a, _ := sets.Find(e[0])
+			b, _ := sets.Find(e[1])
+			if err := sets.Union(a, b); err != nil {
+				return err
+			}
+		}
+		for _, c := range ends {
+			blocked[c] = true
+		}
+	}
+	return nil
+}
+
+// tunnels reports whether a passage runs under cell from west to east, and
+// whether one runs under it from north to south: whether the cells on either
+// side of it are joined directly.
+func (r *Rectangle) tunnels(cell int) (bool, bool) {
+	i, j := r.GraphToRect(cell)
+	var horizontal, vertical bool
+	if j > 0 && j < r.Width-1 {
+		_, horizontal = r.Graph.GetEdge(cell-1, cell+1)
+	}
+	if i > 0 && i < r.Height-1 {
+		_, vertical = r.Graph.GetEdge(cell-r.Width, cell+r.Width)
+	}
+	return horizontal, vertical
+}
+
+// Crossings lists the cells with a passage tunneling under them, in order.
+func (r *Rectangle) Crossings() []int {
+	crossings := []int{}
+	for cell := range r.Width * r.Height {
+		if horizontal, vertical := r.tunnels(cell); horizontal || vertical {
+			crossings = append(crossings, cell)
+		}
+	}
+	return crossings
+}
+
+// woven reports whether any passage of r tunnels under a cell.
+func (r *Rectangle) woven() bool {
+	for _, e := range r.Graph.GetAllEdges() {
+		if d := e.To.(int) - e.From.(int); d == 2 || d == 2*r.Width {
+			return true
+		}
+	}
+	return false
+}
+
+// crossingWalls returns the walls drawn in a crossing at column x and row y of a
+// drawing with cells size across: the outlines of the corners left beside the
+// passages, which are inset by size/4, and the walls of the passage on top
+// across the mouths of the tunnel. horizontal is set when the tunnel runs from
+// west to east.
+func crossingWalls(x, y, size int, horizontal bool) [][2]image.Point {
+	inset := size / 4
+	left, top := x*size, y*size
+	walls := [][2]image.Point{}
+	for _, cx := range []int{left, left + size - inset} {
+		for _, cy := range []int{top, top + size - inset} {
+			walls = append(walls,
+				[2]image.Point{{cx, cy}, {cx + inset, cy}},
+				[2]image.Point{{cx, cy + inset}, {cx + inset, cy + inset}},
+				[2]image.Point{{cx, cy}, {cx, cy + inset}},
+				[2]image.Point{{cx + inset, cy}, {cx + inset, cy + inset}},
+			)
+		}
+	}
+	if horizontal {
+		// The passage on top runs north-south across the tunnel's mouths.
+		for _, wx := range []int{left + inset, left + size - inset} {
+			walls = append(walls, [2]image.Point{{wx, top + inset}, {wx, top + size - inset}})
+		}
+	} else {
+		for _, wy := range []int{top + inset, top + size - inset} {
+			walls = append(walls, [2]image.Point{{left + inset, wy}, {left + size - inset, wy}})
+		}
+	}
+	return walls
+}
+
+// tunnel reports whether the step from cell a to cell b of a path passes under
+// the cell between them.
+func (r *Rectangle) tunnel(a, b int) bool {
+	ai, aj := r.GraphToRect(a)
+	bi, bj := r.GraphToRect(b)
+	return abs(ai-bi)+abs(aj-bj) == 2
+}
+
+// drawCrossings draws the walls of every crossing of r over its cells, centered
+// on their lines as drawWalls centers the walls between cells.
+func (o RenderOptions) drawCrossings(m *image.RGBA, r *Rectangle) {
+	size, t := o.CellSize, o.WallThickness
+	off := (t+1)/2 - o.Margin
+	for _, cell := range r.Crossings() {
+		i, j := r.GraphToRect(cell)
+		horizontal, _ := r.tunnels(cell)
+		for _, w := range crossingWalls(j, i, size, horizontal) {
+			rect := image.Rect(w[0].X-off, w[0].Y-off, w[1].X-off+t, w[1].Y-off+t)
+			o.drawWall(m, rect, o.WallColor)
+		}
+	}
+}
+
+// tunnelMouth returns where the solution drawn from a toward b, centers of cells
+// two apart, stops at the mouth of the tunnel between them, leaving a gap under
+// the passage on top.
+func tunnelMouth(a, b Point, size float64) Point {
+	f := (size/2 + size/8) / (2 * size)
+	return Point{a.X + (b.X-a.X)*f, a.Y + (b.Y-a.Y)*f}
+}
+
+// splitTunnels breaks the solution drawn through points, centers of cells size
+// apart, where it passes under a crossing, so each piece ends at a tunnel mouth.
+func splitTunnels(points []Point, size float64) [][]Point {
+	pieces := [][]Point{}
+	piece := []Point{}
+	for k, p := range points {
+		if k > 0 && math.Hypot(p.X-points[k-1].X, p.Y-points[k-1].Y) > 1.5*size {
+			pieces = append(pieces, append(piece, tunnelMouth(points[k-1], p, size)))
+			piece = []Point{tunnelMouth(p, points[k-1], size)}
+		}
+		piece = append(piece, p)
+	}
+	return append(pieces, piece)
+}
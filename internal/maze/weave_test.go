@@ -0,0 +1,253 @@
+package maze_test
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"tsumegolang/pkg/ds/disjointset"
+
+	. "tsumegolang/internal/maze"
+)
+
+// newWovenMaze returns a 3x3 maze whose center is a crossing: a passage runs
+// over it from north to south while one tunnels under it from 3 to 5.
+func newWovenMaze(t *testing.T) *Rectangle {
+	t.Helper()
+
+	r, err := NewRectangle(3, 3, NoConnect)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, passage := range [][2]int{{1, 4}, {4, 7}, {3, 5}, {0, 1}, {1, 2}, {0, 3}, {6, 7}, {7, 8}} {
+		if err := r.Graph.Connect(passage[0], passage[1], DefaultWeight); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	r.Openings = []int{0, 5}
+	return r
+}
+
+// assertWovenTree checks that the passages of r form a tree covering every cell,
+// joining neighbors or the cells at either end of a tunnel.
+func assertWovenTree(t *testing.T, r *Rectangle) {
+	t.Helper()
+
+	n := r.Width * r.Height
+	edges := r.Graph.GetAllEdges()
+	if len(edges) != n-1 {
+		t.Fatalf("want %d passages in a spanning tree of %d cells, got %d", n-1, n, len(edges))
+	}
+	ds, err := disjointset.NewDisjointSet(disjointset.WithCapacity(n))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := ds.AddMany(n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, e := range edges {
+		from, to := e.From.(int), e.To.(int)
+		dx := from%r.Width - to%r.Width
+		dy := from/r.Width - to/r.Width
+		if d := dx*dx + dy*dy; d != 1 && d != 4 {
+			t.Fatalf("passage %d-%d joins neither neighbors nor the ends of a tunnel", from, to)
+		}
+		a, _ := ds.Find(from)
+		b, _ := ds.Find(to)
+		if a == b {
+			t.Fatalf("passage %d-%d closes a loop", from, to)
+		}
+		if err := ds.Union(a, b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestWovenMaze(t *testing.T) {
+	r := newWovenMaze(t)
+	if got := r.Crossings(); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("(Crossings) want [4], got %v", got)
+	}
+
+	for _, solver := range []Solver{BFS, AStar} {
+		path, err := solver.Solve(r, 0, 5)
+		if err != nil {
+			t.Fatalf("(Solve) unexpected error: %v", err)
+		}
+		if want := []int{0, 3, 5}; !reflect.DeepEqual(path, want) {
+			t.Errorf("(Solve) want %v through the tunnel with %v, got %v", want, solver, path)
+		}
+	}
+
+	var sb strings.Builder
+	if err := EncodeText(&sb, r, nil); err != nil {
+		t.Fatalf("(EncodeText) unexpected error: %v", err)
+	}
+	// The crossing is open on every side, with its cell marked; the exit opens
+	// east of it.
+	want := "" +
+		"╷   ╶───────┐\n" +
+		"│           │\n" +
+		"│       ╶───┘\n" +
+		"│     ┼\n" +
+		"├───╴   ╶───┐\n" +
+		"│           │\n" +
+		"└───────────┘\n"
+	if sb.String() != want {
+		t.Errorf("(EncodeText) want\n%s\ngot\n%s", want, sb.String())
+	}
+
+	// The solution breaks where it dives under the crossing.
+	sb.Reset()
+	if err := EncodeSVG(&sb, r, []int{0, 3, 5}, nil); err != nil {
+		t.Fatalf("(EncodeSVG) unexpected error: %v", err)
+	}
+	if got := strings.Count(sb.String(), "<polyline"); got != 2 {
+		t.Errorf("(EncodeSVG) want the solution in 2 pieces, got %d", got)
+	}
+
+	g := NewGame(r, 0, 5)
+	for _, d := range []Direction{South, East} {
+		if !g.Move(d) {
+			t.Fatalf("(Move) want to move %v from %d", d, g.Position())
+		}
+	}
+	if !g.Won() || g.Moves() != 2 {
+		t.Errorf("(Move) want to win in 2 moves through the tunnel, got cell %d after %d", g.Position(), g.Moves())
+	}
+}
+
+func TestGenerateWoven(t *testing.T) {
+	for _, algorithm := range []Algorithm{Kruskal, Prim} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			mg := NewMazeGenerator(16, 12, WithAlgorithm(algorithm), WithSeed(4), WithWeave(1))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+			r := mg.Maze()
+			assertWovenTree(t, r)
+
+			crossings := r.Crossings()
+			if len(crossings) == 0 {
+				t.Fatalf("(Crossings) want crossings at full density")
+			}
+			for _, cell := range crossings {
+				// A crossing joins two of its neighbors on top and none underneath.
+				joined := 0
+				for _, next := range []int{cell - r.Width, cell + 1, cell + r.Width, cell - 1} {
+					if _, ok := r.Graph.GetEdge(cell, next); ok {
+						joined++
+					}
+				}
+				if joined != 2 {
+					t.Errorf("(Generate) want crossing %d joined to 2 neighbors, got %d", cell, joined)
+				}
+				if slices.Contains(crossings, cell+1) || slices.Contains(crossings, cell+r.Width) {
+					t.Errorf("(Generate) want no crossing next to crossing %d", cell)
+				}
+			}
+
+			bfs, err := BFS.Solve(r, 0, r.Width*r.Height-1)
+			if err != nil {
+				t.Fatalf("(Solve) unexpected error: %v", err)
+			}
+			aStar, err := AStar.Solve(r, 0, r.Width*r.Height-1)
+			if err != nil {
+				t.Fatalf("(Solve) unexpected error: %v", err)
+			}
+			if len(aStar) != len(bfs) {
+				t.Errorf("(Solve) want A* to find a path of %d cells, got %d", len(bfs), len(aStar))
+			}
+
+			// Without crossings the maze is the one carved without weaving.
+			plain := NewMazeGenerator(16, 12, WithAlgorithm(algorithm), WithSeed(4))
+			flat := NewMazeGenerator(16, 12, WithAlgorithm(algorithm), WithSeed(4), WithWeave(0))
+			for _, g := range []*MazeGenerator{plain, flat} {
+				if err := g.Generate(); err != nil {
+					t.Fatalf("(Generate) unexpected error: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(plain.Maze().Graph.GetAllEdges(), flat.Maze().Graph.GetAllEdges()) {
+				t.Errorf("(Generate) want a weave of 0 to carve the plain maze")
+			}
+		})
+	}
+}
+
+func TestGenerateWovenMasked(t *testing.T) {
+	mask, err := ParseMaskText(strings.NewReader("" +
+		"..........\n" +
+		"..........\n" +
+		"....XX....\n" +
+		"....XX....\n" +
+		"..........\n" +
+		"..........\n"))
+	if err != nil {
+		t.Fatalf("(ParseMaskText) unexpected error: %v", err)
+	}
+	mg := NewMazeGenerator(0, 0, WithMask(mask), WithSeed(1), WithWeave(1))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	r := mg.Maze()
+	for _, cell := range r.Crossings() {
+		i, j := r.GraphToRect(cell)
+		for _, p := range [][2]int{{i - 1, j}, {i + 1, j}, {i, j - 1}, {i, j + 1}} {
+			if !r.Included(r.RectToGraph(p[0], p[1])) {
+				t.Errorf("(Generate) want crossing %d away from the mask", cell)
+			}
+		}
+	}
+}
+
+func TestGenerateWovenMetadata(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "maze.png")
+	mg := NewMazeGenerator(10, 10, WithSeed(7), WithWeave(0.6), WithFilename(filename))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	metadata, err := ReadMetadata(filename)
+	if err != nil {
+		t.Fatalf("(ReadMetadata) unexpected error: %v", err)
+	}
+	if metadata[MetadataWeave] != "0.6" {
+		t.Errorf("(ReadMetadata) want weave 0.6, got %q", metadata[MetadataWeave])
+	}
+	again, err := NewMazeGeneratorFromMetadata(metadata)
+	if err != nil {
+		t.Fatalf("(NewMazeGeneratorFromMetadata) unexpected error: %v", err)
+	}
+	if err := again.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(again.Maze().Graph.GetAllEdges(), mg.Maze().Graph.GetAllEdges()) {
+		t.Errorf("(Generate) want the same maze from the metadata")
+	}
+}
+
+func TestGenerateWovenUnsupported(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []GeneratorOptions
+		wantErr error
+	}{
+		{name: "density", opts: []GeneratorOptions{WithWeave(1.5)}, wantErr: ErrUnsupportedShape},
+		{name: "algorithm", opts: []GeneratorOptions{WithWeave(0.5), WithAlgorithm(Backtracker)}, wantErr: ErrUnsupportedAlgorithm},
+		{name: "shape", opts: []GeneratorOptions{WithWeave(0.5), WithShape(Hex)}, wantErr: ErrUnsupportedShape},
+		{name: "recursion", opts: []GeneratorOptions{WithWeave(0.5), WithRecursionLevel(1)}, wantErr: ErrUnsupportedShape},
+		{name: "braid", opts: []GeneratorOptions{WithWeave(0.5), WithBraid(0.5)}, wantErr: ErrUnsupportedShape},
+		{name: "floors", opts: []GeneratorOptions{WithWeave(0.5), WithFloors(2)}, wantErr: ErrUnsupportedShape},
+		{name: "compact", opts: []GeneratorOptions{WithWeave(0.5), WithCompact(), WithAlgorithm(Eller)}, wantErr: ErrUnsupportedCompact},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := NewMazeGenerator(6, 6, tc.opts...).Generate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package maze
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WeightFunc scales the random weight of the candidate passage from the cell in
+// column x and row y toward dir, East or South. Kruskal and Prim carve the
+// lightest passages first, so a passage scaled by less than 1 is more likely to
+// be carved, and one scaled by 0 is carved whenever it does not close a loop.
+type WeightFunc func(x, y int, dir Direction) float64
+
+// BiasKind selects the pattern a Bias favors.
+type BiasKind int
+
+const (
+	Horizontal BiasKind = iota // long east-west corridors
+	Vertical                   // long north-south corridors
+	Radial                     // corridors running out from the center
+	Circular                   // corridors circling the center
+)
+
+var ErrInvalidBias = errors.New("invalid maze bias")
+
+var biasNames = []string{
+	Horizontal: "horizontal",
+	Vertical:   "vertical",
+	Radial:     "radial",
+	Circular:   "circular",
+}
+
+func (k BiasKind) String() string {
+	if k < 0 || int(k) >= len(biasNames) {
+		return fmt.Sprintf("BiasKind(%d)", int(k))
+	}
+	return biasNames[k]
+}
+
+// BiasNames lists the kinds of bias accepted by ParseBias.
+func BiasNames() []string {
+	return slices.Clone(biasNames)
+}
+
+// Bias is a preset WeightFunc favoring a pattern with a strength from 0, no
+// bias, to 1, where the favored passages are always carved first.
+type Bias struct {
+	Kind     BiasKind
+	Strength float64
+}
+
+func (b Bias) String() string {
+	return b.Kind.String() + "=" + strconv.FormatFloat(b.Strength, 'g', -1, 64)
+}
+
+// ParseBias reads a bias written as kind=strength, such as "horizontal=0.8".
+func ParseBias(s string) (Bias, error) {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok {
+		return Bias{}, fmt.Errorf("%w: %q: want kind=strength", ErrInvalidBias, s)
+	}
+	kind := slices.Index(biasNames, strings.ToLower(name))
+	if kind < 0 {
+		return Bias{}, fmt.Errorf("%w: unknown kind %q", ErrInvalidBias, name)
+	}
+	strength, err := strconv.ParseFloat(value, 64)
+	if err != nil || strength < 0 || strength > 1 {
+		return Bias{}, fmt.Errorf("%w: strength %q is not between 0 and 1", ErrInvalidBias, value)
+	}
+	return Bias{Kind: BiasKind(kind), Strength: strength}, nil
+}
+
+// Weights returns the WeightFunc of the bias for a width x height maze.
+func (b Bias) Weights(width, height int) WeightFunc {
+	s := b.Strength
+	center := Point{float64(width) / 2, float64(height) / 2}
+	// along is the cosine of the angle between the passage and the line from
+	// the center to its middle.
+	along := func(x, y int, dir Direction) float64 {
+		step := Point{1, 0}
+		if dir == South {
+			step = Point{0, 1}
+		}
+		v := Point{float64(x) + 0.5 + step.X/2 - center.X, float64(y) + 0.5 + step.Y/2 - center.Y}
+		length := math.Hypot(v.X, v.Y)
+		if length == 0 {
+			return 0
+		}
+		return math.Abs(v.X*step.X+v.Y*step.Y) / length
+	}
+
+	switch b.Kind {
+	case Horizontal, Vertical:
+		favored := East
+		if b.Kind == Vertical {
+			favored = South
+		}
+		return func(x, y int, dir Direction) float64 {
+			if dir == favored {
+				return 1 - s
+			}
+			return 1
+		}
+	case Radial:
+		return func(x, y int, dir Direction) float64 {
+			return 1 - s*along(x, y, dir)
+		}
+	case Circular:
+		return func(x, y int, dir Direction) float64 {
+			cos := along(x, y, dir)
+			return 1 - s*math.Sqrt(1-cos*cos)
+		}
+	}
+	return func(int, int, Direction) float64 { return 1 }
+}
+
+// TextureWeights returns a WeightFunc for a width x height maze favoring the
+// passages over the darker parts of img, which is stretched over the maze. With
+// strength 1 passages over black are carved before all others, so the dark and
+// light regions form sub-mazes joined by few passages.
+func TextureWeights(img image.Image, width, height int, strength float64) WeightFunc {
+	bounds := img.Bounds()
+	return func(x, y int, dir Direction) float64 {
+		// The middle of the passage, on the shared edge of the two cells.
+		mx, my := float64(x)+0.5, float64(y)+0.5
+		if dir == South {
+			my += 0.5
+		} else {
+			mx += 0.5
+		}
+		px := bounds.Min.X + min(int(mx*float64(bounds.Dx())/float64(width)), bounds.Dx()-1)
+		py := bounds.Min.Y + min(int(my*float64(bounds.Dy())/float64(height)), bounds.Dy()-1)
+		gray := color.Gray16Model.Convert(img.At(px, py)).(color.Gray16)
+		darkness := 1 - float64(gray.Y)/0xffff
+		return 1 - strength*darkness
+	}
+}
+
+// WithWeights biases the maze with f, which scales the random weight of every
+// candidate passage. Only square mazes carved by Kruskal or Prim, with or without
+// a mask, can be weighted; the weights are not stored in the metadata.
+func WithWeights(f WeightFunc) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.weights = f
+	}
+}
+
+// WithBias biases the maze with a preset, as WithWeights does. The bias is
+// stored in the metadata.
+func WithBias(b Bias) GeneratorOptions {
+	return func(mg *MazeGenerator) {
+		mg.bias = &b
+	}
+}
+
+// weightFunc returns the WeightFunc set by WithWeights or WithBias, or nil.
+func (mg *MazeGenerator) weightFunc() WeightFunc {
+	switch {
+	case mg.weights != nil:
+		return mg.weights
+	case mg.bias != nil:
+		return mg.bias.Weights(mg.width, mg.height)
+	}
+	return nil
+}
+
+// checkWeights reports whether the maze can be weighted as asked.
+func (mg *MazeGenerator) checkWeights() error {
+	if mg.weightFunc() == nil {
+		return nil
+	}
+	if mg.shape != Square || mg.recursionLevel > 0 || mg.levels != nil {
+		return fmt.Errorf("%w: only square mazes without recursion or levels can be weighted", ErrUnsupportedShape)
+	}
+	if mg.algorithm != Kruskal && mg.algorithm != Prim {
+		return fmt.Errorf("%w: %v ignores weights; use kruskal or prim", ErrUnsupportedAlgorithm, mg.algorithm)
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+package maze_test
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "tsumegolang/internal/maze"
+)
+
+func TestParseBias(t *testing.T) {
+	testCases := []struct {
+		input   string
+		want    Bias
+		wantErr error
+	}{
+		{input: "horizontal=0.8", want: Bias{Kind: Horizontal, Strength: 0.8}},
+		{input: "Radial=1", want: Bias{Kind: Radial, Strength: 1}},
+		{input: "circular=0", want: Bias{Kind: Circular}},
+		{input: "horizontal", wantErr: ErrInvalidBias},
+		{input: "diagonal=0.5", wantErr: ErrInvalidBias},
+		{input: "vertical=1.5", wantErr: ErrInvalidBias},
+		{input: "vertical=much", wantErr: ErrInvalidBias},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := ParseBias(tc.input)
+			if !errors.Is(err, tc.wantErr) {
+				t.Fatalf("(ParseBias) want error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if got != tc.want {
+				t.Errorf("(ParseBias) want %+v, got %+v", tc.want, got)
+			}
+			if again, _ := ParseBias(got.String()); again != got {
+				t.Errorf("(String) want %q to parse back to %+v, got %+v", got.String(), got, again)
+			}
+		})
+	}
+}
+
+func TestBiasWeights(t *testing.T) {
+	testCases := []struct {
+		name string
+		bias Bias
+		x, y int
+		dir  Direction
+		want float64
+	}{
+		{name: "horizontal east", bias: Bias{Horizontal, 0.8}, dir: East, want: 0.2},
+		{name: "horizontal south", bias: Bias{Horizontal, 0.8}, dir: South, want: 1},
+		{name: "vertical south", bias: Bias{Vertical, 0.5}, x: 3, y: 2, dir: South, want: 0.5},
+		// In a 4x4 maze the passage south of cell 3,1 crosses the spoke from the
+		// center through its middle at 3.5,2; the passage east of 2,1 runs
+		// close to the spoke through 3,1.5.
+		{name: "radial across a spoke", bias: Bias{Radial, 1}, x: 3, y: 1, dir: South, want: 1},
+		{name: "radial along a spoke", bias: Bias{Radial, 1}, x: 2, y: 1, dir: East, want: 1 - 1/math.Sqrt(1.25)},
+		{name: "circular across a spoke", bias: Bias{Circular, 1}, x: 3, y: 1, dir: South, want: 0},
+		{name: "no strength", bias: Bias{Circular, 0}, x: 3, y: 1, dir: South, want: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := tc.bias.Weights(4, 4)(tc.x, tc.y, tc.dir)
+			if diff := got - tc.want; diff < -1e-9 || diff > 1e-9 {
+				t.Errorf("(Weights) want %g, got %g", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTextureWeights(t *testing.T) {
+	// The left half of the texture is black and the right half white.
+	img := image.NewGray(image.Rect(0, 0, 100, 50))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, 50, 50), image.Black, image.Point{}, draw.Src)
+	weights := TextureWeights(img, 10, 5, 0.5)
+
+	if got := weights(1, 2, South); got != 0.5 {
+		t.Errorf("(TextureWeights) want 0.5 over black, got %g", got)
+	}
+	if got := weights(7, 2, East); got != 1 {
+		t.Errorf("(TextureWeights) want 1 over white, got %g", got)
+	}
+	gray := image.NewUniform(color.Gray{Y: 0x80})
+	if got := TextureWeights(gray, 10, 5, 1)(0, 0, East); got < 0.49 || got > 0.51 {
+		t.Errorf("(TextureWeights) want about 0.5 over gray, got %g", got)
+	}
+}
+
+func TestGenerateWeighted(t *testing.T) {
+	for _, algorithm := range []Algorithm{Kruskal, Prim} {
+		t.Run(algorithm.String(), func(t *testing.T) {
+			// At full strength every passage along a row is carved.
+			mg := NewMazeGenerator(12, 8, WithAlgorithm(algorithm), WithSeed(2), WithBias(Bias{Horizontal, 1}))
+			if err := mg.Generate(); err != nil {
+				t.Fatalf("(Generate) unexpected error: %v", err)
+			}
+			horizontal := 0
+			for _, e := range mg.Maze().Graph.GetAllEdges() {
+				if e.To.(int)-e.From.(int) == 1 {
+					horizontal++
+				}
+			}
+			if horizontal != 11*8 {
+				t.Errorf("(Generate) want %d passages along the rows, got %d", 11*8, horizontal)
+			}
+			assertSpanningTree(t, mg.Maze(), mg.Maze().Graph.GetAllEdges())
+
+			// Weights of 1 change nothing.
+			plain := NewMazeGenerator(12, 8, WithAlgorithm(algorithm), WithSeed(2))
+			even := NewMazeGenerator(12, 8, WithAlgorithm(algorithm), WithSeed(2),
+				WithWeights(func(int, int, Direction) float64 { return 1 }))
+			for _, g := range []*MazeGenerator{plain, even} {
+				if err := g.Generate(); err != nil {
+					t.Fatalf("(Generate) unexpected error: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(plain.Maze().Graph.GetAllEdges(), even.Maze().Graph.GetAllEdges()) {
+				t.Errorf("(Generate) want weights of 1 to carve the unweighted maze")
+			}
+		})
+	}
+}
+
+func TestGenerateWeightedMetadata(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "maze.png")
+	mg := NewMazeGenerator(10, 10, WithSeed(7), WithBias(Bias{Radial, 0.7}), WithFilename(filename))
+	if err := mg.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	metadata, err := ReadMetadata(filename)
+	if err != nil {
+		t.Fatalf("(ReadMetadata) unexpected error: %v", err)
+	}
+	if metadata[MetadataBias] != "radial=0.7" {
+		t.Errorf("(ReadMetadata) want bias radial=0.7, got %q", metadata[MetadataBias])
+	}
+	again, err := NewMazeGeneratorFromMetadata(metadata)
+	if err != nil {
+		t.Fatalf("(NewMazeGeneratorFromMetadata) unexpected error: %v", err)
+	}
+	if err := again.Generate(); err != nil {
+		t.Fatalf("(Generate) unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(again.Maze().Graph.GetAllEdges(), mg.Maze().Graph.GetAllEdges()) {
+		t.Errorf("(Generate) want the same maze from the metadata")
+	}
+}
+
+func TestGenerateWeightedUnsupported(t *testing.T) {
+	testCases := []struct {
+		name    string
+		opts    []GeneratorOptions
+		wantErr error
+	}{
+		{name: "algorithm", opts: []GeneratorOptions{WithAlgorithm(Backtracker)}, wantErr: ErrUnsupportedAlgorithm},
+		{name: "shape", opts: []GeneratorOptions{WithShape(Hex)}, wantErr: ErrUnsupportedShape},
+		{name: "recursion", opts: []GeneratorOptions{WithRecursionLevel(1)}, wantErr: ErrUnsupportedShape},
+		{name: "floors", opts: []GeneratorOptions{WithFloors(2)}, wantErr: ErrUnsupportedShape},
+		{name: "compact", opts: []GeneratorOptions{WithCompact(), WithAlgorithm(Eller)}, wantErr: ErrUnsupportedCompact},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := append([]GeneratorOptions{WithBias(Bias{Vertical, 0.5})}, tc.opts...)
+			if err := NewMazeGenerator(6, 6, opts...).Generate(); !errors.Is(err, tc.wantErr) {
+				t.Errorf("(Generate) want %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
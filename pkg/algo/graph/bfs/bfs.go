@@ -0,0 +1,65 @@
+package bfs
+
+import (
+	"slices"
+
+	"tsumegolang/pkg/algo/graph/common"
+	"tsumegolang/pkg/ds/basic"
+	"tsumegolang/pkg/ds/graph"
+)
+
+// Graph is the interface required by ShortestPath.
+type Graph interface {
+	GetSize() int
+	IsDirected() bool
+	GetAllEdges() []graph.Edge
+}
+
+// ShortestPath returns the nodes on a path from `from` to `to` with the fewest edges,
+// including both endpoints, using breadth-first search. Edge weights are ignored.
+// Returns common.ErrNoPath if `to` cannot be reached.
+func ShortestPath(g Graph, from, to int) ([]int, error) {
+	n := g.GetSize()
+	if from < 0 || from >= n || to < 0 || to >= n {
+		return nil, common.ErrNoSuchRoot
+	}
+
+	adjacent := make([][]int, n)
+	for _, e := range g.GetAllEdges() {
+		i, j := e.From.(int), e.To.(int)
+		adjacent[i] = append(adjacent[i], j)
+		if !g.IsDirected() {
+			adjacent[j] = append(adjacent[j], i)
+		}
+	}
+
+	previous := make([]int, n)
+	for i := range previous {
+		previous[i] = -1
+	}
+	previous[from] = from
+
+	queue := basic.NewQueue[int]()
+	queue.Enqueue(from)
+	for current, ok := queue.Dequeue(); ok && current != to; current, ok = queue.Dequeue() {
+		for _, next := range adjacent[current] {
+			if previous[next] < 0 {
+				previous[next] = current
+				queue.Enqueue(next)
+			}
+		}
+	}
+
+	if previous[to] < 0 {
+		return nil, common.ErrNoPath
+	}
+
+	path := []int{to}
+	for current := to; current != from; {
+		current = previous[current]
+		path = append(path, current)
+	}
+	slices.Reverse(path)
+
+	return path, nil
+}
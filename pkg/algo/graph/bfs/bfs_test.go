@@ -0,0 +1,104 @@
+package bfs_test
+
+import (
+	"slices"
+	"testing"
+
+	. "tsumegolang/pkg/algo/graph/bfs"
+	"tsumegolang/pkg/algo/graph/common"
+	"tsumegolang/pkg/ds/graph"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+func TestShortestPath(t *testing.T) {
+	testCases := []struct {
+		name        string
+		n           int
+		directed    bool
+		connections []graph.Edge
+		from, to    int
+		want        []int
+		wantErr     error
+	}{
+		{
+			name: "same node",
+			n:    2,
+			from: 1,
+			to:   1,
+			want: []int{1},
+		},
+		{
+			name: "fewest edges beat lower weights",
+			n:    4,
+			connections: []graph.Edge{
+				{From: 0, To: 1, Weight: 0.1},
+				{From: 1, To: 2, Weight: 0.1},
+				{From: 2, To: 3, Weight: 0.1},
+				{From: 0, To: 3, Weight: 9.0},
+			},
+			from: 0,
+			to:   3,
+			want: []int{0, 3},
+		},
+		{
+			name: "undirected edges are walked backwards",
+			n:    4,
+			connections: []graph.Edge{
+				{From: 0, To: 1, Weight: 1},
+				{From: 1, To: 2, Weight: 1},
+				{From: 2, To: 3, Weight: 1},
+			},
+			from: 3,
+			to:   0,
+			want: []int{3, 2, 1, 0},
+		},
+		{
+			name:     "directed edges are not walked backwards",
+			n:        3,
+			directed: true,
+			connections: []graph.Edge{
+				{From: 0, To: 1, Weight: 1},
+				{From: 1, To: 2, Weight: 1},
+			},
+			from:    2,
+			to:      0,
+			wantErr: common.ErrNoPath,
+		},
+		{
+			name:    "disconnected",
+			n:       3,
+			from:    0,
+			to:      2,
+			wantErr: common.ErrNoPath,
+		},
+		{
+			name:    "no such node",
+			n:       3,
+			from:    0,
+			to:      3,
+			wantErr: common.ErrNoSuchRoot,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := sparsegraph.NewGraph(tc.n, tc.directed)
+			if err != nil {
+				t.Fatalf("NewGraph(%d) failed: %v", tc.n, err)
+			}
+			for _, edge := range tc.connections {
+				if err := g.Connect(edge.From.(int), edge.To.(int), edge.Weight); err != nil {
+					t.Fatalf("Connect(%d, %d, %f) failed: %v", edge.From, edge.To, edge.Weight, err)
+				}
+			}
+
+			got, err := ShortestPath(g, tc.from, tc.to)
+			if err != tc.wantErr {
+				t.Fatalf("ShortestPath() error = %v; want %v", err, tc.wantErr)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("ShortestPath() = %v; want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 var (
 	ErrCycleDetected       = errors.New("cycle detected in the graph")
 	ErrDisconnectedGraph   = errors.New("the graph is disconnected")
+	ErrNoSuchRoot          = errors.New("the root node is not in the graph")
+	ErrNoPath              = errors.New("no path between the nodes")
 	ErrNeedDirectedGraph   = errors.New("this algorithm requires a directed graph")
 	ErrNeedUndirectedGraph = errors.New("this algorithm requires an undirected graph")
 )
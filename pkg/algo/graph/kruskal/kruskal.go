@@ -47,7 +47,9 @@ func MST(original, mst Graph) error {
 		}
 
 		if rootL != rootR {
-			mst.Connect(edge.From.(int), edge.To.(int), edge.Weight)
+			if err := mst.Connect(edge.From.(int), edge.To.(int), edge.Weight); err != nil {
+				return err
+			}
 			if err := ds.Union(rootL, rootR); err != nil {
 				return err
 			}
@@ -0,0 +1,71 @@
+package prim
+
+import (
+	"container/heap"
+
+	"tsumegolang/pkg/algo/graph/common"
+	"tsumegolang/pkg/ds/graph"
+)
+
+// Graph is the interface required by MST.
+type Graph interface {
+	GetSize() int
+	IsDirected() bool
+	Connect(i, j int, w float64) error
+	GetAllEdges() []graph.Edge
+}
+
+// MST computes the minimum spanning tree of original using Prim's algorithm,
+// growing the tree outward from root and storing the result in mst. mst must be
+// empty. original is not modified. Returns common.ErrDisconnectedGraph if some
+// node cannot be reached from root; mst then holds the tree of root's component.
+func MST(original, mst Graph, root int) error {
+	if original.IsDirected() {
+		return common.ErrNeedUndirectedGraph
+	}
+	if root < 0 || root >= original.GetSize() {
+		return common.ErrNoSuchRoot
+	}
+
+	adjacent := make([][]graph.Edge, original.GetSize())
+	for _, e := range original.GetAllEdges() {
+		from, to := e.From.(int), e.To.(int)
+		adjacent[from] = append(adjacent[from], graph.Edge{From: from, To: to, Weight: e.Weight})
+		adjacent[to] = append(adjacent[to], graph.Edge{From: to, To: from, Weight: e.Weight})
+	}
+
+	inTree := make([]bool, original.GetSize())
+	inTree[root] = true
+	reached := 1
+
+	frontier := graph.NewEdgeHeap(append([]graph.Edge{}, adjacent[root]...))
+	for frontier.Len() > 0 {
+		edge, err := frontier.PopEdge()
+		if err != nil {
+			return err
+		}
+
+		to := edge.To.(int)
+		if inTree[to] {
+			continue
+		}
+
+		if err := mst.Connect(edge.From.(int), to, edge.Weight); err != nil {
+			return err
+		}
+		inTree[to] = true
+		reached++
+
+		for _, next := range adjacent[to] {
+			if !inTree[next.To.(int)] {
+				heap.Push(&frontier, next)
+			}
+		}
+	}
+
+	if reached != original.GetSize() {
+		return common.ErrDisconnectedGraph
+	}
+
+	return nil
+}
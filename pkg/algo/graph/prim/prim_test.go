@@ -0,0 +1,159 @@
+package prim_test
+
+import (
+	"testing"
+
+	"tsumegolang/pkg/algo/graph/common"
+	. "tsumegolang/pkg/algo/graph/prim"
+	"tsumegolang/pkg/ds/graph"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+func TestMST(t *testing.T) {
+	testCases := []struct {
+		name        string
+		n           int
+		root        int
+		connections []graph.Edge
+		wantMST     []graph.Edge
+		wantErr     error
+	}{
+		{
+			name: "simple triangle",
+			n:    3,
+			root: 0,
+			connections: []graph.Edge{
+				{From: 0, To: 1, Weight: 1.0},
+				{From: 1, To: 2, Weight: 1.1},
+				{From: 0, To: 2, Weight: 1.5},
+			},
+			wantMST: []graph.Edge{
+				{From: 0, To: 1, Weight: 1.0},
+				{From: 1, To: 2, Weight: 1.1},
+			},
+		},
+		{
+			name: "fully connected square from a corner",
+			n:    4,
+			root: 3,
+			connections: []graph.Edge{
+				{From: 0, To: 1, Weight: 1.1},
+				{From: 1, To: 2, Weight: 2.2},
+				{From: 2, To: 3, Weight: 3.2},
+				{From: 0, To: 3, Weight: 1.3},
+				{From: 0, To: 2, Weight: 1.2},
+				{From: 1, To: 3, Weight: 2.3},
+			},
+			wantMST: []graph.Edge{
+				{From: 0, To: 1, Weight: 1.1},
+				{From: 0, To: 2, Weight: 1.2},
+				{From: 0, To: 3, Weight: 1.3},
+			},
+		},
+		{
+			name: "two disconnected squares",
+			n:    8,
+			root: 0,
+			connections: []graph.Edge{
+				{From: 0, To: 1, Weight: 1.1},
+				{From: 1, To: 2, Weight: 2.2},
+				{From: 2, To: 3, Weight: 3.2},
+				{From: 0, To: 3, Weight: 1.3},
+				{From: 0, To: 2, Weight: 1.2},
+				{From: 1, To: 3, Weight: 2.3},
+				{From: 4, To: 5, Weight: 5.4},
+				{From: 5, To: 6, Weight: 5.6},
+				{From: 6, To: 7, Weight: 3.6},
+			},
+			wantMST: []graph.Edge{
+				{From: 0, To: 1, Weight: 1.1},
+				{From: 0, To: 2, Weight: 1.2},
+				{From: 0, To: 3, Weight: 1.3},
+			},
+			wantErr: common.ErrDisconnectedGraph,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original, err := sparsegraph.NewGraph(tc.n, false)
+			if err != nil {
+				t.Fatalf("NewGraph(%d) failed: %v", tc.n, err)
+			}
+			for _, edge := range tc.connections {
+				if err := original.Connect(edge.From.(int), edge.To.(int), edge.Weight); err != nil {
+					t.Fatalf("Connect(%d, %d, %f) failed: %v", edge.From, edge.To, edge.Weight, err)
+				}
+			}
+
+			mst, err := sparsegraph.NewGraph(tc.n, false)
+			if err != nil {
+				t.Fatalf("NewGraph(%d) for MST failed: %v", tc.n, err)
+			}
+
+			if err := MST(original, mst, tc.root); err != tc.wantErr {
+				t.Fatalf("MST() error = %v; want %v", err, tc.wantErr)
+			}
+
+			gotMST := mst.GetAllEdges()
+			if len(gotMST) != len(tc.wantMST) {
+				t.Fatalf("MST length = %d; want %d", len(gotMST), len(tc.wantMST))
+			}
+
+			wantMSTHeap := graph.NewEdgeHeap(tc.wantMST)
+			gotMSTHeap := graph.NewEdgeHeap(gotMST)
+			for wantMSTHeap.Len() > 0 {
+				want, err := wantMSTHeap.PopEdge()
+				if err != nil {
+					t.Fatalf("PopEdge from wantMSTHeap failed: %v", err)
+				}
+				got, err := gotMSTHeap.PopEdge()
+				if err != nil {
+					t.Fatalf("PopEdge from gotMSTHeap failed: %v", err)
+				}
+				if !got.Equals(want) {
+					t.Errorf("MST edge mismatch: got %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestMSTError(t *testing.T) {
+	testCases := []struct {
+		name     string
+		directed bool
+		root     int
+		wantErr  error
+	}{
+		{
+			name:     "directed graph",
+			directed: true,
+			root:     0,
+			wantErr:  common.ErrNeedUndirectedGraph,
+		},
+		{
+			name:     "root out of range",
+			directed: false,
+			root:     3,
+			wantErr:  common.ErrNoSuchRoot,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			original, err := sparsegraph.NewGraph(3, tc.directed)
+			if err != nil {
+				t.Fatalf("NewGraph(3) failed: %v", err)
+			}
+			mst, err := sparsegraph.NewGraph(3, false)
+			if err != nil {
+				t.Fatalf("NewGraph(3) for MST failed: %v", err)
+			}
+
+			if err := MST(original, mst, tc.root); err != tc.wantErr {
+				t.Errorf("MST() error = %v; want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package concurrency
 
+import (
+	"context"
+	"errors"
+)
+
+var ErrPoolClosed = errors.New("worker pool is shut down")
+
 type JobStatus int
 
 const (
@@ -17,4 +24,14 @@ type JobResult[I, O any] struct {
 
 type Job[I any, O any] func(I) JobResult[I, O]
 
+// ContextJob is a Job that stops early when ctx is done.
+type ContextJob[I any, O any] func(ctx context.Context, input I) JobResult[I, O]
+
+// WithContext adapts job to a ContextJob that ignores its context.
+func (job Job[I, O]) WithContext() ContextJob[I, O] {
+	return func(_ context.Context, input I) JobResult[I, O] {
+		return job(input)
+	}
+}
+
 type Service[I any] func(I) error
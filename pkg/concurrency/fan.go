@@ -1,11 +1,17 @@
 package concurrency
 
 import (
+	"context"
 	"sync"
 )
 
-func FanOut[I, O any](in <-chan I, n int, fn Job[I, O]) []<-chan JobResult[I, O] {
+// FanOut runs fn on the values from in with n goroutines, each sending its
+// results on a channel of its own. A panic in fn becomes a result with a
+// *PanicError; of options, only WithJobTimeout applies.
+func FanOut[I, O any](in <-chan I, n int, fn Job[I, O], options ...PoolOption) []<-chan JobResult[I, O] {
 	outs := make([]<-chan JobResult[I, O], n)
+	job := fn.WithContext()
+	timeout := newPoolConfig(n, options).timeout
 
 	for i := range outs {
 		out := make(chan JobResult[I, O])
@@ -15,7 +21,7 @@ func FanOut[I, O any](in <-chan I, n int, fn Job[I, O]) []<-chan JobResult[I, O]
 			defer close(out)
 
 			for v := range in {
-				y := fn(v)
+				y := runJob(context.Background(), job, v, timeout)
 				out <- y
 			}
 		}(out)
@@ -3,6 +3,7 @@ package concurrency_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	. "tsumegolang/pkg/concurrency"
 )
@@ -71,3 +72,37 @@ func TestFanOutAndFanIn(t *testing.T) {
 		})
 	}
 }
+
+func TestFanOutRecovery(t *testing.T) {
+	workFn := func(x int) JobResult[int, int] {
+		switch x {
+		case 0:
+			panic("zero")
+		case 1:
+			time.Sleep(time.Second)
+		}
+		return JobResult[int, int]{Input: x, Output: x, Status: StatusSuccess}
+	}
+
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for _, v := range []int{0, 1, 2} {
+			in <- v
+		}
+	}()
+
+	got := map[int]error{}
+	for result := range FanIn(FanOut(in, 2, workFn, WithJobTimeout(20*time.Millisecond))...) {
+		got[result.Input] = result.Err
+	}
+	if !errors.Is(got[0], ErrJobPanicked) {
+		t.Errorf("Expected the panic to become %v, got %v", ErrJobPanicked, got[0])
+	}
+	if !errors.Is(got[1], ErrJobTimeout) {
+		t.Errorf("Expected the slow job to fail with %v, got %v", ErrJobTimeout, got[1])
+	}
+	if err, ok := got[2]; !ok || err != nil {
+		t.Errorf("Expected the last job to succeed, got %v", err)
+	}
+}
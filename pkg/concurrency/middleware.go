@@ -0,0 +1,208 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrCircuitOpen  = errors.New("circuit breaker is open")
+	ErrBulkheadFull = errors.New("bulkhead is full")
+)
+
+// Middleware decorates a job with extra behavior. Middlewares work on
+// ContextJobs so that the context reaches every layer, and canceling it stops
+// waits and retries; Chain applies them to a plain Job.
+type Middleware[I, O any] func(ContextJob[I, O]) ContextJob[I, O]
+
+// Chain decorates job with middlewares, the first outermost, so
+//
+//	Chain(job, Retry[I, O](policy), Timeout[I, O](time.Second))
+//
+// retries job with a timeout on every attempt. The result is a Job for
+// NewWorkerPool, whose runs cannot be canceled; use ChainContext for that.
+func Chain[I, O any](job Job[I, O], middlewares ...Middleware[I, O]) Job[I, O] {
+	chained := ChainContext(job.WithContext(), middlewares...)
+	return func(input I) JobResult[I, O] {
+		return chained(context.Background(), input)
+	}
+}
+
+// ChainContext is Chain for a ContextJob, passing the context of each run
+// through the middlewares to job.
+func ChainContext[I, O any](job ContextJob[I, O], middlewares ...Middleware[I, O]) ContextJob[I, O] {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		job = middlewares[i](job)
+	}
+	return job
+}
+
+// IsRetryable reports whether a job that failed with err may succeed if tried
+// again. An error with a Retryable() bool method decides for itself; otherwise
+// panics, open circuits and cancellation are final and all else is retried.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var r interface{ Retryable() bool }
+	if errors.As(err, &r) {
+		return r.Retryable()
+	}
+	switch {
+	case errors.Is(err, ErrJobPanicked), errors.Is(err, ErrCircuitOpen), errors.Is(err, context.Canceled):
+		return false
+	}
+	return true
+}
+
+// RetryPolicy sets how Retry tries a job again. The wait before each retry
+// starts at Backoff and grows by Multiplier, if above 1, up to MaxBackoff, if
+// set. Retryable classifies errors, IsRetryable by default.
+type RetryPolicy struct {
+	Attempts   int
+	Backoff    time.Duration
+	Multiplier float64
+	MaxBackoff time.Duration
+	Retryable  func(error) bool
+}
+
+// Retry runs the job up to policy.Attempts times while it fails with a
+// retryable error, returning the last result. It stops retrying, and waiting
+// between attempts, once the context is done.
+func Retry[I, O any](policy RetryPolicy) Middleware[I, O] {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	return func(job ContextJob[I, O]) ContextJob[I, O] {
+		return func(ctx context.Context, input I) JobResult[I, O] {
+			backoff := policy.Backoff
+			for attempt := 1; ; attempt++ {
+				result := job(ctx, input)
+				if result.Status != StatusError || attempt >= policy.Attempts || !retryable(result.Err) || ctx.Err() != nil {
+					return result
+				}
+				select {
+				case <-time.After(backoff):
+				case <-ctx.Done():
+					return result
+				}
+				if policy.Multiplier > 1 {
+					backoff = time.Duration(float64(backoff) * policy.Multiplier)
+				}
+				if policy.MaxBackoff > 0 {
+					backoff = min(backoff, policy.MaxBackoff)
+				}
+			}
+		}
+	}
+}
+
+// Timeout gives up on a job that runs longer than d with an error wrapping
+// ErrJobTimeout, and cancels the context it passed the job. A job that ignores
+// its context keeps running in the background until it returns. A panic in the
+// job becomes a result with a *PanicError.
+func Timeout[I, O any](d time.Duration) Middleware[I, O] {
+	return func(job ContextJob[I, O]) ContextJob[I, O] {
+		return func(ctx context.Context, input I) JobResult[I, O] {
+			return runJob(ctx, job, input, d)
+		}
+	}
+}
+
+// Limiter paces work, as RateLimiter does.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RateLimit waits for limiter before every run of the job, failing with the
+// context's error if it is done first.
+func RateLimit[I, O any](limiter Limiter) Middleware[I, O] {
+	return func(job ContextJob[I, O]) ContextJob[I, O] {
+		return func(ctx context.Context, input I) JobResult[I, O] {
+			if err := limiter.Wait(ctx); err != nil {
+				return JobResult[I, O]{Input: input, Status: StatusError, Err: err}
+			}
+			return job(ctx, input)
+		}
+	}
+}
+
+// CircuitBreakerConfig sets when a CircuitBreaker opens: after FailureThreshold
+// failures in a row, for Cooldown before it lets a single trial run through.
+type CircuitBreakerConfig struct {
+	FailureThreshold int
+	Cooldown         time.Duration
+}
+
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen // a trial is running
+)
+
+// CircuitBreaker fails jobs with ErrCircuitOpen, without running them, while
+// the decorated job has been failing. A successful trial closes the circuit and
+// a failed one, or one that panics, opens it for another cooldown.
+func CircuitBreaker[I, O any](cfg CircuitBreakerConfig) Middleware[I, O] {
+	threshold := max(cfg.FailureThreshold, 1)
+	return func(job ContextJob[I, O]) ContextJob[I, O] {
+		var mu sync.Mutex
+		state := circuitClosed
+		failures := 0
+		var openUntil time.Time
+
+		return func(ctx context.Context, input I) (result JobResult[I, O]) {
+			mu.Lock()
+			trial := false
+			switch {
+			case state == circuitHalfOpen, state == circuitOpen && time.Now().Before(openUntil):
+				mu.Unlock()
+				return JobResult[I, O]{Input: input, Status: StatusError, Err: ErrCircuitOpen}
+			case state == circuitOpen:
+				state, trial = circuitHalfOpen, true
+			}
+			mu.Unlock()
+
+			// Record the outcome even if the job panics, counting the panic as a
+			// failure, so a panicking trial cannot leave the circuit half open.
+			completed := false
+			defer func() {
+				mu.Lock()
+				defer mu.Unlock()
+				if completed && result.Status != StatusError {
+					state, failures = circuitClosed, 0
+					return
+				}
+				failures++
+				if trial || failures >= threshold {
+					state, openUntil = circuitOpen, time.Now().Add(cfg.Cooldown)
+				}
+			}()
+
+			result = job(ctx, input)
+			completed = true
+			return result
+		}
+	}
+}
+
+// Bulkhead lets at most n runs of the job at a time, failing the others with
+// ErrBulkheadFull.
+func Bulkhead[I, O any](n int) Middleware[I, O] {
+	return func(job ContextJob[I, O]) ContextJob[I, O] {
+		sem := NewSemaphore(n)
+		return func(ctx context.Context, input I) JobResult[I, O] {
+			if !sem.Try() {
+				return JobResult[I, O]{Input: input, Status: StatusError, Err: fmt.Errorf("%w: %d running", ErrBulkheadFull, n)}
+			}
+			defer sem.Release()
+			return job(ctx, input)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package concurrency_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "tsumegolang/pkg/concurrency"
+)
+
+type permanentError struct{}
+
+func (permanentError) Error() string   { return "permanent" }
+func (permanentError) Retryable() bool { return false }
+
+func TestIsRetryable(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "no error", err: nil, want: false},
+		{name: "plain error", err: errors.New("flaky"), want: true},
+		{name: "timeout", err: fmt.Errorf("%w after 1s", ErrJobTimeout), want: true},
+		{name: "panic", err: &PanicError{Value: "boom"}, want: false},
+		{name: "open circuit", err: ErrCircuitOpen, want: false},
+		{name: "canceled", err: fmt.Errorf("fetch: %w", context.Canceled), want: false},
+		{name: "self-classified", err: fmt.Errorf("fetch: %w", permanentError{}), want: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsRetryable(tc.err); got != tc.want {
+				t.Errorf("Expected IsRetryable(%v) to be %t, got %t", tc.err, tc.want, got)
+			}
+		})
+	}
+}
+
+// flakyJob fails with errs in turn, then succeeds, counting its calls.
+func flakyJob(calls *atomic.Int32, errs ...error) Job[int, int] {
+	return func(x int) JobResult[int, int] {
+		n := int(calls.Add(1))
+		if n <= len(errs) {
+			return JobResult[int, int]{Input: x, Status: StatusError, Err: errs[n-1]}
+		}
+		return JobResult[int, int]{Input: x, Output: x * 2, Status: StatusSuccess}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	flaky := errors.New("flaky")
+	testCases := []struct {
+		name       string
+		errs       []error
+		policy     RetryPolicy
+		wantCalls  int32
+		wantStatus JobStatus
+	}{
+		{name: "Succeeds after retries", errs: []error{flaky, flaky}, policy: RetryPolicy{Attempts: 3}, wantCalls: 3, wantStatus: StatusSuccess},
+		{name: "Runs out of attempts", errs: []error{flaky, flaky, flaky}, policy: RetryPolicy{Attempts: 2}, wantCalls: 2, wantStatus: StatusError},
+		{name: "Stops at a final error", errs: []error{permanentError{}}, policy: RetryPolicy{Attempts: 3}, wantCalls: 1, wantStatus: StatusError},
+		{
+			name:       "Custom classification",
+			errs:       []error{flaky},
+			policy:     RetryPolicy{Attempts: 3, Retryable: func(err error) bool { return !errors.Is(err, flaky) }},
+			wantCalls:  1,
+			wantStatus: StatusError,
+		},
+		{name: "No attempts set", errs: []error{flaky}, wantCalls: 1, wantStatus: StatusError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls atomic.Int32
+			job := Chain(flakyJob(&calls, tc.errs...), Retry[int, int](tc.policy))
+			if got := job(4); got.Status != tc.wantStatus {
+				t.Errorf("Expected status %v, got %+v", tc.wantStatus, got)
+			}
+			if got := calls.Load(); got != tc.wantCalls {
+				t.Errorf("Expected %d calls, got %d", tc.wantCalls, got)
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var calls atomic.Int32
+	flaky := errors.New("flaky")
+	job := Chain(flakyJob(&calls, flaky, flaky, flaky), Retry[int, int](RetryPolicy{
+		Attempts:   4,
+		Backoff:    10 * time.Millisecond,
+		Multiplier: 2,
+		MaxBackoff: 15 * time.Millisecond,
+	}))
+
+	start := time.Now()
+	if got := job(1); got.Status != StatusSuccess {
+		t.Fatalf("Expected success, got %+v", got)
+	}
+	// The waits are 10ms, then 15ms twice, capped from 20ms and 30ms.
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("Expected at least 40ms of backoff, got %v", elapsed)
+	}
+}
+
+func TestChainOrder(t *testing.T) {
+	// Retry is outermost, so each attempt gets its own timeout.
+	var calls atomic.Int32
+	job := func(x int) JobResult[int, int] {
+		if calls.Add(1) == 1 {
+			time.Sleep(time.Second)
+		}
+		return JobResult[int, int]{Input: x, Output: x, Status: StatusSuccess}
+	}
+	chained := Chain(job, Retry[int, int](RetryPolicy{Attempts: 2}), Timeout[int, int](20*time.Millisecond))
+	if got := chained(7); got.Status != StatusSuccess || got.Output != 7 {
+		t.Errorf("Expected the second attempt to succeed, got %+v", got)
+	}
+
+	// With Timeout outermost, the retries share one deadline.
+	calls.Store(0)
+	chained = Chain(job, Timeout[int, int](20*time.Millisecond), Retry[int, int](RetryPolicy{Attempts: 2}))
+	if got := chained(7); !errors.Is(got.Err, ErrJobTimeout) {
+		t.Errorf("Expected %v, got %+v", ErrJobTimeout, got)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	job := Chain(func(x int) JobResult[int, int] {
+		if x < 0 {
+			panic("negative")
+		}
+		time.Sleep(time.Duration(x) * time.Millisecond)
+		return JobResult[int, int]{Input: x, Output: x, Status: StatusSuccess}
+	}, Timeout[int, int](20*time.Millisecond))
+
+	if got := job(1); got.Status != StatusSuccess {
+		t.Errorf("Expected a fast job to succeed, got %+v", got)
+	}
+	if got := job(500); got.Status != StatusError || !errors.Is(got.Err, ErrJobTimeout) {
+		t.Errorf("Expected a slow job to fail with %v, got %+v", ErrJobTimeout, got)
+	}
+	if got := job(-1); !errors.Is(got.Err, ErrJobPanicked) {
+		t.Errorf("Expected a panic to fail with %v, got %+v", ErrJobPanicked, got)
+	}
+
+	// The job's context is canceled when it times out, so it can stop too.
+	stopped := make(chan struct{})
+	contextJob := ChainContext(func(ctx context.Context, x int) JobResult[int, int] {
+		<-ctx.Done()
+		close(stopped)
+		return JobResult[int, int]{Input: x, Status: StatusError, Err: ctx.Err()}
+	}, Timeout[int, int](20*time.Millisecond))
+	contextJob(context.Background(), 1)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("Expected the timed out job's context to be canceled")
+	}
+}
+
+type countingLimiter struct {
+	waits atomic.Int32
+	err   error
+}
+
+func (l *countingLimiter) Wait(context.Context) error {
+	l.waits.Add(1)
+	return l.err
+}
+
+func TestRateLimit(t *testing.T) {
+	var calls atomic.Int32
+	limiter := &countingLimiter{}
+	job := Chain(flakyJob(&calls), RateLimit[int, int](limiter))
+	for range 3 {
+		job(1)
+	}
+	if limiter.waits.Load() != 3 || calls.Load() != 3 {
+		t.Errorf("Expected 3 waits and 3 calls, got %d and %d", limiter.waits.Load(), calls.Load())
+	}
+
+	limiter.err = context.DeadlineExceeded
+	if got := job(1); got.Status != StatusError || !errors.Is(got.Err, context.DeadlineExceeded) || calls.Load() != 3 {
+		t.Errorf("Expected the limiter's error without a call, got %+v after %d calls", got, calls.Load())
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	var fail atomic.Bool
+	var calls atomic.Int32
+	job := Chain(func(x int) JobResult[int, int] {
+		calls.Add(1)
+		if fail.Load() {
+			return JobResult[int, int]{Input: x, Status: StatusError, Err: errors.New("down")}
+		}
+		return JobResult[int, int]{Input: x, Output: x, Status: StatusSuccess}
+	}, CircuitBreaker[int, int](CircuitBreakerConfig{FailureThreshold: 2, Cooldown: 30 * time.Millisecond}))
+
+	fail.Store(true)
+	job(1)
+	job(2)
+	if got := job(3); !errors.Is(got.Err, ErrCircuitOpen) || calls.Load() != 2 {
+		t.Fatalf("Expected the circuit to open after 2 failures, got %+v after %d calls", got, calls.Load())
+	}
+
+	// A failed trial opens the circuit for another cooldown.
+	time.Sleep(40 * time.Millisecond)
+	if got := job(4); errors.Is(got.Err, ErrCircuitOpen) || calls.Load() != 3 {
+		t.Fatalf("Expected a trial after the cooldown, got %+v after %d calls", got, calls.Load())
+	}
+	if got := job(5); !errors.Is(got.Err, ErrCircuitOpen) {
+		t.Fatalf("Expected the circuit to reopen after a failed trial, got %+v", got)
+	}
+
+	// A successful trial closes it.
+	fail.Store(false)
+	time.Sleep(40 * time.Millisecond)
+	for x := range 3 {
+		if got := job(x); got.Status != StatusSuccess {
+			t.Errorf("Expected the circuit to close after a successful trial, got %+v", got)
+		}
+	}
+}
+
+func TestCircuitBreakerPanic(t *testing.T) {
+	var panics atomic.Bool
+	panics.Store(true)
+	job := Chain(func(x int) JobResult[int, int] {
+		if panics.Load() {
+			panic("down")
+		}
+		return JobResult[int, int]{Input: x, Output: x, Status: StatusSuccess}
+	}, CircuitBreaker[int, int](CircuitBreakerConfig{FailureThreshold: 1, Cooldown: 30 * time.Millisecond}))
+	run := func(x int) (result JobResult[int, int], panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		return job(x), false
+	}
+
+	if _, panicked := run(1); !panicked {
+		t.Fatal("Expected the job's panic to reach the caller")
+	}
+	if got, _ := run(2); !errors.Is(got.Err, ErrCircuitOpen) {
+		t.Fatalf("Expected a panic to open the circuit, got %+v", got)
+	}
+
+	// A panicking trial opens the circuit again rather than leaving it half open.
+	time.Sleep(40 * time.Millisecond)
+	if _, panicked := run(3); !panicked {
+		t.Fatal("Expected a trial after the cooldown")
+	}
+	panics.Store(false)
+	time.Sleep(40 * time.Millisecond)
+	if got, _ := run(4); got.Status != StatusSuccess {
+		t.Errorf("Expected another trial after the panicking one, got %+v", got)
+	}
+}
+
+func TestMiddlewareCanceled(t *testing.T) {
+	flaky := errors.New("flaky")
+	slow := func(ctx context.Context, x int) JobResult[int, int] {
+		select {
+		case <-time.After(time.Second):
+			return JobResult[int, int]{Input: x, Output: x, Status: StatusSuccess}
+		case <-ctx.Done():
+			return JobResult[int, int]{Input: x, Status: StatusError, Err: ctx.Err()}
+		}
+	}
+	limiter := NewRateLimiter(time.Second, 1)
+	limiter.Wait(context.Background())
+
+	testCases := []struct {
+		name    string
+		job     ContextJob[int, int]
+		wantErr error
+	}{
+		{
+			name:    "Retry stops waiting",
+			job:     ChainContext(flakyJob(new(atomic.Int32), flaky, flaky).WithContext(), Retry[int, int](RetryPolicy{Attempts: 3, Backoff: time.Second})),
+			wantErr: flaky,
+		},
+		{
+			name:    "RateLimit stops waiting",
+			job:     ChainContext(flakyJob(new(atomic.Int32)).WithContext(), RateLimit[int, int](limiter)),
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "Timeout passes the context",
+			job:     ChainContext(slow, Timeout[int, int](time.Second)),
+			wantErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "Bulkhead passes the context",
+			job:     ChainContext(slow, Bulkhead[int, int](1)),
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			start := time.Now()
+			if got := tc.job(ctx, 1); !errors.Is(got.Err, tc.wantErr) {
+				t.Errorf("Expected %v, got %+v", tc.wantErr, got)
+			}
+			if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+				t.Errorf("Expected to stop at the deadline, got %v", elapsed)
+			}
+		})
+	}
+}
+
+func TestBulkhead(t *testing.T) {
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	started.Add(2)
+	job := Chain(func(x int) JobResult[int, int] {
+		started.Done()
+		<-release
+		return JobResult[int, int]{Input: x, Output: x, Status: StatusSuccess}
+	}, Bulkhead[int, int](2))
+
+	results := make(chan JobResult[int, int], 2)
+	for x := range 2 {
+		go func() { results <- job(x) }()
+	}
+	started.Wait()
+	if got := job(3); !errors.Is(got.Err, ErrBulkheadFull) {
+		t.Errorf("Expected %v with 2 jobs running, got %+v", ErrBulkheadFull, got)
+	}
+	close(release)
+	for range 2 {
+		if got := <-results; got.Status != StatusSuccess {
+			t.Errorf("Expected the running jobs to succeed, got %+v", got)
+		}
+	}
+}
+
+func TestMiddlewareInWorkerPool(t *testing.T) {
+	var calls atomic.Int32
+	flaky := errors.New("flaky")
+	job := Chain(flakyJob(&calls, flaky), Retry[int, int](RetryPolicy{Attempts: 2}), Timeout[int, int](time.Second))
+
+	wp := NewWorkerPool(job, 1)
+	wp.Start()
+	resultCh, err := wp.Submit(21)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	if got := <-resultCh; got.Status != StatusSuccess || got.Output != 42 {
+		t.Errorf("Expected the retried job to succeed, got %+v", got)
+	}
+	if err := wp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+}
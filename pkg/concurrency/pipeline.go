@@ -1,9 +1,17 @@
 package concurrency
 
+import (
+	"context"
+	"time"
+)
+
+// PipelineJob is a stage of a Pipeline. Task runs on the inputs Validator
+// accepts, giving up after Timeout if it is positive.
 type PipelineJob struct {
 	Task      Job[any, any]
 	Result    JobResult[any, any]
 	Validator func(any) error
+	Timeout   time.Duration
 }
 
 type PipelineJobResult JobResult[any, any]
@@ -11,20 +19,23 @@ type PipelineJobResult JobResult[any, any]
 func Pipeline(in <-chan PipelineJobResult, job PipelineJob) <-chan PipelineJobResult {
 	out := make(chan PipelineJobResult)
 
+	// A panic in the validator or the task becomes an error like any other.
+	stage := ContextJob[any, any](func(_ context.Context, input any) JobResult[any, any] {
+		if err := job.Validator(input); err != nil {
+			return JobResult[any, any]{Input: input, Status: StatusError, Err: err}
+		}
+		return job.Task(input)
+	})
+
 	go func() {
 		defer close(out)
 
 		for input := range in {
-			if err := job.Validator(input.Input); err != nil {
-				out <- PipelineJobResult{Input: input.Input, Err: err}
-				continue
-			}
-
-			result := job.Task(input.Input)
+			result := runJob(context.Background(), stage, input.Input, job.Timeout)
 			if result.Err != nil {
-				out <- PipelineJobResult{Input: input.Input, Err: result.Err}
+				out <- PipelineJobResult{Input: input.Input, Status: StatusError, Err: result.Err}
 			} else {
-				out <- PipelineJobResult{Input: input.Input, Output: result.Output}
+				out <- PipelineJobResult{Input: input.Input, Status: StatusSuccess, Output: result.Output}
 			}
 		}
 	}()
@@ -3,6 +3,7 @@ package concurrency_test
 import (
 	"errors"
 	"testing"
+	"time"
 
 	. "tsumegolang/pkg/concurrency"
 )
@@ -63,9 +64,15 @@ func TestPipeline(t *testing.T) {
 
 			for result := range results {
 				if result.Err != nil {
+					if result.Status != StatusError {
+						t.Errorf("Expected status %v for an error, got %v", StatusError, result.Status)
+					}
 					errCount++
 					continue
 				}
+				if result.Status != StatusSuccess {
+					t.Errorf("Expected status %v for a success, got %v", StatusSuccess, result.Status)
+				}
 
 				if !expectedResults[result.Output.(int)] {
 					t.Errorf("Unexpected result: %d", result.Output)
@@ -83,3 +90,42 @@ func TestPipeline(t *testing.T) {
 		})
 	}
 }
+
+func TestPipelineRecovery(t *testing.T) {
+	job := PipelineJob{
+		Task: func(input any) JobResult[any, any] {
+			x := input.(int)
+			if x > 100 {
+				time.Sleep(time.Second)
+			}
+			return JobResult[any, any]{Input: x, Output: 10 / x, Status: StatusSuccess}
+		},
+		Validator: func(input any) error {
+			_ = input.(int)
+			return nil
+		},
+		Timeout: 20 * time.Millisecond,
+	}
+
+	in := make(chan PipelineJobResult)
+	go func() {
+		defer close(in)
+		for _, v := range []any{0, "text", 500, 5} {
+			in <- PipelineJobResult{Input: v}
+		}
+	}()
+
+	var got []error
+	for result := range Pipeline(in, job) {
+		got = append(got, result.Err)
+	}
+	want := []error{ErrJobPanicked, ErrJobPanicked, ErrJobTimeout, nil}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d results, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if !errors.Is(got[i], want[i]) || (want[i] == nil) != (got[i] == nil) {
+			t.Errorf("Result %d: expected error %v, got %v", i, want[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package concurrency
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	ErrQueueFull   = errors.New("worker pool queue is full")
+	ErrInvalidSize = errors.New("invalid worker pool size")
+)
+
+// OverflowPolicy selects what submitting to a full queue does.
+type OverflowPolicy int
+
+const (
+	OverflowBlock      OverflowPolicy = iota // wait for room
+	OverflowFail                             // fail with ErrQueueFull
+	OverflowDropOldest                       // skip the oldest queued job to make room
+)
+
+type poolConfig struct {
+	queueSize int
+	overflow  OverflowPolicy
+	timeout   time.Duration
+	aging     time.Duration
+}
+
+type PoolOption func(*poolConfig)
+
+// WithQueueSize sets how many submitted jobs can wait for a worker. The queue
+// holds as many jobs as there are workers by default.
+func WithQueueSize(n int) PoolOption {
+	return func(c *poolConfig) {
+		c.queueSize = max(n, 0)
+	}
+}
+
+// WithOverflow sets what submitting to a full queue does, OverflowBlock by default.
+func WithOverflow(policy OverflowPolicy) PoolOption {
+	return func(c *poolConfig) {
+		c.overflow = policy
+	}
+}
+
+// WithJobTimeout gives up on a job that runs longer than d, with an error
+// wrapping ErrJobTimeout. The job's context expires at the same time. FanOut
+// takes this option as well.
+func WithJobTimeout(d time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.timeout = d
+	}
+}
+
+// WithAging raises the priority of a job waiting in a PriorityWorkerPool by one
+// for every d it waits, so low priorities are not starved.
+func WithAging(d time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.aging = d
+	}
+}
+
+func newPoolConfig(numWorkers int, options []PoolOption) poolConfig {
+	c := poolConfig{queueSize: -1}
+	for _, option := range options {
+		option(&c)
+	}
+	if c.queueSize < 0 {
+		c.queueSize = max(numWorkers, 0)
+	}
+	return c
+}
+
+// PoolStats is a snapshot of a pool's health. Completed counts the jobs that ran,
+// Failed those of them with StatusError, and Dropped the jobs skipped to make
+// room under OverflowDropOldest. AverageLatency runs from submission to result.
+type PoolStats struct {
+	Workers        int
+	Queued         int
+	Active         int
+	Completed      int
+	Failed         int
+	Dropped        int
+	AverageLatency time.Duration
+}
+
+type poolCounters struct {
+	active    atomic.Int64
+	completed atomic.Int64
+	failed    atomic.Int64
+	dropped   atomic.Int64
+	latency   atomic.Int64 // total nanoseconds
+}
+
+func (c *poolCounters) finish(status JobStatus, submitted time.Time) {
+	c.latency.Add(int64(time.Since(submitted)))
+	c.completed.Add(1)
+	if status == StatusError {
+		c.failed.Add(1)
+	}
+}
+
+func (c *poolCounters) stats(workers, queued int) PoolStats {
+	s := PoolStats{
+		Workers:   workers,
+		Queued:    queued,
+		Active:    int(c.active.Load()),
+		Completed: int(c.completed.Load()),
+		Failed:    int(c.failed.Load()),
+		Dropped:   int(c.dropped.Load()),
+	}
+	if s.Completed > 0 {
+		s.AverageLatency = time.Duration(c.latency.Load() / int64(s.Completed))
+	}
+	return s
+}
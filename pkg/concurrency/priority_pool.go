@@ -0,0 +1,193 @@
+package concurrency
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+type priorityRequest[I any, O any] struct {
+	workerRequest[I, O]
+	rank float64 // priority, less the aging already owed at submission
+	seq  uint64
+}
+
+type priorityQueue[I any, O any] []priorityRequest[I, O]
+
+func (q priorityQueue[I, O]) Len() int { return len(q) }
+
+func (q priorityQueue[I, O]) Less(i, j int) bool {
+	if q[i].rank != q[j].rank {
+		return q[i].rank > q[j].rank
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q priorityQueue[I, O]) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue[I, O]) Push(x any) { *q = append(*q, x.(priorityRequest[I, O])) }
+
+func (q *priorityQueue[I, O]) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	*q = old[:n-1]
+	return item
+}
+
+// PriorityWorkerPool is a WorkerPool that runs the queued job with the highest
+// priority first, and of equal priorities the first submitted. The queue is
+// unbounded; of the options, WithJobTimeout and WithAging apply.
+type PriorityWorkerPool[I any, O any] struct {
+	job        ContextJob[I, O]
+	numWorkers int
+	timeout    time.Duration
+	aging      time.Duration
+	epoch      time.Time
+	wg         sync.WaitGroup
+	ctx        context.Context
+	cancel     context.CancelFunc
+	counters   poolCounters
+
+	mu      sync.Mutex
+	ready   *sync.Cond
+	queue   priorityQueue[I, O]
+	seq     uint64
+	started bool
+	closed  bool
+}
+
+func NewPriorityWorkerPool[I any, O any](job Job[I, O], numWorkers int, options ...PoolOption) *PriorityWorkerPool[I, O] {
+	return NewContextPriorityWorkerPool(job.WithContext(), numWorkers, options...)
+}
+
+// NewContextPriorityWorkerPool is NewPriorityWorkerPool for jobs that receive
+// the context of their submission.
+func NewContextPriorityWorkerPool[I any, O any](job ContextJob[I, O], numWorkers int, options ...PoolOption) *PriorityWorkerPool[I, O] {
+	ctx, cancel := context.WithCancel(context.Background())
+	config := newPoolConfig(numWorkers, options)
+
+	wp := &PriorityWorkerPool[I, O]{
+		job:        job,
+		numWorkers: numWorkers,
+		timeout:    config.timeout,
+		aging:      config.aging,
+		epoch:      time.Now(),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	wp.ready = sync.NewCond(&wp.mu)
+	return wp
+}
+
+func (wp *PriorityWorkerPool[I, O]) Start() {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.started || wp.closed {
+		return
+	}
+	wp.started = true
+	for range wp.numWorkers {
+		wp.wg.Add(1)
+		go wp.worker()
+	}
+}
+
+func (wp *PriorityWorkerPool[I, O]) worker() {
+	defer wp.wg.Done()
+
+	for {
+		wp.mu.Lock()
+		for len(wp.queue) == 0 && !wp.closed {
+			wp.ready.Wait()
+		}
+		if len(wp.queue) == 0 {
+			wp.mu.Unlock()
+			return
+		}
+		req := heap.Pop(&wp.queue).(priorityRequest[I, O])
+		wp.mu.Unlock()
+
+		execute(wp.ctx, wp.job, wp.timeout, &wp.counters, req.workerRequest)
+	}
+}
+
+// Stats returns a snapshot of the pool's workers, queue and finished jobs.
+func (wp *PriorityWorkerPool[I, O]) Stats() PoolStats {
+	wp.mu.Lock()
+	queued := len(wp.queue)
+	wp.mu.Unlock()
+	return wp.counters.stats(wp.numWorkers, queued)
+}
+
+func (wp *PriorityWorkerPool[I, O]) Submit(input I, priority int) (chan JobResult[I, O], error) {
+	return wp.SubmitContext(context.Background(), input, priority)
+}
+
+// SubmitContext queues input with priority, higher first. The job runs with ctx,
+// so it is skipped if ctx is done while it is queued.
+func (wp *PriorityWorkerPool[I, O]) SubmitContext(ctx context.Context, input I, priority int) (chan JobResult[I, O], error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.closed {
+		return nil, ErrPoolClosed
+	}
+	now := time.Now()
+	rank := float64(priority)
+	if wp.aging > 0 {
+		// Every queued job ages at the same rate, so a job submitted later
+		// starts behind by the time it missed.
+		rank -= float64(now.Sub(wp.epoch)) / float64(wp.aging)
+	}
+	resultCh := make(chan JobResult[I, O], 1)
+	heap.Push(&wp.queue, priorityRequest[I, O]{
+		workerRequest: workerRequest[I, O]{ctx: ctx, input: input, resultCh: resultCh, submitted: now},
+		rank:          rank,
+		seq:           wp.seq,
+	})
+	wp.seq++
+	wp.ready.Signal()
+	return resultCh, nil
+}
+
+// Shutdown stops accepting jobs and waits for the queued and running ones to
+// finish. If ctx is done first, it cancels the running jobs, skips the queued
+// ones and returns ctx's error once the workers have stopped.
+func (wp *PriorityWorkerPool[I, O]) Shutdown(ctx context.Context) error {
+	wp.mu.Lock()
+	wp.closed = true
+	wp.ready.Broadcast()
+	wp.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		wp.cancel()
+		<-done
+	}
+	wp.cancel()
+
+	// Without workers, nothing has taken the queued requests.
+	wp.mu.Lock()
+	queue := wp.queue
+	wp.queue = nil
+	wp.mu.Unlock()
+	for _, req := range queue {
+		execute(wp.ctx, wp.job, wp.timeout, &wp.counters, req.workerRequest)
+	}
+	return err
+}
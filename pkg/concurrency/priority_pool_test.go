@@ -0,0 +1,135 @@
+package concurrency_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	. "tsumegolang/pkg/concurrency"
+)
+
+func TestPriorityWorkerPool(t *testing.T) {
+	type submission struct {
+		input    string
+		priority int
+		wait     time.Duration // before submitting
+	}
+	testCases := []struct {
+		name        string
+		options     []PoolOption
+		submissions []submission
+		want        []string
+	}{
+		{
+			name: "Priority with FIFO ties",
+			submissions: []submission{
+				{input: "prefetch", priority: 0},
+				{input: "small", priority: 5},
+				{input: "user", priority: 10},
+				{input: "other small", priority: 5},
+				{input: "more prefetch", priority: 0},
+			},
+			want: []string{"user", "small", "other small", "prefetch", "more prefetch"},
+		},
+		{
+			name: "No aging",
+			submissions: []submission{
+				{input: "old", priority: 0},
+				{input: "new", priority: 3, wait: 50 * time.Millisecond},
+			},
+			want: []string{"new", "old"},
+		},
+		{
+			name:    "Aging",
+			options: []PoolOption{WithAging(10 * time.Millisecond)},
+			submissions: []submission{
+				{input: "old", priority: 0},
+				{input: "new", priority: 3, wait: 50 * time.Millisecond},
+			},
+			want: []string{"old", "new"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var order []string
+			var mu sync.Mutex
+			job := func(input string) JobResult[string, int] {
+				mu.Lock()
+				defer mu.Unlock()
+				order = append(order, input)
+				return JobResult[string, int]{Input: input, Output: len(input), Status: StatusSuccess}
+			}
+
+			// Jobs wait in the queue until the single worker starts.
+			wp := NewPriorityWorkerPool(job, 1, tc.options...)
+			var results []chan JobResult[string, int]
+			for _, s := range tc.submissions {
+				time.Sleep(s.wait)
+				resultCh, err := wp.Submit(s.input, s.priority)
+				if err != nil {
+					t.Fatalf("Submit failed: %v", err)
+				}
+				results = append(results, resultCh)
+			}
+			if got := wp.Stats().Queued; got != len(tc.submissions) {
+				t.Errorf("Expected %d queued jobs, got %d", len(tc.submissions), got)
+			}
+			wp.Start()
+
+			for i, resultCh := range results {
+				got := <-resultCh
+				if got.Status != StatusSuccess || got.Output != len(tc.submissions[i].input) {
+					t.Errorf("Expected a successful result for %q, got %+v", tc.submissions[i].input, got)
+				}
+			}
+			if err := wp.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown failed: %v", err)
+			}
+			if !reflect.DeepEqual(order, tc.want) {
+				t.Errorf("Expected jobs to run in order %v, got %v", tc.want, order)
+			}
+			if got := wp.Stats().Completed; got != len(tc.want) {
+				t.Errorf("Expected %d completed jobs, got %d", len(tc.want), got)
+			}
+		})
+	}
+}
+
+func TestPriorityWorkerPoolShutdown(t *testing.T) {
+	job := func(ctx context.Context, input string) JobResult[string, int] {
+		<-ctx.Done()
+		return JobResult[string, int]{Input: input, Status: StatusError, Err: ctx.Err()}
+	}
+	wp := NewContextPriorityWorkerPool(job, 1)
+
+	queuedCtx, cancelQueued := context.WithCancel(context.Background())
+	canceled, err := wp.SubmitContext(queuedCtx, "canceled", 1)
+	if err != nil {
+		t.Fatalf("SubmitContext failed: %v", err)
+	}
+	cancelQueued()
+	wp.Start()
+	if got := <-canceled; got.Status != StatusSkipped || !errors.Is(got.Err, context.Canceled) {
+		t.Errorf("Expected the canceled job to be skipped, got %+v", got)
+	}
+
+	running, err := wp.Submit("running", 0)
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wp.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Shutdown to give up with %v, got %v", context.DeadlineExceeded, err)
+	}
+	if got := <-running; got.Status != StatusError || !errors.Is(got.Err, context.Canceled) {
+		t.Errorf("Expected the running job to be canceled, got %+v", got)
+	}
+	if _, err := wp.Submit("late", 0); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected %v after shutdown, got %v", ErrPoolClosed, err)
+	}
+}
@@ -0,0 +1,52 @@
+package concurrency
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket letting one run through every interval, with
+// bursts of up to burst runs after a quiet spell.
+type RateLimiter struct {
+	mu     sync.Mutex
+	every  time.Duration
+	burst  int
+	tokens float64
+	last   time.Time
+}
+
+func NewRateLimiter(every time.Duration, burst int) *RateLimiter {
+	burst = max(burst, 1)
+	return &RateLimiter{every: every, burst: burst, tokens: float64(burst), last: time.Now()}
+}
+
+// Wait blocks until a run may start, or until ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	if l.every <= 0 {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(float64(l.burst), l.tokens+float64(now.Sub(l.last))/float64(l.every))
+	l.last = now
+	l.tokens--
+	delay := time.Duration(-l.tokens * float64(l.every))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,44 @@
+package concurrency_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "tsumegolang/pkg/concurrency"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(20*time.Millisecond, 2)
+
+	// The burst goes through at once; the third run waits for a token.
+	start := time.Now()
+	for range 3 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("Expected the third run to wait about 20ms, got %v", elapsed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected %v waiting past the deadline, got %v", context.DeadlineExceeded, err)
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	limiter := NewRateLimiter(0, 1)
+	start := time.Now()
+	for range 100 {
+		if err := limiter.Wait(context.Background()); err != nil {
+			t.Fatalf("Wait failed: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected no waiting without a rate, got %v", elapsed)
+	}
+}
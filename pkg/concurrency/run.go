@@ -0,0 +1,66 @@
+package concurrency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+)
+
+var (
+	ErrJobPanicked = errors.New("job panicked")
+	ErrJobTimeout  = errors.New("job timed out")
+)
+
+// PanicError is the error of a job that panicked, carrying the value it
+// panicked with and the stack at the time. It wraps ErrJobPanicked.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v\n%s", ErrJobPanicked, e.Value, e.Stack)
+}
+
+func (e *PanicError) Unwrap() error {
+	return ErrJobPanicked
+}
+
+// runJob runs job on input, turning a panic into a result with a *PanicError.
+// With a positive timeout the job's context expires after it, and runJob stops
+// waiting for the job then with an error wrapping ErrJobTimeout.
+func runJob[I, O any](ctx context.Context, job ContextJob[I, O], input I, timeout time.Duration) JobResult[I, O] {
+	if timeout <= 0 {
+		return recoverJob(ctx, job, input)
+	}
+
+	timeoutErr := fmt.Errorf("%w after %v", ErrJobTimeout, timeout)
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, timeoutErr)
+	defer cancel()
+
+	done := make(chan JobResult[I, O], 1)
+	go func() {
+		done <- recoverJob(ctx, job, input)
+	}()
+
+	select {
+	case result := <-done:
+		if result.Status == StatusError && context.Cause(ctx) == timeoutErr {
+			result.Err = fmt.Errorf("%w: %w", timeoutErr, result.Err)
+		}
+		return result
+	case <-ctx.Done():
+		return JobResult[I, O]{Input: input, Status: StatusError, Err: context.Cause(ctx)}
+	}
+}
+
+func recoverJob[I, O any](ctx context.Context, job ContextJob[I, O], input I) (result JobResult[I, O]) {
+	defer func() {
+		if v := recover(); v != nil {
+			result = JobResult[I, O]{Input: input, Status: StatusError, Err: &PanicError{Value: v, Stack: debug.Stack()}}
+		}
+	}()
+	return job(ctx, input)
+}
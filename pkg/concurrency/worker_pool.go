@@ -1,41 +1,118 @@
 package concurrency
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"sync"
+	"time"
 )
 
 type workerRequest[I any, O any] struct {
-	input    I
-	resultCh chan JobResult[I, O]
+	ctx       context.Context
+	input     I
+	resultCh  chan JobResult[I, O]
+	submitted time.Time
 }
 
 type WorkerPool[I any, O any] struct {
-	job        Job[I, O]
+	job        ContextJob[I, O]
 	numWorkers int
+	overflow   OverflowPolicy
+	timeout    time.Duration
 	queue      chan workerRequest[I, O]
+	retire     chan struct{}
 	wg         sync.WaitGroup
 	ctx        context.Context
 	cancel     context.CancelFunc
+	counters   poolCounters
+
+	mu         sync.Mutex
+	started    bool
+	closed     bool
+	closing    chan struct{}
+	submitters sync.WaitGroup
+	closeOnce  sync.Once
+}
+
+func NewWorkerPool[I any, O any](job Job[I, O], numWorkers int, options ...PoolOption) *WorkerPool[I, O] {
+	return NewContextWorkerPool(job.WithContext(), numWorkers, options...)
 }
 
-func NewWorkerPool[I any, O any](job Job[I, O], numWorkers int) *WorkerPool[I, O] {
+// NewContextWorkerPool is NewWorkerPool for jobs that receive the context of
+// their submission, which is also canceled when Shutdown gives up waiting.
+func NewContextWorkerPool[I any, O any](job ContextJob[I, O], numWorkers int, options ...PoolOption) *WorkerPool[I, O] {
 	ctx, cancel := context.WithCancel(context.Background())
+	config := newPoolConfig(numWorkers, options)
 
 	return &WorkerPool[I, O]{
 		job:        job,
 		numWorkers: numWorkers,
-		queue:      make(chan workerRequest[I, O], numWorkers),
+		overflow:   config.overflow,
+		timeout:    config.timeout,
+		queue:      make(chan workerRequest[I, O], config.queueSize),
+		retire:     make(chan struct{}),
 		ctx:        ctx,
 		cancel:     cancel,
+		closing:    make(chan struct{}),
 	}
 }
 
 func (wp *WorkerPool[I, O]) Start() {
-	for range cap(wp.queue) {
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.started || wp.closed {
+		return
+	}
+	wp.started = true
+	for range wp.numWorkers {
+		wp.wg.Add(1)
+		go wp.worker()
+	}
+}
+
+// Resize adds or retires workers until there are n. Retiring workers finish
+// their current job first.
+func (wp *WorkerPool[I, O]) Resize(n int) error {
+	if n < 1 {
+		return fmt.Errorf("%w: %d workers", ErrInvalidSize, n)
+	}
+	wp.mu.Lock()
+	defer wp.mu.Unlock()
+
+	if wp.closed {
+		return ErrPoolClosed
+	}
+	if !wp.started {
+		wp.numWorkers = n
+		return nil
+	}
+	for ; wp.numWorkers < n; wp.numWorkers++ {
 		wp.wg.Add(1)
 		go wp.worker()
 	}
+	if retiring := wp.numWorkers - n; retiring > 0 {
+		go func() {
+			for range retiring {
+				select {
+				case wp.retire <- struct{}{}:
+				case <-wp.ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	wp.numWorkers = n
+	return nil
+}
+
+// Stats returns a snapshot of the pool's workers, queue and finished jobs.
+func (wp *WorkerPool[I, O]) Stats() PoolStats {
+	wp.mu.Lock()
+	workers := wp.numWorkers
+	wp.mu.Unlock()
+	return wp.counters.stats(workers, len(wp.queue))
 }
 
 func (wp *WorkerPool[I, O]) worker() {
@@ -43,35 +120,147 @@ func (wp *WorkerPool[I, O]) worker() {
 
 	for {
 		select {
-		case <-wp.ctx.Done():
-			// Drain remaining requests and close their result channels
-			for req := range wp.queue {
-				close(req.resultCh)
-			}
+		case <-wp.retire:
 			return
 		case req, ok := <-wp.queue:
 			if !ok {
 				return
 			}
-			result := wp.job(req.input)
-			req.resultCh <- result
-			close(req.resultCh)
+			wp.run(req)
 		}
 	}
 }
 
+func (wp *WorkerPool[I, O]) run(req workerRequest[I, O]) {
+	execute(wp.ctx, wp.job, wp.timeout, &wp.counters, req)
+}
+
+// execute sends the result of req and closes its channel. Requests whose context
+// is done before they start, or that are still queued when the pool's context is
+// canceled, are skipped with the context's error.
+func execute[I, O any](poolCtx context.Context, job ContextJob[I, O], timeout time.Duration, counters *poolCounters, req workerRequest[I, O]) {
+	if err := cmp.Or(req.ctx.Err(), poolCtx.Err()); err != nil {
+		req.skip(err)
+		return
+	}
+	defer close(req.resultCh)
+
+	ctx, cancel := context.WithCancel(req.ctx)
+	defer cancel()
+	stop := context.AfterFunc(poolCtx, cancel)
+	defer stop()
+
+	counters.active.Add(1)
+	result := runJob(ctx, job, req.input, timeout)
+	counters.active.Add(-1)
+	counters.finish(result.Status, req.submitted)
+	req.resultCh <- result
+}
+
+func (req workerRequest[I, O]) skip(err error) {
+	req.resultCh <- JobResult[I, O]{Input: req.input, Status: StatusSkipped, Err: err}
+	close(req.resultCh)
+}
+
 func (wp *WorkerPool[I, O]) Submit(input I) (chan JobResult[I, O], error) {
+	return wp.SubmitContext(context.Background(), input)
+}
+
+// SubmitContext queues input, under OverflowBlock waiting for room until ctx is
+// done. The job runs with ctx, so it is skipped if ctx is done while it is
+// queued and sees the cancellation while it runs.
+func (wp *WorkerPool[I, O]) SubmitContext(ctx context.Context, input I) (chan JobResult[I, O], error) {
+	return wp.submit(ctx, input, true)
+}
+
+// TrySubmit queues input without waiting, failing with ErrQueueFull when the
+// queue is full unless the pool drops the oldest job instead.
+func (wp *WorkerPool[I, O]) TrySubmit(input I) (chan JobResult[I, O], error) {
+	return wp.submit(context.Background(), input, false)
+}
+
+func (wp *WorkerPool[I, O]) submit(ctx context.Context, input I, wait bool) (chan JobResult[I, O], error) {
+	wp.mu.Lock()
+	if wp.closed {
+		wp.mu.Unlock()
+		return nil, ErrPoolClosed
+	}
+	wp.submitters.Add(1)
+	wp.mu.Unlock()
+	defer wp.submitters.Done()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	resultCh := make(chan JobResult[I, O], 1)
+	req := workerRequest[I, O]{ctx: ctx, input: input, resultCh: resultCh, submitted: time.Now()}
+	select {
+	case wp.queue <- req:
+		return resultCh, nil
+	default:
+	}
+
+	switch {
+	case wp.overflow == OverflowDropOldest && cap(wp.queue) > 0:
+		for {
+			select {
+			case wp.queue <- req:
+				return resultCh, nil
+			default:
+			}
+			select {
+			case old := <-wp.queue:
+				wp.counters.dropped.Add(1)
+				old.skip(fmt.Errorf("%w: dropped for a newer job", ErrQueueFull))
+			default:
+			}
+		}
+	case !wait || wp.overflow != OverflowBlock:
+		return nil, ErrQueueFull
+	}
+
 	select {
-	case wp.queue <- workerRequest[I, O]{input: input, resultCh: resultCh}:
+	case wp.queue <- req:
 		return resultCh, nil
-	case <-wp.ctx.Done():
-		return nil, wp.ctx.Err()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-wp.closing:
+		return nil, ErrPoolClosed
 	}
 }
 
-func (wp *WorkerPool[I, O]) Shutdown() {
-	close(wp.queue)
+// Shutdown stops accepting jobs and waits for the queued and running ones to
+// finish. If ctx is done first, it cancels the running jobs, skips the queued
+// ones and returns ctx's error once the workers have stopped.
+func (wp *WorkerPool[I, O]) Shutdown(ctx context.Context) error {
+	wp.closeOnce.Do(func() {
+		wp.mu.Lock()
+		wp.closed = true
+		wp.mu.Unlock()
+		close(wp.closing)
+		wp.submitters.Wait()
+		close(wp.queue)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		wp.wg.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+		wp.cancel()
+		<-done
+	}
 	wp.cancel()
-	wp.wg.Wait()
+
+	// Without workers, nothing has taken the queued requests.
+	for req := range wp.queue {
+		wp.run(req)
+	}
+	return err
 }
@@ -1,10 +1,12 @@
 package concurrency_test
 
 import (
+	"context"
 	"errors"
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	. "tsumegolang/pkg/concurrency"
 )
@@ -131,7 +133,9 @@ func TestWorkerPoolCancellation(t *testing.T) {
 		t.Fatalf("Submit failed: %v", err)
 	}
 
-	wp.Shutdown()
+	if err := wp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
 
 	// After shutdown, all result channels should be closed
 	// Some may have completed, some may have been drained without processing
@@ -148,3 +152,328 @@ func TestWorkerPoolCancellation(t *testing.T) {
 		// If ok is false, channel was closed without sending (drained during cancellation)
 	}
 }
+
+func TestWorkerPoolSubmitContext(t *testing.T) {
+	release := make(chan struct{})
+	job := func(ctx context.Context, input string) JobResult[string, int] {
+		select {
+		case <-release:
+			return JobResult[string, int]{Input: input, Output: len(input), Status: StatusSuccess}
+		case <-ctx.Done():
+			return JobResult[string, int]{Input: input, Status: StatusError, Err: ctx.Err()}
+		}
+	}
+
+	wp := NewContextWorkerPool(job, 1)
+	wp.Start()
+	defer wp.Shutdown(context.Background())
+
+	// The first job holds the only worker until its deadline passes.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	running, err := wp.SubmitContext(ctx, "running")
+	if err != nil {
+		t.Fatalf("SubmitContext failed: %v", err)
+	}
+	queuedCtx, cancelQueued := context.WithCancel(context.Background())
+	queued, err := wp.SubmitContext(queuedCtx, "queued")
+	if err != nil {
+		t.Fatalf("SubmitContext failed: %v", err)
+	}
+	cancelQueued()
+
+	if got := <-running; got.Status != StatusError || !errors.Is(got.Err, context.DeadlineExceeded) {
+		t.Errorf("Expected the running job to see its deadline, got %+v", got)
+	}
+	if got := <-queued; got.Status != StatusSkipped || !errors.Is(got.Err, context.Canceled) {
+		t.Errorf("Expected the queued job to be skipped, got %+v", got)
+	}
+
+	if _, err := wp.SubmitContext(queuedCtx, "late"); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected %v submitting with a done context, got %v", context.Canceled, err)
+	}
+
+	close(release)
+	resultCh, err := wp.SubmitContext(context.Background(), "done")
+	if err != nil {
+		t.Fatalf("SubmitContext failed: %v", err)
+	}
+	if got := <-resultCh; got.Status != StatusSuccess || got.Output != 4 {
+		t.Errorf("Expected a successful result, got %+v", got)
+	}
+}
+
+func TestWorkerPoolShutdownContext(t *testing.T) {
+	started := make(chan struct{})
+	job := func(ctx context.Context, input string) JobResult[string, int] {
+		started <- struct{}{}
+		<-ctx.Done()
+		return JobResult[string, int]{Input: input, Status: StatusError, Err: ctx.Err()}
+	}
+
+	wp := NewContextWorkerPool(job, 1)
+	wp.Start()
+	running, err := wp.Submit("running")
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-started
+	queued, err := wp.Submit("queued")
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := wp.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected Shutdown to give up with %v, got %v", context.DeadlineExceeded, err)
+	}
+
+	if got := <-running; got.Status != StatusError || !errors.Is(got.Err, context.Canceled) {
+		t.Errorf("Expected the running job to be canceled, got %+v", got)
+	}
+	if got := <-queued; got.Status != StatusSkipped {
+		t.Errorf("Expected the queued job to be skipped, got %+v", got)
+	}
+	if _, err := wp.Submit("late"); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected %v after shutdown, got %v", ErrPoolClosed, err)
+	}
+}
+
+// newBlockedPool returns a started pool with one worker held by a running job
+// until release is closed.
+func newBlockedPool(t *testing.T, options ...PoolOption) (*WorkerPool[string, int], chan struct{}) {
+	t.Helper()
+
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	job := func(input string) JobResult[string, int] {
+		started <- struct{}{}
+		<-release
+		return JobResult[string, int]{Input: input, Output: len(input), Status: StatusSuccess}
+	}
+	wp := NewWorkerPool(job, 1, options...)
+	wp.Start()
+	if _, err := wp.Submit("running"); err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	<-started
+	return wp, release
+}
+
+func TestWorkerPoolOverflow(t *testing.T) {
+	testCases := []struct {
+		name        string
+		policy      OverflowPolicy
+		wantErr     error
+		wantDropped int
+	}{
+		{name: "Fail fast", policy: OverflowFail, wantErr: ErrQueueFull},
+		{name: "Drop oldest", policy: OverflowDropOldest, wantDropped: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			wp, release := newBlockedPool(t, WithQueueSize(2), WithOverflow(tc.policy))
+			oldest, err := wp.Submit("oldest")
+			if err != nil {
+				t.Fatalf("Submit failed: %v", err)
+			}
+			if _, err := wp.Submit("second"); err != nil {
+				t.Fatalf("Submit failed: %v", err)
+			}
+			if got := wp.Stats().Queued; got != 2 {
+				t.Errorf("Expected 2 queued jobs, got %d", got)
+			}
+
+			if _, err := wp.Submit("third"); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Expected %v submitting to a full queue, got %v", tc.wantErr, err)
+			}
+			if tc.wantDropped > 0 {
+				if got := <-oldest; got.Status != StatusSkipped || !errors.Is(got.Err, ErrQueueFull) {
+					t.Errorf("Expected the oldest job to be dropped, got %+v", got)
+				}
+			}
+			if got := wp.Stats().Dropped; got != tc.wantDropped {
+				t.Errorf("Expected %d dropped jobs, got %d", tc.wantDropped, got)
+			}
+
+			close(release)
+			if err := wp.Shutdown(context.Background()); err != nil {
+				t.Fatalf("Shutdown failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestWorkerPoolTrySubmit(t *testing.T) {
+	wp, release := newBlockedPool(t, WithQueueSize(1))
+	if _, err := wp.TrySubmit("queued"); err != nil {
+		t.Fatalf("TrySubmit failed: %v", err)
+	}
+	if _, err := wp.TrySubmit("full"); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected %v from a full queue, got %v", ErrQueueFull, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := wp.SubmitContext(ctx, "blocked"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected a blocked submission to time out, got %v", err)
+	}
+
+	close(release)
+	if err := wp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+}
+
+func TestWorkerPoolResize(t *testing.T) {
+	var running, peak int
+	var mu sync.Mutex
+	release := make(chan struct{})
+	job := func(input int) JobResult[int, int] {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return JobResult[int, int]{Input: input, Output: input, Status: StatusSuccess}
+	}
+
+	wp := NewWorkerPool(job, 1, WithQueueSize(10))
+	if err := wp.Resize(0); !errors.Is(err, ErrInvalidSize) {
+		t.Errorf("Expected %v resizing to no workers, got %v", ErrInvalidSize, err)
+	}
+	wp.Start()
+	if err := wp.Resize(4); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+
+	var results []chan JobResult[int, int]
+	for i := range 8 {
+		resultCh, err := wp.Submit(i)
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		results = append(results, resultCh)
+	}
+	deadline := time.Now().Add(time.Second)
+	for wp.Stats().Active < 4 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := wp.Stats(); got.Workers != 4 || got.Active != 4 || got.Queued != 4 {
+		t.Errorf("Expected 4 workers busy with 4 jobs queued, got %+v", got)
+	}
+
+	if err := wp.Resize(2); err != nil {
+		t.Fatalf("Resize failed: %v", err)
+	}
+	close(release)
+	for _, resultCh := range results {
+		<-resultCh
+	}
+	if err := wp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	if err := wp.Resize(3); !errors.Is(err, ErrPoolClosed) {
+		t.Errorf("Expected %v resizing after shutdown, got %v", ErrPoolClosed, err)
+	}
+
+	stats := wp.Stats()
+	if peak != 4 || stats.Workers != 2 || stats.Completed != 8 || stats.Failed != 0 || stats.AverageLatency <= 0 {
+		t.Errorf("Expected 8 jobs completed by at most 4 workers, then 2 workers; got peak %d and %+v", peak, stats)
+	}
+}
+
+func TestWorkerPoolStats(t *testing.T) {
+	job := func(input string) JobResult[string, int] {
+		if input == "" {
+			return JobResult[string, int]{Input: input, Status: StatusError, Err: errors.New("empty")}
+		}
+		return JobResult[string, int]{Input: input, Output: len(input), Status: StatusSuccess}
+	}
+	wp := NewWorkerPool(job, 2)
+	wp.Start()
+	for _, input := range []string{"a", "", "bc"} {
+		resultCh, err := wp.Submit(input)
+		if err != nil {
+			t.Fatalf("Submit failed: %v", err)
+		}
+		<-resultCh
+	}
+	if err := wp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	got := wp.Stats()
+	if got.Workers != 2 || got.Queued != 0 || got.Active != 0 || got.Completed != 3 || got.Failed != 1 || got.Dropped != 0 {
+		t.Errorf("Expected 3 jobs completed and 1 failed by 2 workers, got %+v", got)
+	}
+}
+
+func TestWorkerPoolRecovery(t *testing.T) {
+	job := func(ctx context.Context, input string) JobResult[string, int] {
+		switch input {
+		case "panic":
+			panic("bad input")
+		case "slow":
+			<-ctx.Done()
+			return JobResult[string, int]{Input: input, Status: StatusError, Err: ctx.Err()}
+		case "stuck":
+			select {}
+		}
+		return JobResult[string, int]{Input: input, Output: len(input), Status: StatusSuccess}
+	}
+
+	testCases := []struct {
+		input      string
+		wantStatus JobStatus
+		wantErr    error
+	}{
+		{input: "panic", wantStatus: StatusError, wantErr: ErrJobPanicked},
+		{input: "slow", wantStatus: StatusError, wantErr: ErrJobTimeout},
+		{input: "stuck", wantStatus: StatusError, wantErr: ErrJobTimeout},
+		{input: "fine", wantStatus: StatusSuccess},
+	}
+
+	wp := NewContextWorkerPool(job, 2, WithJobTimeout(20*time.Millisecond))
+	wp.Start()
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			resultCh, err := wp.Submit(tc.input)
+			if err != nil {
+				t.Fatalf("Submit failed: %v", err)
+			}
+			got, ok := <-resultCh
+			if !ok {
+				t.Fatalf("Expected a result before the channel closed")
+			}
+			if got.Status != tc.wantStatus || !errors.Is(got.Err, tc.wantErr) {
+				t.Errorf("Expected status %v with error %v, got %+v", tc.wantStatus, tc.wantErr, got)
+			}
+			if _, open := <-resultCh; open {
+				t.Errorf("Expected the result channel to be closed")
+			}
+		})
+	}
+	if err := wp.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	panicky := NewWorkerPool(func(string) JobResult[string, int] { panic("again") }, 1)
+	panicky.Start()
+	resultCh, err := panicky.Submit("x")
+	if err != nil {
+		t.Fatalf("Submit failed: %v", err)
+	}
+	var panicErr *PanicError
+	if got := <-resultCh; !errors.As(got.Err, &panicErr) || panicErr.Value != "again" || len(panicErr.Stack) == 0 {
+		t.Errorf("Expected a *PanicError with the value and stack, got %v", got.Err)
+	}
+	if err := panicky.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+}
@@ -0,0 +1,12 @@
+package gridgraph
+
+import (
+	"errors"
+)
+
+var (
+	ErrInvalidConfiguration = errors.New("invalid configuration for grid graph")
+	ErrNoSuchNode           = errors.New("no such node in the graph")
+	ErrNoSuchEdge           = errors.New("no such edge in the graph")
+	ErrNotNeighbors         = errors.New("nodes are not neighbors in the grid")
+)
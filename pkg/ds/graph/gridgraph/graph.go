@@ -0,0 +1,211 @@
+// Package gridgraph implements an undirected graph over the cells of a
+// rectangular grid, in which only neighboring cells can be connected. Node n is
+// the cell in row n/width and column n%width. Each node keeps one bit for the
+// edge to its east neighbor and one for its south neighbor, so a graph of a
+// million nodes takes a quarter of a megabyte. Edges all have weight Weight.
+
+package gridgraph
+
+import (
+	"iter"
+	"math/bits"
+
+	"tsumegolang/pkg/ds/graph"
+)
+
+const (
+	Weight = 1.0 // the weight of every edge; weights passed to Connect are ignored
+)
+
+type Graph struct {
+	width  int
+	height int
+	east   []uint64 // bit n is set when node n connects to n+1
+	south  []uint64 // bit n is set when node n connects to n+width
+}
+
+// NewGraph creates a graph over a width x height grid with no edges.
+// Returns an error if either dimension is less than or equal to zero.
+func NewGraph(width, height int) (*Graph, error) {
+	if width <= 0 || height <= 0 {
+		return nil, ErrInvalidConfiguration
+	}
+
+	words := (width*height + 63) / 64
+	return &Graph{
+		width:  width,
+		height: height,
+		east:   make([]uint64, words),
+		south:  make([]uint64, words),
+	}, nil
+}
+
+// Width returns the number of columns of the grid.
+func (g *Graph) Width() int {
+	return g.width
+}
+
+// Height returns the number of rows of the grid.
+func (g *Graph) Height() int {
+	return g.height
+}
+
+// IsDirected returns false: grid graphs are undirected.
+func (g *Graph) IsDirected() bool {
+	return false
+}
+
+// Copy creates a deep copy of the graph and returns it.
+func (g *Graph) Copy() *Graph {
+	return &Graph{
+		width:  g.width,
+		height: g.height,
+		east:   append([]uint64(nil), g.east...),
+		south:  append([]uint64(nil), g.south...),
+	}
+}
+
+// GetSize returns the number of nodes in the graph.
+func (g *Graph) GetSize() int {
+	return g.width * g.height
+}
+
+// checkIdx checks if the given index is valid for the graph.
+func (g *Graph) checkIdx(i int) error {
+	if i < 0 || i >= g.GetSize() {
+		return ErrNoSuchNode
+	}
+
+	return nil
+}
+
+// bit returns the bitset holding the edge between nodes i and j, and the edge's
+// position in it. Returns false if the nodes are not neighbors.
+func (g *Graph) bit(i, j int) ([]uint64, int, bool) {
+	if i > j {
+		i, j = j, i
+	}
+	switch {
+	case j == i+1 && j%g.width != 0:
+		return g.east, i, true
+	case j == i+g.width:
+		return g.south, i, true
+	}
+	return nil, 0, false
+}
+
+func has(set []uint64, n int) bool {
+	return set[n/64]&(1<<(n%64)) != 0
+}
+
+// Connect creates an edge between nodes i and j, which must be neighbors in the
+// grid. The weight is ignored. Returns an error if either node index is invalid
+// or the nodes are not neighbors.
+func (g *Graph) Connect(i, j int, w float64) error {
+	if err := g.checkIdx(i); err != nil {
+		return err
+	}
+	if err := g.checkIdx(j); err != nil {
+		return err
+	}
+
+	set, n, ok := g.bit(i, j)
+	if !ok {
+		return ErrNotNeighbors
+	}
+	set[n/64] |= 1 << (n % 64)
+
+	return nil
+}
+
+// Disconnect removes the edge between nodes i and j.
+// Returns an error if either node index is invalid or if the edge does not exist.
+func (g *Graph) Disconnect(i, j int) error {
+	if err := g.checkIdx(i); err != nil {
+		return err
+	}
+	if err := g.checkIdx(j); err != nil {
+		return err
+	}
+
+	set, n, ok := g.bit(i, j)
+	if !ok || !has(set, n) {
+		return ErrNoSuchEdge
+	}
+	set[n/64] &^= 1 << (n % 64)
+
+	return nil
+}
+
+// Connected reports whether there is an edge between nodes i and j. Unlike
+// GetEdge it does not allocate, for callers that visit every wall.
+func (g *Graph) Connected(i, j int) bool {
+	if g.checkIdx(i) != nil || g.checkIdx(j) != nil {
+		return false
+	}
+	set, n, ok := g.bit(i, j)
+	return ok && has(set, n)
+}
+
+// GetEdge retrieves the edge between nodes i and j, from the lower index to the
+// higher. Returns the edge and a boolean indicating whether the edge exists.
+func (g *Graph) GetEdge(i, j int) (graph.Edge, bool) {
+	if !g.Connected(i, j) {
+		return graph.Edge{}, false
+	}
+	return graph.Edge{From: min(i, j), To: max(i, j), Weight: Weight}, true
+}
+
+// Neighbors returns the nodes connected to node i in ascending order.
+func (g *Graph) Neighbors(i int) []int {
+	neighbors := make([]int, 0, 4)
+	for _, j := range []int{i - g.width, i - 1, i + 1, i + g.width} {
+		if g.Connected(i, j) {
+			neighbors = append(neighbors, j)
+		}
+	}
+	return neighbors
+}
+
+// GetAllNodes returns a slice of all nodes in the graph.
+func (g *Graph) GetAllNodes() []int {
+	nodes := make([]int, g.GetSize())
+	for i := range nodes {
+		nodes[i] = i
+	}
+
+	return nodes
+}
+
+// GetAllEdges returns all edges in the graph, ordered by source and then
+// destination as by sparsegraph.
+func (g *Graph) GetAllEdges() []graph.Edge {
+	edges := make([]graph.Edge, 0, g.EdgeCount())
+	for e := range g.Edges() {
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+// Edges yields the edges of GetAllEdges one at a time, without collecting them.
+func (g *Graph) Edges() iter.Seq[graph.Edge] {
+	return func(yield func(graph.Edge) bool) {
+		for i := range g.GetSize() {
+			if has(g.east, i) && !yield(graph.Edge{From: i, To: i + 1, Weight: Weight}) {
+				return
+			}
+			if has(g.south, i) && !yield(graph.Edge{From: i, To: i + g.width, Weight: Weight}) {
+				return
+			}
+		}
+	}
+}
+
+// EdgeCount returns the number of edges in the graph.
+func (g *Graph) EdgeCount() int {
+	count := 0
+	for k := range g.east {
+		count += bits.OnesCount64(g.east[k]) + bits.OnesCount64(g.south[k])
+	}
+	return count
+}
@@ -0,0 +1,168 @@
+package gridgraph_test
+
+import (
+	"reflect"
+	"testing"
+
+	"tsumegolang/pkg/algo/graph/kruskal"
+	"tsumegolang/pkg/ds/graph"
+	. "tsumegolang/pkg/ds/graph/gridgraph"
+	"tsumegolang/pkg/ds/graph/sparsegraph"
+)
+
+func TestNewGraph(t *testing.T) {
+	testCases := []struct {
+		name          string
+		width, height int
+		wantErr       error
+	}{
+		{name: "single node", width: 1, height: 1},
+		{name: "wide", width: 70, height: 2},
+		{name: "no columns", width: 0, height: 3, wantErr: ErrInvalidConfiguration},
+		{name: "no rows", width: 3, height: -1, wantErr: ErrInvalidConfiguration},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGraph(tc.width, tc.height)
+			if err != tc.wantErr {
+				t.Fatalf("NewGraph(%d, %d) error = %v; want %v", tc.width, tc.height, err, tc.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := g.GetSize(); got != tc.width*tc.height {
+				t.Errorf("GetSize() = %d; want %d", got, tc.width*tc.height)
+			}
+			if g.IsDirected() {
+				t.Errorf("IsDirected() = true; want false")
+			}
+		})
+	}
+}
+
+func TestConnect(t *testing.T) {
+	testCases := []struct {
+		name    string
+		i, j    int
+		wantErr error
+	}{
+		{name: "east", i: 0, j: 1},
+		{name: "west", i: 5, j: 4},
+		{name: "south", i: 1, j: 5},
+		{name: "north", i: 6, j: 2},
+		{name: "across the end of a row", i: 3, j: 4, wantErr: ErrNotNeighbors},
+		{name: "diagonal", i: 0, j: 5, wantErr: ErrNotNeighbors},
+		{name: "itself", i: 2, j: 2, wantErr: ErrNotNeighbors},
+		{name: "invalid node index", i: -1, j: 0, wantErr: ErrNoSuchNode},
+		{name: "invalid node index 2", i: 11, j: 12, wantErr: ErrNoSuchNode},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := NewGraph(4, 3)
+			if err != nil {
+				t.Fatalf("NewGraph() failed: %v", err)
+			}
+
+			err = g.Connect(tc.i, tc.j, 2.5)
+			if err != tc.wantErr {
+				t.Fatalf("Connect(%d, %d) = %v; want %v", tc.i, tc.j, err, tc.wantErr)
+			}
+			if err != nil {
+				if g.EdgeCount() != 0 {
+					t.Errorf("EdgeCount() = %d after a failed Connect; want 0", g.EdgeCount())
+				}
+				return
+			}
+
+			want := graph.Edge{From: min(tc.i, tc.j), To: max(tc.i, tc.j), Weight: Weight}
+			for _, pair := range [][2]int{{tc.i, tc.j}, {tc.j, tc.i}} {
+				if e, ok := g.GetEdge(pair[0], pair[1]); !ok || !e.Equals(want) {
+					t.Errorf("GetEdge(%d, %d) = %v, %t; want %v", pair[0], pair[1], e, ok, want)
+				}
+			}
+
+			if err := g.Disconnect(tc.j, tc.i); err != nil {
+				t.Fatalf("Disconnect(%d, %d) failed: %v", tc.j, tc.i, err)
+			}
+			if g.Connected(tc.i, tc.j) {
+				t.Errorf("Connected(%d, %d) = true after Disconnect", tc.i, tc.j)
+			}
+			if err := g.Disconnect(tc.i, tc.j); err != ErrNoSuchEdge {
+				t.Errorf("Disconnect(%d, %d) = %v; want %v", tc.i, tc.j, err, ErrNoSuchEdge)
+			}
+		})
+	}
+}
+
+func TestEdges(t *testing.T) {
+	// 70 columns put the edges of the second row in the second word of each bitset.
+	g, err := NewGraph(70, 3)
+	if err != nil {
+		t.Fatalf("NewGraph() failed: %v", err)
+	}
+	want := []graph.Edge{
+		{From: 0, To: 1, Weight: Weight},
+		{From: 0, To: 70, Weight: Weight},
+		{From: 69, To: 139, Weight: Weight},
+		{From: 70, To: 71, Weight: Weight},
+		{From: 138, To: 139, Weight: Weight},
+	}
+	for _, e := range want {
+		if err := g.Connect(e.To.(int), e.From.(int), 0); err != nil {
+			t.Fatalf("Connect() failed: %v", err)
+		}
+	}
+
+	if got := g.GetAllEdges(); !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAllEdges() = %v; want %v", got, want)
+	}
+	if got := g.EdgeCount(); got != len(want) {
+		t.Errorf("EdgeCount() = %d; want %d", got, len(want))
+	}
+	if got := g.Neighbors(70); !reflect.DeepEqual(got, []int{0, 71}) {
+		t.Errorf("Neighbors(70) = %v; want [0 71]", got)
+	}
+
+	cp := g.Copy()
+	cp.Disconnect(0, 1)
+	if !g.Connected(0, 1) {
+		t.Errorf("Disconnect on copy removed edge from original")
+	}
+}
+
+func TestMST(t *testing.T) {
+	// A grid graph can hold the spanning tree Kruskal's algorithm finds in a
+	// sparse graph of the same grid.
+	candidates, err := sparsegraph.NewGraph(12, false)
+	if err != nil {
+		t.Fatalf("NewGraph() failed: %v", err)
+	}
+	for i := range 12 {
+		if i%4 < 3 {
+			candidates.Connect(i, i+1, float64(i%5))
+		}
+		if i < 8 {
+			candidates.Connect(i, i+4, float64(i%3))
+		}
+	}
+
+	want, _ := sparsegraph.NewGraph(12, false)
+	if err := kruskal.MST(candidates, want); err != nil {
+		t.Fatalf("MST() failed: %v", err)
+	}
+	got, _ := NewGraph(4, 3)
+	if err := kruskal.MST(candidates, got); err != nil {
+		t.Fatalf("MST() failed: %v", err)
+	}
+
+	for _, e := range want.GetAllEdges() {
+		if !got.Connected(e.From.(int), e.To.(int)) {
+			t.Errorf("MST() into a grid graph is missing %v", e)
+		}
+	}
+	if got.EdgeCount() != 11 {
+		t.Errorf("EdgeCount() = %d; want 11", got.EdgeCount())
+	}
+}
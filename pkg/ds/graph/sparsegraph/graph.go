@@ -4,6 +4,9 @@
 package sparsegraph
 
 import (
+	"maps"
+	"slices"
+
 	"tsumegolang/pkg/ds/graph"
 )
 
@@ -148,15 +151,16 @@ func (g *Graph) GetAllNodes() []int {
 	return nodes
 }
 
-// GetAllEdges returns all edges in the graph as a slice of triples [weight, from, to].
+// GetAllEdges returns all edges in the graph as a slice of triples [weight, from, to],
+// ordered by source and then destination so that results are reproducible.
 func (g *Graph) GetAllEdges() []graph.Edge {
 	edges := make([]graph.Edge, 0)
 	for i, adj := range g.nodes {
 		if adj == nil {
 			continue
 		}
-		for j, w := range adj {
-			edges = append(edges, graph.Edge{From: i, To: j, Weight: w})
+		for _, j := range slices.Sorted(maps.Keys(adj)) {
+			edges = append(edges, graph.Edge{From: i, To: j, Weight: adj[j]})
 		}
 	}
 	return edges
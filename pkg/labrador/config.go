@@ -0,0 +1,477 @@
+package labrador
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvPrefix    = "LABRADOR_"
+	EnvConfigKey = EnvPrefix + "CONFIG"
+
+	ReportMarkdown = "markdown"
+	ReportJSON     = "json"
+)
+
+var (
+	ErrInvalidConfig   = fmt.Errorf("invalid configuration")
+	ErrUnknownKey      = fmt.Errorf("unknown configuration key")
+	ErrUnsupportedFile = fmt.Errorf("unsupported configuration file type")
+	ErrParseTOML       = fmt.Errorf("failed to parse TOML")
+)
+
+// Config holds every setting used by a Downloader.
+type Config struct {
+	RetryCount    int           // Number of attempts per URL.
+	Backoff       time.Duration // Wait between attempts.
+	Workers       int           // Number of concurrent downloads.
+	Timeout       time.Duration // Timeout for a single HTTP request; zero disables it.
+	ResultTimeout time.Duration // Maximum wait for the result of a single URL; zero disables it.
+	OutputDir     string        // Base directory for downloaded files and reports.
+	Reports       []string      // Report formats to write into OutputDir.
+}
+
+// DefaultConfig returns the settings used when nothing else is specified.
+func DefaultConfig() Config {
+	return Config{
+		RetryCount:    defaultRetryCount,
+		Backoff:       defaultBackoffMs * time.Millisecond,
+		Workers:       defaultWorkerCount,
+		Timeout:       defaultTimeout,
+		ResultTimeout: defaultResultTimeout,
+		OutputDir:     "downloads",
+		Reports:       []string{ReportMarkdown},
+	}
+}
+
+// configKey describes one setting as it appears in config files, environment variables and flags.
+type configKey struct {
+	name  string // key in config files; the environment variable is EnvPrefix + upper-cased name
+	flag  string
+	usage string
+	set   func(*Config, string) error
+}
+
+var configKeys = []configKey{
+	{
+		name:  "retry_count",
+		flag:  "retry-count",
+		usage: "number of times to try a download before giving up",
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			c.RetryCount = n
+			return err
+		},
+	},
+	{
+		name:  "backoff",
+		flag:  "backoff",
+		usage: "wait between retries, as a duration or in milliseconds",
+		set: func(c *Config, v string) error {
+			d, err := parseDuration(v)
+			c.Backoff = d
+			return err
+		},
+	},
+	{
+		name:  "workers",
+		flag:  "worker-count",
+		usage: "number of concurrent workers to use for downloading",
+		set: func(c *Config, v string) error {
+			n, err := strconv.Atoi(v)
+			c.Workers = n
+			return err
+		},
+	},
+	{
+		name:  "timeout",
+		flag:  "timeout",
+		usage: "timeout for a single HTTP request, as a duration or in milliseconds",
+		set: func(c *Config, v string) error {
+			d, err := parseDuration(v)
+			c.Timeout = d
+			return err
+		},
+	},
+	{
+		name:  "result_timeout",
+		flag:  "result-timeout",
+		usage: "maximum wait for the result of a single URL, as a duration or in milliseconds",
+		set: func(c *Config, v string) error {
+			d, err := parseDuration(v)
+			c.ResultTimeout = d
+			return err
+		},
+	},
+	{
+		name:  "output_dir",
+		flag:  "output-dir",
+		usage: "base directory for downloaded files and reports",
+		set: func(c *Config, v string) error {
+			c.OutputDir = v
+			return nil
+		},
+	},
+	{
+		name:  "reports",
+		flag:  "reports",
+		usage: "comma-separated report formats to write (markdown, json)",
+		set: func(c *Config, v string) error {
+			c.Reports = splitList(v)
+			return nil
+		},
+	},
+}
+
+// parseDuration accepts either a Go duration string or a bare number of milliseconds.
+func parseDuration(v string) (time.Duration, error) {
+	if ms, err := strconv.Atoi(v); err == nil {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	return time.ParseDuration(v)
+}
+
+func splitList(v string) []string {
+	items := []string{}
+	for item := range strings.SplitSeq(v, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Set assigns a single setting by its config file key.
+func (c *Config) Set(key, value string) error {
+	for _, k := range configKeys {
+		if k.name == key {
+			if err := k.set(c, strings.TrimSpace(value)); err != nil {
+				return fmt.Errorf("%w: %s: %w", ErrInvalidConfig, key, err)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownKey, key)
+}
+
+// Validate reports whether the settings can be used to build a Downloader.
+func (c Config) Validate() error {
+	if c.RetryCount < 1 {
+		return fmt.Errorf("%w: retry_count must be at least 1", ErrInvalidConfig)
+	}
+	if c.Workers < 1 {
+		return fmt.Errorf("%w: workers must be at least 1", ErrInvalidConfig)
+	}
+	if c.Backoff < 0 || c.Timeout < 0 || c.ResultTimeout < 0 {
+		return fmt.Errorf("%w: durations must not be negative", ErrInvalidConfig)
+	}
+	if c.OutputDir == "" {
+		return fmt.Errorf("%w: output_dir must not be empty", ErrInvalidConfig)
+	}
+	for _, report := range c.Reports {
+		if report != ReportMarkdown && report != ReportJSON {
+			return fmt.Errorf("%w: unknown report format %q", ErrInvalidConfig, report)
+		}
+	}
+	return nil
+}
+
+// LoadFile applies the settings in a YAML (.yaml, .yml) or TOML (.toml) file.
+func (c *Config) LoadFile(filename string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrCantOpenFile, err)
+	}
+
+	var values map[string]string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		values, err = parseYAMLConfig(data)
+	case ".toml":
+		values, err = parseTOMLConfig(data)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedFile, filename)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Apply known keys in a fixed order so errors are reported consistently.
+	for _, k := range configKeys {
+		if v, ok := values[k.name]; ok {
+			if err := c.Set(k.name, v); err != nil {
+				return err
+			}
+			delete(values, k.name)
+		}
+	}
+	for key := range values {
+		return fmt.Errorf("%w: %s", ErrUnknownKey, key)
+	}
+
+	return nil
+}
+
+// LoadEnv applies every EnvPrefix variable found by lookup, e.g. LABRADOR_RETRY_COUNT.
+func (c *Config) LoadEnv(lookup func(string) (string, bool)) error {
+	for _, k := range configKeys {
+		if v, ok := lookup(EnvPrefix + strings.ToUpper(k.name)); ok {
+			if err := c.Set(k.name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadConfig registers the configuration flags (plus -config) on fs, parses args and
+// builds a Config. Settings are applied in increasing order of precedence: defaults,
+// the config file named by -config or LABRADOR_CONFIG, LABRADOR_* environment variables,
+// and finally the flags set in args.
+func LoadConfig(fs *flag.FlagSet, args []string, lookup func(string) (string, bool)) (Config, error) {
+	cfg := DefaultConfig()
+
+	configFile := fs.String("config", "", "YAML or TOML file with labrador settings")
+	type flagValue struct {
+		key   string
+		value string
+	}
+	var flagValues []flagValue
+	for _, k := range configKeys {
+		fs.Func(k.flag, k.usage, func(v string) error {
+			flagValues = append(flagValues, flagValue{key: k.name, value: v})
+			return nil
+		})
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	filename := *configFile
+	if filename == "" {
+		filename, _ = lookup(EnvConfigKey)
+	}
+	if filename != "" {
+		if err := cfg.LoadFile(filename); err != nil {
+			return cfg, err
+		}
+	}
+
+	if err := cfg.LoadEnv(lookup); err != nil {
+		return cfg, err
+	}
+
+	for _, fv := range flagValues {
+		if err := cfg.Set(fv.key, fv.value); err != nil {
+			return cfg, err
+		}
+	}
+
+	return cfg, cfg.Validate()
+}
+
+// parseYAMLConfig reads a flat YAML mapping. Nested maps, and lists holding
+// anything but plain values, are rejected with ErrInvalidConfig.
+func parseYAMLConfig(data []byte) (map[string]string, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseYAML, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for key, v := range raw {
+		value, err := stringifyValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrInvalidConfig, key, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// stringifyValue turns a scalar, or a list of scalars, into the text Set takes.
+func stringifyValue(v any) (string, error) {
+	switch v := v.(type) {
+	case []any:
+		items := make([]string, len(v))
+		for i, item := range v {
+			if !isScalar(item) {
+				return "", fmt.Errorf("lists may only hold plain values")
+			}
+			items[i] = fmt.Sprint(item)
+		}
+		return strings.Join(items, ","), nil
+	case nil:
+		return "", nil
+	}
+	if !isScalar(v) {
+		return "", fmt.Errorf("nested values are not supported")
+	}
+	return fmt.Sprint(v), nil
+}
+
+func isScalar(v any) bool {
+	switch v.(type) {
+	case string, bool, int, int64, uint64, float64, time.Time:
+		return true
+	}
+	return false
+}
+
+// parseTOMLConfig understands the subset of TOML needed for a flat settings file:
+// comments, and key = value pairs on one line each whose values are strings,
+// integers, floats, booleans or one-line arrays of those. Tables, inline tables,
+// multi-line strings and arrays, and dates are rejected with ErrParseTOML.
+func parseTOMLConfig(data []byte) (map[string]string, error) {
+	values := map[string]string{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripTOMLComment(scanner.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			return nil, fmt.Errorf("%w: line %d: tables are not supported", ErrParseTOML, lineNo)
+		}
+
+		key, raw, found := strings.Cut(line, "=")
+		if !found {
+			return nil, fmt.Errorf("%w: line %d: expected key = value", ErrParseTOML, lineNo)
+		}
+		key = strings.Trim(strings.TrimSpace(key), `"`)
+		raw = strings.TrimSpace(raw)
+
+		value, err := parseTOMLValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrParseTOML, lineNo, err)
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrParseTOML, err)
+	}
+
+	return values, nil
+}
+
+func stripTOMLComment(line string) string {
+	var quote rune
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseTOMLValue(raw string) (string, error) {
+	switch {
+	case raw == "":
+		return "", fmt.Errorf("missing value")
+	case strings.HasPrefix(raw, `"""`) || strings.HasPrefix(raw, "'''"):
+		return "", fmt.Errorf("multi-line strings are not supported")
+	case strings.HasPrefix(raw, "{"):
+		return "", fmt.Errorf("inline tables are not supported")
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return "", fmt.Errorf("arrays must open and close on one line")
+		}
+		items := []string{}
+		elements, err := splitTOMLArray(raw[1 : len(raw)-1])
+		if err != nil {
+			return "", err
+		}
+		for _, item := range elements {
+			if strings.HasPrefix(item, "[") || strings.HasPrefix(item, "{") {
+				return "", fmt.Errorf("nested arrays and tables are not supported")
+			}
+			v, err := parseTOMLValue(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, v)
+		}
+		return strings.Join(items, ","), nil
+	case strings.HasPrefix(raw, `"`):
+		return strconv.Unquote(raw)
+	case strings.HasPrefix(raw, "'"):
+		if len(raw) < 2 || !strings.HasSuffix(raw, "'") {
+			return "", fmt.Errorf("unterminated string")
+		}
+		return raw[1 : len(raw)-1], nil
+	case raw == "true" || raw == "false":
+		return raw, nil
+	}
+	// Other bare values must be numbers; TOML allows underscores between digits.
+	number := strings.ReplaceAll(raw, "_", "")
+	if _, err := strconv.ParseFloat(number, 64); err != nil {
+		if _, err := strconv.ParseInt(number, 0, 64); err != nil {
+			return "", fmt.Errorf("unsupported value %s: want a string, number, boolean or array", raw)
+		}
+	}
+	return number, nil
+}
+
+// splitTOMLArray splits the inside of a one-line array at the commas between
+// its elements, leaving commas inside quoted strings alone.
+func splitTOMLArray(inner string) ([]string, error) {
+	var items []string
+	var quote rune
+	start := 0
+	for i, r := range inner {
+		switch {
+		case quote != 0:
+			if r == quote && !escaped(inner[:i], quote) {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			items = append(items, strings.TrimSpace(inner[start:i]))
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string in array")
+	}
+	// TOML allows a trailing comma.
+	if last := strings.TrimSpace(inner[start:]); last != "" {
+		items = append(items, last)
+	}
+	if slices.Contains(items, "") {
+		return nil, fmt.Errorf("empty array element")
+	}
+	return items, nil
+}
+
+// escaped reports whether a basic string's closing quote after before is
+// escaped by an odd number of backslashes.
+func escaped(before string, quote rune) bool {
+	if quote != '"' {
+		return false
+	}
+	n := len(before) - len(strings.TrimRight(before, `\`))
+	return n%2 == 1
+}
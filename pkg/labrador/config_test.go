@@ -0,0 +1,230 @@
+package labrador_test
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	. "tsumegolang/pkg/labrador"
+)
+
+func TestConfigLoadFile(t *testing.T) {
+	testCases := []struct {
+		name     string
+		filename string
+		content  string
+		want     Config
+		wantErr  error
+	}{
+		{
+			name:     "YAML file",
+			filename: "labrador.yaml",
+			content: `retry_count: 5
+backoff: 2s
+workers: 4
+timeout: 1500
+output_dir: out
+reports:
+  - markdown
+  - json
+`,
+			want: Config{
+				RetryCount:    5,
+				Backoff:       2 * time.Second,
+				Workers:       4,
+				Timeout:       1500 * time.Millisecond,
+				ResultTimeout: DefaultConfig().ResultTimeout,
+				OutputDir:     "out",
+				Reports:       []string{ReportMarkdown, ReportJSON},
+			},
+		},
+		{
+			name:     "TOML file",
+			filename: "labrador.toml",
+			content: `# labrador settings
+retry_count = 2
+backoff = "250ms" # quarter second
+workers = 1_0
+result_timeout = '1m'
+output_dir = "out # not a comment"
+reports = ["json"]
+`,
+			want: Config{
+				RetryCount:    2,
+				Backoff:       250 * time.Millisecond,
+				Workers:       10,
+				Timeout:       DefaultConfig().Timeout,
+				ResultTimeout: time.Minute,
+				OutputDir:     "out # not a comment",
+				Reports:       []string{ReportJSON},
+			},
+		},
+		{
+			name:     "unknown key",
+			filename: "labrador.yaml",
+			content:  "retries: 5\n",
+			wantErr:  ErrUnknownKey,
+		},
+		{
+			name:     "invalid value",
+			filename: "labrador.toml",
+			content:  "workers = \"many\"\n",
+			wantErr:  ErrInvalidConfig,
+		},
+		{
+			name:     "TOML table",
+			filename: "labrador.toml",
+			content:  "[labrador]\nworkers = 2\n",
+			wantErr:  ErrParseTOML,
+		},
+		{
+			name:     "TOML array of quoted commas",
+			filename: "labrador.toml",
+			content:  "output_dir = \"a, b\"\nreports = [\"json\", 'markdown',]\n",
+			want: Config{
+				RetryCount:    DefaultConfig().RetryCount,
+				Backoff:       DefaultConfig().Backoff,
+				Workers:       DefaultConfig().Workers,
+				Timeout:       DefaultConfig().Timeout,
+				ResultTimeout: DefaultConfig().ResultTimeout,
+				OutputDir:     "a, b",
+				Reports:       []string{ReportJSON, ReportMarkdown},
+			},
+		},
+		{
+			name:     "TOML inline table",
+			filename: "labrador.toml",
+			content:  "workers = { count = 2 }\n",
+			wantErr:  ErrParseTOML,
+		},
+		{
+			name:     "TOML nested array",
+			filename: "labrador.toml",
+			content:  "reports = [[\"json\"]]\n",
+			wantErr:  ErrParseTOML,
+		},
+		{
+			name:     "TOML multi-line array",
+			filename: "labrador.toml",
+			content:  "reports = [\n  \"json\",\n]\n",
+			wantErr:  ErrParseTOML,
+		},
+		{
+			name:     "TOML date",
+			filename: "labrador.toml",
+			content:  "output_dir = 2024-01-02\n",
+			wantErr:  ErrParseTOML,
+		},
+		{
+			name:     "YAML nested map",
+			filename: "labrador.yaml",
+			content:  "workers:\n  count: 2\n",
+			wantErr:  ErrInvalidConfig,
+		},
+		{
+			name:     "YAML list of maps",
+			filename: "labrador.yaml",
+			content:  "reports:\n  - name: json\n",
+			wantErr:  ErrInvalidConfig,
+		},
+		{
+			name:     "unsupported extension",
+			filename: "labrador.ini",
+			content:  "workers=2\n",
+			wantErr:  ErrUnsupportedFile,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.filename)
+			if err := os.WriteFile(path, []byte(tc.content), 0644); err != nil {
+				t.Fatalf("Failed to write config file: %v", err)
+			}
+
+			got := DefaultConfig()
+			err := got.LoadFile(path)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("LoadFile() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile() unexpected error: %v", err)
+			}
+			assertConfig(t, got, tc.want)
+		})
+	}
+}
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "labrador.yaml")
+	content := "retry_count: 5\nworkers: 4\noutput_dir: from-file\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write config file: %v", err)
+	}
+
+	env := map[string]string{
+		EnvConfigKey:          path,
+		"LABRADOR_WORKERS":    "6",
+		"LABRADOR_OUTPUT_DIR": "from-env",
+	}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	fs := flag.NewFlagSet("labrador", flag.ContinueOnError)
+	got, err := LoadConfig(fs, []string{"-output-dir", "from-flag", "-reports", "markdown,json"}, lookup)
+	if err != nil {
+		t.Fatalf("LoadConfig() unexpected error: %v", err)
+	}
+
+	want := DefaultConfig()
+	want.RetryCount = 5          // file
+	want.Workers = 6             // env beats file
+	want.OutputDir = "from-flag" // flag beats env
+	want.Reports = []string{ReportMarkdown, ReportJSON}
+	assertConfig(t, got, want)
+}
+
+func TestLoadConfigValidation(t *testing.T) {
+	fs := flag.NewFlagSet("labrador", flag.ContinueOnError)
+	noEnv := func(string) (string, bool) { return "", false }
+
+	_, err := LoadConfig(fs, []string{"-worker-count", "0"}, noEnv)
+	if !errors.Is(err, ErrInvalidConfig) {
+		t.Errorf("LoadConfig() error = %v, want %v", err, ErrInvalidConfig)
+	}
+}
+
+func assertConfig(t *testing.T, got, want Config) {
+	t.Helper()
+
+	if got.RetryCount != want.RetryCount {
+		t.Errorf("RetryCount = %d, want %d", got.RetryCount, want.RetryCount)
+	}
+	if got.Backoff != want.Backoff {
+		t.Errorf("Backoff = %v, want %v", got.Backoff, want.Backoff)
+	}
+	if got.Workers != want.Workers {
+		t.Errorf("Workers = %d, want %d", got.Workers, want.Workers)
+	}
+	if got.Timeout != want.Timeout {
+		t.Errorf("Timeout = %v, want %v", got.Timeout, want.Timeout)
+	}
+	if got.ResultTimeout != want.ResultTimeout {
+		t.Errorf("ResultTimeout = %v, want %v", got.ResultTimeout, want.ResultTimeout)
+	}
+	if got.OutputDir != want.OutputDir {
+		t.Errorf("OutputDir = %q, want %q", got.OutputDir, want.OutputDir)
+	}
+	if !slices.Equal(got.Reports, want.Reports) {
+		t.Errorf("Reports = %v, want %v", got.Reports, want.Reports)
+	}
+}
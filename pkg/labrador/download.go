@@ -14,15 +14,20 @@ var (
 	ErrNonRetryable = fmt.Errorf("non-retryable error")
 )
 
+const (
+	defaultTimeout = 30 * time.Second
+)
+
 type DownloadResult struct {
 	Content     []byte
 	ContentType string
 }
 
 func TryDownload(url string) (*DownloadResult, error) {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+	return tryDownload(&http.Client{Timeout: defaultTimeout}, url)
+}
+
+func tryDownload(client *http.Client, url string) (*DownloadResult, error) {
 	resp, err := client.Get(url)
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", ErrUnknown, err)
@@ -1,14 +1,18 @@
 package labrador
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"time"
 
 	"tsumegolang/pkg/concurrency"
 )
 
 const (
-	defaultWorkerCount = 1
+	defaultWorkerCount   = 1
+	defaultResultTimeout = 10 * time.Minute
 )
 
 var (
@@ -23,36 +27,25 @@ type downloadJob struct {
 	Section string
 }
 
-type MultiDownloader struct {
+// Downloader fetches sections of URLs concurrently and writes them under the
+// configured output directory.
+type Downloader struct {
 	workerPool *concurrency.WorkerPool[downloadJob, DownloadRecord]
-	outputDir  string
+	config     Config
 }
 
-type MultiDownloaderSettings struct {
-	RetryCount  int
-	BackoffMs   int
-	WorkerCount int
-	OutputDir   string
-}
-
-func NewMultiDownloader(settings MultiDownloaderSettings) *MultiDownloader {
-	numWorkers := settings.WorkerCount
-	if numWorkers < 1 {
-		numWorkers = defaultWorkerCount
+// NewDownloader builds a Downloader from cfg, which must pass Validate.
+func NewDownloader(cfg Config) (*Downloader, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
 	}
 
-	outputDir := settings.OutputDir
-	if outputDir == "" {
-		outputDir = "."
-	}
-
-	handlerOpts := []DownloadHandlerOption{}
-	if settings.RetryCount > 0 {
-		handlerOpts = append(handlerOpts, WithRetryCount(settings.RetryCount))
-	}
-	if settings.BackoffMs > 0 {
-		handlerOpts = append(handlerOpts, WithBackoff(settings.BackoffMs))
+	handlerOpts := []DownloadHandlerOption{
+		WithRetryCount(cfg.RetryCount),
+		WithBackoff(int(cfg.Backoff.Milliseconds())),
+		WithTimeout(cfg.Timeout),
 	}
+	outputDir := cfg.OutputDir
 
 	job := func(dj downloadJob) concurrency.JobResult[downloadJob, DownloadRecord] {
 		downloader := NewDownloadHandler(handlerOpts...)
@@ -95,17 +88,17 @@ func NewMultiDownloader(settings MultiDownloaderSettings) *MultiDownloader {
 		}
 	}
 
-	return &MultiDownloader{
-		workerPool: concurrency.NewWorkerPool(job, numWorkers),
-		outputDir:  outputDir,
-	}
+	return &Downloader{
+		workerPool: concurrency.NewWorkerPool(job, cfg.Workers),
+		config:     cfg,
+	}, nil
 }
 
-func (md *MultiDownloader) Start() {
-	md.workerPool.Start()
+func (d *Downloader) Start() {
+	d.workerPool.Start()
 }
 
-func (md *MultiDownloader) DownloadSections(sections []Section) []DownloadRecord {
+func (d *Downloader) DownloadSections(sections []Section) []DownloadRecord {
 	var allJobs []downloadJob
 	for _, section := range sections {
 		for _, url := range section.URLs {
@@ -120,7 +113,7 @@ func (md *MultiDownloader) DownloadSections(sections []Section) []DownloadRecord
 	resultChans := make([]chan concurrency.JobResult[downloadJob, DownloadRecord], len(allJobs))
 
 	for i, job := range allJobs {
-		resultCh, err := md.workerPool.Submit(job)
+		resultCh, err := d.workerPool.Submit(job)
 		if err != nil {
 			record := DownloadRecord{
 				Section: job.Section,
@@ -143,10 +136,14 @@ func (md *MultiDownloader) DownloadSections(sections []Section) []DownloadRecord
 		if resultCh == nil {
 			continue
 		}
+		var timeout <-chan time.Time // nil blocks forever when no limit is configured
+		if d.config.ResultTimeout > 0 {
+			timeout = time.After(d.config.ResultTimeout)
+		}
 		select {
 		case result := <-resultCh:
 			results[i] = result
-		case <-time.After(10 * time.Minute):
+		case <-timeout:
 			record := DownloadRecord{
 				Section: allJobs[i].Section,
 				URL:     allJobs[i].URL,
@@ -170,6 +167,39 @@ func (md *MultiDownloader) DownloadSections(sections []Section) []DownloadRecord
 	return records
 }
 
-func (md *MultiDownloader) Shutdown() {
-	md.workerPool.Shutdown()
+// Stats reports the health of the download workers.
+func (d *Downloader) Stats() concurrency.PoolStats {
+	return d.workerPool.Stats()
+}
+
+func (d *Downloader) Shutdown() {
+	d.workerPool.Shutdown(context.Background())
+}
+
+// WriteReports writes every configured report format into the output directory
+// and returns the paths written.
+func (d *Downloader) WriteReports(records []DownloadRecord) ([]string, error) {
+	if err := os.MkdirAll(d.config.OutputDir, 0755); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCreateDir, err)
+	}
+
+	paths := make([]string, 0, len(d.config.Reports))
+	for _, report := range d.config.Reports {
+		var err error
+		var path string
+		switch report {
+		case ReportMarkdown:
+			path = filepath.Join(d.config.OutputDir, "index.md")
+			err = GenerateMarkdownIndex(records, path)
+		case ReportJSON:
+			path = filepath.Join(d.config.OutputDir, "index.json")
+			err = GenerateJSONReport(records, path)
+		}
+		if err != nil {
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+
+	return paths, nil
 }
@@ -3,7 +3,7 @@ package labrador_test
 import (
 	"testing"
 
-	. "tsumegolang/internal/labrador"
+	. "tsumegolang/pkg/labrador"
 )
 
 func TestDetermineFileExtension(t *testing.T) {
@@ -0,0 +1,66 @@
+package labrador
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	ErrWriteJSON = fmt.Errorf("failed to write JSON report")
+)
+
+type jsonReport struct {
+	Generated  time.Time    `json:"generated"`
+	Total      int          `json:"total"`
+	Successful int          `json:"successful"`
+	Failed     int          `json:"failed"`
+	Records    []jsonRecord `json:"records"`
+}
+
+type jsonRecord struct {
+	Section  string `json:"section"`
+	URL      string `json:"url"`
+	FilePath string `json:"file_path,omitempty"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// GenerateJSONReport writes the download records as a machine-readable JSON document.
+func GenerateJSONReport(records []DownloadRecord, outputPath string) error {
+	report := jsonReport{
+		Generated: time.Now(),
+		Total:     len(records),
+		Records:   make([]jsonRecord, len(records)),
+	}
+
+	for i, record := range records {
+		if record.Success {
+			report.Successful++
+		} else {
+			report.Failed++
+		}
+
+		report.Records[i] = jsonRecord{
+			Section:  record.Section,
+			URL:      record.URL,
+			FilePath: record.FilePath,
+			Success:  record.Success,
+		}
+		if record.Error != nil {
+			report.Records[i].Error = record.Error.Error()
+		}
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteJSON, err)
+	}
+
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("%w: %w", ErrWriteJSON, err)
+	}
+
+	return nil
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 	"testing"
 
-	. "tsumegolang/internal/labrador"
+	. "tsumegolang/pkg/labrador"
 )
 
 func TestGenerateMarkdownIndex(t *testing.T) {
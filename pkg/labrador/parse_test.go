@@ -4,7 +4,7 @@ import (
 	"os"
 	"testing"
 
-	. "tsumegolang/internal/labrador"
+	. "tsumegolang/pkg/labrador"
 )
 
 func TestParseURLsFromTextFile(t *testing.T) {
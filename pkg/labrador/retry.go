@@ -0,0 +1,87 @@
+package labrador
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"tsumegolang/pkg/concurrency"
+)
+
+const (
+	defaultRetryCount = 3
+	defaultBackoffMs  = 1000
+)
+
+type DownloadHandler struct {
+	retryCount int
+	backoffMs  int
+	client     *http.Client
+}
+
+type DownloadHandlerOption func(*DownloadHandler)
+
+func NewDownloadHandler(options ...DownloadHandlerOption) *DownloadHandler {
+	handler := &DownloadHandler{
+		retryCount: defaultRetryCount,
+		backoffMs:  defaultBackoffMs,
+		client:     &http.Client{Timeout: defaultTimeout},
+	}
+
+	for _, option := range options {
+		option(handler)
+	}
+
+	return handler
+}
+
+func WithRetryCount(count int) DownloadHandlerOption {
+	return func(handler *DownloadHandler) {
+		if count < 1 {
+			count = 1
+		}
+		handler.retryCount = count
+	}
+}
+
+func WithBackoff(backoff int) DownloadHandlerOption {
+	return func(handler *DownloadHandler) {
+		if backoff < 0 {
+			backoff = 0
+		}
+		handler.backoffMs = backoff
+	}
+}
+
+func WithTimeout(timeout time.Duration) DownloadHandlerOption {
+	return func(handler *DownloadHandler) {
+		if timeout < 0 {
+			timeout = 0
+		}
+		handler.client = &http.Client{Timeout: timeout}
+	}
+}
+
+func (h *DownloadHandler) Download(url string) (*DownloadResult, error) {
+	download := concurrency.Chain(h.tryDownload, concurrency.Retry[string, *DownloadResult](concurrency.RetryPolicy{
+		Attempts: h.retryCount,
+		Backoff:  time.Duration(h.backoffMs) * time.Millisecond,
+		Retryable: func(err error) bool {
+			return !errors.Is(err, ErrNonRetryable)
+		},
+	}))
+
+	result := download(url)
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return result.Output, nil
+}
+
+func (h *DownloadHandler) tryDownload(url string) concurrency.JobResult[string, *DownloadResult] {
+	result, err := tryDownload(h.client, url)
+	if err != nil {
+		return concurrency.JobResult[string, *DownloadResult]{Input: url, Err: err, Status: concurrency.StatusError}
+	}
+	return concurrency.JobResult[string, *DownloadResult]{Input: url, Output: result, Status: concurrency.StatusSuccess}
+}
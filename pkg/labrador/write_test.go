@@ -5,7 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
-	. "tsumegolang/internal/labrador"
+	. "tsumegolang/pkg/labrador"
 )
 
 func TestConvertUrlToFilename(t *testing.T) {